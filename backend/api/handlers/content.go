@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+)
+
+// contentEncodingBase64 二进制字幕内容在JSON中的编码方式
+const contentEncodingBase64 = "base64"
+
+// decodeContent 将请求中的字幕内容转换为字节，encoding为"base64"时先解码
+func decodeContent(content, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(content), nil
+	case contentEncodingBase64:
+		data, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("base64内容解码失败: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("不支持的内容编码: %s", encoding)
+	}
+}
+
+// encodeContent 将生成的字幕转换为响应内容，二进制格式使用base64编码
+func encodeContent(filename string, data []byte) (string, string) {
+	if subtitle.IsBinary(filename) {
+		return base64.StdEncoding.EncodeToString(data), contentEncodingBase64
+	}
+	return string(data), ""
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/exchange"
+	"github.com/frank0/subtitleTranslate/internal/langdetect"
+	"github.com/frank0/subtitleTranslate/internal/language"
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportSubtitle 将字幕导出为供译员使用的交换文件（XLIFF 1.2/2.0、PO、CSV）
+func ExportSubtitle(c *gin.Context) {
+	var req models.ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+	format, err := exchange.Normalize(req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{
+		LostCueThreshold: req.LostCueThreshold,
+		FPS:              req.FPS,
+	})
+	entries, diagnostics, err := parseWith(factory, req.Filename, req.Content, req.ContentEncoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: diagnostics,
+		})
+		return
+	}
+
+	texts := make([]string, len(entries))
+	for i, entry := range entries {
+		texts[i] = entry.Content
+	}
+
+	// 可选的机器翻译预填
+	var prefill []string
+	if req.Prefill {
+		if !allowCredentials(c, models.ApiSettings{ApiKey: req.ApiKey, ApiUrl: req.ApiUrl}) {
+			return
+		}
+		provider, sourceCode, targetCode, err := prefillLanguages(req, texts)
+		if err == nil {
+			if !chargeQuota(c, provider.Name, texts) {
+				return
+			}
+			prefill, err = translateTexts(c, provider.Name, texts, targetCode, sourceCode, models.ApiSettings{
+				ApiKey:    req.ApiKey,
+				ApiSecret: req.ApiSecret,
+				ApiUrl:    req.ApiUrl,
+			})
+		}
+		if err != nil && c.Request.Context().Err() != nil {
+			c.JSON(http.StatusRequestTimeout, models.TranslationResponse{
+				Success: false,
+				Error:   "请求被取消或超时",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.TranslationResponse{
+				Success: false,
+				Error:   "机器翻译预填失败: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	content, err := exchange.Export(format, exchange.Units(entries, prefill), exchange.Meta{
+		Original:       req.Filename,
+		SourceLanguage: req.SourceLanguage,
+		TargetLanguage: req.TargetLanguage,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.TranslationResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	fileBase := strings.TrimSuffix(req.Filename, filepath.Ext(req.Filename))
+	if req.TargetLanguage != "" {
+		fileBase += "_" + req.TargetLanguage
+	}
+	c.JSON(http.StatusOK, models.TranslationResponse{
+		Success: true,
+		Data: &models.TranslationResult{
+			OriginalFilename:   req.Filename,
+			TranslatedFilename: fileBase + exchange.Extension(format),
+			Content:            string(content),
+		},
+		Diagnostics: diagnostics,
+	})
+}
+
+// prefillLanguages 检查预填使用的提供商和语言，返回提供商的源语言和目标语言代码
+func prefillLanguages(req models.ExportRequest, texts []string) (*language.Provider, string, string, error) {
+	provider, err := language.GetProvider(req.Provider)
+	if err != nil {
+		return nil, "", "", err
+	}
+	targetLang, err := language.Normalize(req.TargetLanguage)
+	if err == nil && targetLang == language.Auto {
+		err = fmt.Errorf("预填译文需要指定目标语言")
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	sourceLang, err := language.Normalize(req.SourceLanguage)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if sourceLang == language.Auto && !provider.SupportsAuto {
+		detected := langdetect.Detect(texts)
+		if detected.Confidence < langdetect.MinConfidence {
+			return nil, "", "", fmt.Errorf("提供商%s需要明确的源语言，且无法自动识别", provider.Name)
+		}
+		if sourceLang, err = language.Normalize(detected.Language); err != nil {
+			return nil, "", "", err
+		}
+	}
+	if err := provider.Supports(sourceLang, targetLang); err != nil {
+		return nil, "", "", err
+	}
+	sourceCode, _ := provider.Code(sourceLang)
+	targetCode, _ := provider.Code(targetLang)
+	return provider, sourceCode, targetCode, nil
+}
+
+// ImportSubtitle 将译员返回的交换文件按单元ID合并回原字幕，生成带时间轴的译文字幕
+func ImportSubtitle(c *gin.Context) {
+	var req models.ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{
+		LostCueThreshold: req.LostCueThreshold,
+		FPS:              req.FPS,
+		TargetLanguage:   req.TargetLanguage,
+	})
+	entries, diagnostics, err := parseWith(factory, req.Filename, req.Content, req.ContentEncoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: diagnostics,
+		})
+		return
+	}
+
+	units, err := exchange.Parse(req.TranslationFilename, []byte(req.Translation))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: diagnostics,
+		})
+		return
+	}
+	translated, matchDiagnostics, err := exchange.Match(entries, units)
+	diagnostics = append(diagnostics, matchDiagnostics...)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: diagnostics,
+		})
+		return
+	}
+
+	outputFormat := req.OutputFormat
+	if outputFormat == "" {
+		outputFormat = "translation_only"
+	}
+	subtitle.ApplyTranslations(entries, translated, outputFormat, req.TranslationPosition)
+
+	content, encoding := encodeContent(req.Filename, factory.Build(req.Filename, entries, outputFormat))
+	diagnostics = append(diagnostics, factory.Validate(req.Filename, entries)...)
+
+	c.JSON(http.StatusOK, models.TranslationResponse{
+		Success: true,
+		Data: &models.TranslationResult{
+			OriginalFilename:   req.Filename,
+			TranslatedFilename: outputFilename(req.Filename, req.TargetLanguage, outputFormat, req.TranslationPosition),
+			Content:            content,
+			ContentEncoding:    encoding,
+		},
+		Diagnostics: diagnostics,
+	})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/frank0/subtitleTranslate/internal/auth"
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyHandler 管理服务器API密钥的接口，需要admin权限
+type KeyHandler struct {
+	store *auth.Store
+}
+
+// NewKeyHandler 创建API密钥管理接口
+func NewKeyHandler(store *auth.Store) *KeyHandler {
+	return &KeyHandler{store: store}
+}
+
+// Create 创建API密钥，明文令牌只在响应中返回一次
+func (h *KeyHandler) Create(c *gin.Context) {
+	var req models.CreateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{auth.ScopeTranslate}
+	}
+	if err := auth.ValidateScopes(req.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	key, token, err := h.store.Create(req.Name, req.Scopes, req.AllowServerCredentials)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": models.CreateKeyResponse{
+			APIKeyInfo: keyInfo(*key),
+			Token:      token,
+		},
+	})
+}
+
+// List 列出所有API密钥（不包含令牌）
+func (h *KeyHandler) List(c *gin.Context) {
+	keys := h.store.List()
+	infos := make([]models.APIKeyInfo, len(keys))
+	for i, key := range keys {
+		infos[i] = keyInfo(key)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    infos,
+	})
+}
+
+// Revoke 吊销API密钥，吊销后立即失效
+func (h *KeyHandler) Revoke(c *gin.Context) {
+	key, err := h.store.Revoke(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    keyInfo(*key),
+	})
+}
+
+// keyInfo 返回密钥的公开信息
+func keyInfo(key auth.Key) models.APIKeyInfo {
+	return models.APIKeyInfo{
+		ID:                     key.ID,
+		Name:                   key.Name,
+		Prefix:                 key.Prefix,
+		Scopes:                 key.Scopes,
+		AllowServerCredentials: key.AllowServerCredentials,
+		CreatedAt:              key.CreatedAt,
+		RevokedAt:              key.RevokedAt,
+	}
+}
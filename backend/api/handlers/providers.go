@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/language"
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/retry"
+	"github.com/gin-gonic/gin"
+)
+
+// ListProviders 列出翻译提供商、支持的语言、服务器配置的请求设置和熔断器状态
+func ListProviders(c *gin.Context) {
+	cfg := config.Current()
+	var providers []models.ProviderInfo
+	for _, name := range language.ProviderNames() {
+		p, _ := language.GetProvider(name)
+		limits := cfg.Provider(name)
+		info := models.ProviderInfo{
+			Name:         p.Name,
+			SupportsAuto: p.SupportsAuto,
+			Limits: models.ProviderLimits{
+				Concurrency:       limits.Concurrency,
+				BatchSize:         limits.BatchSize,
+				RequestsPerSecond: limits.RequestsPerSecond,
+				MaxCharacters:     limits.MaxCharacters,
+				ChunkCharacters:   limits.ChunkCharacters,
+				MergeCharacters:   limits.MergeCharacters,
+				Region:            limits.Region,
+				Retry: models.ProviderRetry{
+					MaxAttempts:      limits.Retry.MaxAttempts,
+					InitialBackoffMs: limits.Retry.InitialBackoffMs,
+					MaxBackoffMs:     limits.Retry.MaxBackoffMs,
+					FailureThreshold: limits.Retry.FailureThreshold,
+					CooldownSeconds:  limits.Retry.CooldownSeconds,
+				},
+			},
+			Circuit: retry.State(name),
+		}
+		for _, code := range p.Languages() {
+			l, _ := language.Get(code)
+			info.Languages = append(info.Languages, models.LanguageInfo{
+				Code:   l.Code,
+				Name:   l.Name,
+				Native: l.Native,
+			})
+		}
+		providers = append(providers, info)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    providers,
+	})
+}
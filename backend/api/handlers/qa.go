@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/qa"
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+	"github.com/gin-gonic/gin"
+)
+
+// CheckQuality 处理字幕质量检查请求
+func CheckQuality(c *gin.Context) {
+	var req models.QARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.QAResponse{
+			Success: false,
+			Error:   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+
+	factory := subtitle.NewParserFactory()
+	entries, diagnostics, err := parseWith(factory, req.Filename, req.Content, req.ContentEncoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.QAResponse{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: diagnostics,
+		})
+		return
+	}
+
+	// 提供原文时同时检查译文
+	var source []models.SubtitleEntry
+	if req.SourceContent != "" {
+		sourceFilename := req.SourceFilename
+		if sourceFilename == "" {
+			sourceFilename = req.Filename
+		}
+		source, _, err = parseWith(factory, sourceFilename, req.SourceContent, req.SourceContentEncoding)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.QAResponse{
+				Success: false,
+				Error:   "原文" + err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, models.QAResponse{
+		Success:     true,
+		Data:        qa.Check(entries, source, qa.FromRequest(req.Options, req.TargetLanguage)),
+		Diagnostics: diagnostics,
+	})
+}
+
+// parseWith 使用解析器工厂解析字幕内容，encoding为请求中的内容编码
+func parseWith(factory *subtitle.ParserFactory, filename, content, encoding string) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	if _, err := factory.GetParser(filename); err != nil {
+		return nil, nil, err
+	}
+	data, err := decodeContent(content, encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, diagnostics, err := factory.Parse(filename, data)
+	if err != nil {
+		return nil, diagnostics, fmt.Errorf("解析字幕文件失败: %w", err)
+	}
+	return entries, diagnostics, nil
+}
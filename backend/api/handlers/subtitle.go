@@ -1,15 +1,29 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/frank0/subtitleTranslate/api/middleware"
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/langdetect"
+	"github.com/frank0/subtitleTranslate/internal/language"
+	"github.com/frank0/subtitleTranslate/internal/metrics"
 	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/qa"
+	"github.com/frank0/subtitleTranslate/internal/reflow"
+	"github.com/frank0/subtitleTranslate/internal/retry"
+	"github.com/frank0/subtitleTranslate/internal/sdh"
+	"github.com/frank0/subtitleTranslate/internal/secrets"
 	"github.com/frank0/subtitleTranslate/internal/services"
 	"github.com/frank0/subtitleTranslate/internal/subtitle"
-	"github.com/frank0/subtitleTranslate/internal/utils"
+	"github.com/frank0/subtitleTranslate/internal/timing"
+	"github.com/frank0/subtitleTranslate/internal/usage"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,15 +38,60 @@ func TranslateSubtitle(c *gin.Context) {
 		return
 	}
 
+	// 规范化语言代码，并在解析文件前检查提供商是否支持
+	provider, err := language.GetProvider(req.Provider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+	targetLang, err := language.Normalize(req.TargetLanguage)
+	if err == nil && targetLang == language.Auto {
+		err = fmt.Errorf("目标语言不能为auto")
+	}
+	if err == nil {
+		_, err = provider.Code(targetLang)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   "目标语言无效: " + err.Error(),
+		})
+		return
+	}
+	sourceLang, err := language.Normalize(req.SourceLanguage)
+	if err == nil && sourceLang != language.Auto {
+		err = provider.Supports(sourceLang, targetLang)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   "源语言无效: " + err.Error(),
+		})
+		return
+	}
+
 	// 将API设置传递给翻译服务
 	apiSettings := models.ApiSettings{
 		ApiKey:    req.ApiKey,
 		ApiSecret: req.ApiSecret,
 		ApiUrl:    req.ApiUrl,
 	}
+	if !req.DryRun && !allowCredentials(c, apiSettings) {
+		return
+	}
 
 	// 创建解析器工厂
-	factory := subtitle.NewParserFactory()
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{
+		Strict:           req.Strict,
+		LostCueThreshold: req.LostCueThreshold,
+		FPS:              req.FPS,
+		SAMISourceClass:  req.SAMISourceClass,
+		SAMITargetClass:  req.SAMITargetClass,
+		TargetLanguage:   targetLang,
+	})
 
 	// 获取文件扩展名
 	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(req.Filename), "."))
@@ -44,9 +103,8 @@ func TranslateSubtitle(c *gin.Context) {
 		return
 	}
 
-	// 获取合适的解析器
-	parser, err := factory.GetParser(req.Filename)
-	if err != nil {
+	// 检查是否有合适的解析器
+	if _, err := factory.GetParser(req.Filename); err != nil {
 		c.JSON(http.StatusBadRequest, models.TranslationResponse{
 			Success: false,
 			Error:   "不支持的文件格式: " + ext,
@@ -55,24 +113,106 @@ func TranslateSubtitle(c *gin.Context) {
 	}
 
 	// 解析字幕文件
-	entries, err := parser.Parse(req.Content)
+	data, err := decodeContent(req.Content, req.ContentEncoding)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.TranslationResponse{
 			Success: false,
-			Error:   "解析字幕文件失败: " + err.Error(),
+			Error:   err.Error(),
+		})
+		return
+	}
+	entries, diagnostics, err := factory.Parse(req.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success:     false,
+			Error:       "解析字幕文件失败: " + err.Error(),
+			Diagnostics: diagnostics,
 		})
 		return
 	}
 
+	// 翻译前调整时间轴
+	if req.Timing != nil {
+		entries, err = timing.Apply(entries, *req.Timing)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.TranslationResponse{
+				Success: false,
+				Error:   "调整时间轴失败: " + err.Error(),
+			})
+			return
+		}
+	}
+
 	// 提取所有字幕文本
 	texts := make([]string, len(entries))
 	for i, entry := range entries {
 		texts[i] = entry.Content
 	}
 
+	// 拆分听障注释和说话人标签，只把需要翻译的部分送去翻译
+	var sdhPlan *sdh.Plan
+	if req.SDH != nil {
+		opts, err := sdh.FromRequest(*req.SDH)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.TranslationResponse{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+		entries, sdhPlan = sdh.Prepare(entries, opts)
+		texts = sdhPlan.Texts()
+	}
+
+	// 离线识别源语言，提供商不支持自动检测时使用识别结果
+	detected := langdetect.Detect(texts)
+	effectiveSource := sourceLang
+	if sourceLang == language.Auto && detected.Confidence >= langdetect.MinConfidence {
+		if code, err := language.Normalize(detected.Language); err == nil {
+			effectiveSource = code
+			if !provider.SupportsAuto {
+				sourceLang = code
+			}
+		}
+	}
+	if err := provider.Supports(sourceLang, targetLang); err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 转换为提供商的语言代码
+	sourceCode, _ := provider.Code(sourceLang)
+	targetCode, _ := provider.Code(targetLang)
+
+	// 试运行：只估算计费字符数和费用，不调用提供商
+	if req.DryRun {
+		metrics.TranslationJobs.Inc(provider.Name, "estimated")
+		estimate := usage.Estimate(texts, language.ProviderNames(), config.Current().Pricing)
+		c.JSON(http.StatusOK, models.TranslationResponse{
+			Success: true,
+			Data: &models.TranslationResult{
+				OriginalFilename: req.Filename,
+				DetectedLanguage: &models.DetectedLanguage{
+					Language:   detected.Language,
+					Script:     detected.Script,
+					Confidence: detected.Confidence,
+				},
+				SourceLanguage: effectiveSource,
+				Skipped:        effectiveSource == targetLang,
+				Estimate:       &estimate,
+			},
+			Diagnostics: diagnostics,
+		})
+		return
+	}
+
 	// 根据提供商选择翻译服务
 	var translatedTexts []string
 	var translateErr error
+	skipped := false
 
 	// 检查请求上下文是否被取消
 	select {
@@ -85,24 +225,41 @@ func TranslateSubtitle(c *gin.Context) {
 	default:
 	}
 
-	switch strings.ToLower(req.Provider) {
-	case "volce":
-		translatedTexts, translateErr = services.TranslateWithVolcengine(texts, req.TargetLanguage, req.SourceLanguage, apiSettings)
-	case "google":
-		translatedTexts, translateErr = services.TranslateWithGoogle(texts, req.TargetLanguage, req.SourceLanguage, apiSettings)
-	case "tencent":
-		translatedTexts, translateErr = services.TranslateWithTencent(texts, req.TargetLanguage, req.SourceLanguage, apiSettings)
-	case "aliyun":
-		translatedTexts, translateErr = services.TranslateWithAliyun(texts, req.TargetLanguage, req.SourceLanguage, apiSettings)
+	switch {
+	case effectiveSource == targetLang:
+		// 源语言与目标语言相同，无需翻译
+		translatedTexts = append([]string(nil), texts...)
+		skipped = true
 	default:
-		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+		if !chargeQuota(c, provider.Name, texts) {
+			return
+		}
+		// 未指定源语言时部分提供商（Google）会返回识别出的语言
+		c.Request = c.Request.WithContext(langdetect.WithProviderDetection(c.Request.Context()))
+		translatedTexts, translateErr = translateTexts(c, provider.Name, texts, targetCode, sourceCode, apiSettings)
+	}
+
+	if translateErr != nil && c.Request.Context().Err() != nil {
+		// 客户端断开或请求超时，剩余的批次已经停止发送
+		metrics.TranslationJobs.Inc(provider.Name, "canceled")
+		c.JSON(http.StatusRequestTimeout, models.TranslationResponse{
+			Success: false,
+			Error:   "请求被取消或超时",
+		})
+		return
+	}
+	if errors.Is(translateErr, retry.ErrCircuitOpen) {
+		// 提供商连续失败已熔断，冷却期后再试
+		metrics.TranslationJobs.Inc(provider.Name, "failed")
+		middleware.SetRetryAfter(c, time.Duration(config.Current().Provider(provider.Name).Retry.CooldownSeconds)*time.Second)
+		c.JSON(http.StatusServiceUnavailable, models.TranslationResponse{
 			Success: false,
-			Error:   "不支持的翻译提供商: " + req.Provider,
+			Error:   "翻译失败: " + translateErr.Error(),
 		})
 		return
 	}
-
 	if translateErr != nil {
+		metrics.TranslationJobs.Inc(provider.Name, "failed")
 		c.JSON(http.StatusInternalServerError, models.TranslationResponse{
 			Success: false,
 			Error:   "翻译失败: " + translateErr.Error(),
@@ -110,58 +267,178 @@ func TranslateSubtitle(c *gin.Context) {
 		return
 	}
 
-	// 更新字幕内容
-	for i, entry := range entries {
-		translated := translatedTexts[i]
-
-		switch req.OutputFormat {
-		case "original_and_translation":
-			if req.TranslationPosition == "above" {
-				entry.Content = translated + "\n" + entry.Content
-			} else {
-				entry.Content = entry.Content + "\n" + translated
-			}
-		default: // "translation_only"
-			entry.Content = translated
-		}
-		entries[i] = entry
+	// 离线识别不可靠时，使用提供商识别出的语言作为实际的源语言
+	providerDetected := langdetect.ProviderLanguage(c.Request.Context())
+	if code, err := language.Normalize(providerDetected); err == nil && code != language.Auto && effectiveSource == language.Auto {
+		effectiveSource = code
 	}
 
-	// 根据文件扩展名选择构建器
-	lowerFilename := strings.ToLower(req.Filename)
-	var translatedContent string
-	if strings.HasSuffix(lowerFilename, ".vtt") {
-		translatedContent = utils.BuildVTT(entries, req.OutputFormat)
-	} else if strings.HasSuffix(lowerFilename, ".ass") || strings.HasSuffix(lowerFilename, ".ssa") {
-		translatedContent = utils.BuildASS(entries, req.OutputFormat)
+	if skipped {
+		metrics.TranslationJobs.Inc(provider.Name, "skipped")
 	} else {
-		translatedContent = utils.BuildSRT(entries, req.OutputFormat)
+		metrics.TranslationJobs.Inc(provider.Name, "success")
+		metrics.CuesTranslated.Add(float64(len(texts)), provider.Name, effectiveSource, targetLang)
+		metrics.CharactersTranslated.Add(float64(sourceCharacters(texts)), provider.Name, effectiveSource, targetLang)
 	}
 
-	// 生成翻译后的文件名
-	fileExt := filepath.Ext(req.Filename)
-	fileBase := strings.TrimSuffix(req.Filename, fileExt)
-	var translatedFilename string
+	if sdhPlan != nil {
+		translatedTexts = sdhPlan.Apply(translatedTexts)
+	}
 
-	if req.OutputFormat == "original_and_translation" {
-		// 双语字幕
-		if req.TranslationPosition == "above" {
-			translatedFilename = fmt.Sprintf("%s_%s_bilingual_above%s", fileBase, req.TargetLanguage, fileExt)
-		} else {
-			translatedFilename = fmt.Sprintf("%s_%s_bilingual%s", fileBase, req.TargetLanguage, fileExt)
+	// 按目标语言规则重新断行，并检查阅读速度
+	var readingSpeedWarnings []models.ReadingSpeedWarning
+	if req.Reflow != nil {
+		opts := reflow.FromRequest(*req.Reflow, targetLang)
+		for i, entry := range entries {
+			translatedTexts[i] = reflow.Wrap(translatedTexts[i], opts)
+			entry.Content = translatedTexts[i]
+			if w := reflow.Check(entry, opts); w != nil {
+				readingSpeedWarnings = append(readingSpeedWarnings, *w)
+			}
 		}
-	} else {
-		// 仅译文
-		translatedFilename = fmt.Sprintf("%s_%s%s", fileBase, req.TargetLanguage, fileExt)
 	}
 
+	// 质量检查基于仅含译文的条目
+	var qaReport *models.QAReport
+	if req.QA != nil {
+		translatedEntries := make([]models.SubtitleEntry, len(entries))
+		for i, entry := range entries {
+			entry.Content = translatedTexts[i]
+			translatedEntries[i] = entry
+		}
+		qaReport = qa.Check(translatedEntries, entries, qa.FromRequest(*req.QA, targetLang))
+	}
+
+	// 更新字幕内容
+	subtitle.ApplyTranslations(entries, translatedTexts, req.OutputFormat, req.TranslationPosition)
+
+	// 根据文件扩展名选择构建器
+	translatedContent, contentEncoding := encodeContent(req.Filename, factory.Build(req.Filename, entries, req.OutputFormat))
+	diagnostics = append(diagnostics, factory.Validate(req.Filename, entries)...)
+
+	// 生成翻译后的文件名
+	translatedFilename := outputFilename(req.Filename, req.TargetLanguage, req.OutputFormat, req.TranslationPosition)
+
 	// 返回翻译结果
 	c.JSON(http.StatusOK, models.TranslationResponse{
 		Success: true,
 		Data: &models.TranslationResult{
-			OriginalFilename:   req.Filename,
-			TranslatedFilename: translatedFilename,
-			Content:            translatedContent,
+			OriginalFilename:     req.Filename,
+			TranslatedFilename:   translatedFilename,
+			Content:              translatedContent,
+			ContentEncoding:      contentEncoding,
+			ReadingSpeedWarnings: readingSpeedWarnings,
+			QA:                   qaReport,
+			DetectedLanguage: &models.DetectedLanguage{
+				Language:   detected.Language,
+				Script:     detected.Script,
+				Confidence: detected.Confidence,
+				Provider:   providerDetected,
+			},
+			SourceLanguage: effectiveSource,
+			Skipped:        skipped,
 		},
+		Diagnostics: diagnostics,
 	})
 }
+
+// translateTexts 调用指定提供商翻译文本，语言代码为提供商的代码
+// 重复和空白的文本只翻译一次（或不翻译），成功后在用量记录中登记实际发送的字符数
+func translateTexts(c *gin.Context, providerName string, texts []string, targetCode, sourceCode string, settings models.ApiSettings) ([]string, error) {
+	unique, positions := services.Unique(texts)
+	lookups := 0
+	for _, pos := range positions {
+		if pos >= 0 {
+			lookups++
+		}
+	}
+	metrics.DedupeLookups.Add(float64(lookups), providerName)
+	metrics.DedupeHits.Add(float64(lookups-len(unique)), providerName)
+	if len(unique) == 0 {
+		return append([]string(nil), texts...), nil
+	}
+
+	var translated []string
+	var err error
+	switch providerName {
+	case "volce":
+		translated, err = services.TranslateWithVolcengine(c.Request.Context(), unique, targetCode, sourceCode, settings)
+	case "google":
+		translated, err = services.TranslateWithGoogle(c.Request.Context(), unique, targetCode, sourceCode, settings)
+	case "tencent":
+		translated, err = services.TranslateWithTencent(c.Request.Context(), unique, targetCode, sourceCode, settings)
+	case "aliyun":
+		translated, err = services.TranslateWithAliyun(c.Request.Context(), unique, targetCode, sourceCode, settings)
+	default:
+		return nil, fmt.Errorf("不支持的翻译提供商: %s", providerName)
+	}
+	if err != nil {
+		// 提供商的错误信息会返回给客户端，隐藏其中的凭据
+		return nil, secrets.RedactError(err, settings.ApiKey, settings.ApiSecret)
+	}
+	middleware.RecordSent(c, providerName, services.BillableCharacters(providerName, unique))
+	return services.Expand(texts, translated, positions), nil
+}
+
+// allowCredentials 请求未携带自己的提供商密钥时，检查令牌是否允许使用服务器配置的密钥
+// 不允许时返回403并返回false；只指定apiUrl而不提供apiKey时返回400，服务器的密钥不会发送到调用方指定的地址
+func allowCredentials(c *gin.Context, settings models.ApiSettings) bool {
+	if settings.ApiKey == "" && settings.ApiUrl != "" {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   "指定apiUrl时必须同时提供apiKey，服务器配置的密钥只能用于服务器配置的地址",
+		})
+		return false
+	}
+	if settings.ApiKey != "" || middleware.AllowsServerCredentials(c) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, models.TranslationResponse{
+		Success: false,
+		Error:   "该API令牌不允许使用服务器配置的翻译提供商密钥，请在请求中提供apiKey/apiSecret",
+	})
+	return false
+}
+
+// chargeQuota 调用提供商前按源文字符数检查并记录配额
+// 配额不足时返回429和Retry-After并返回false
+func chargeQuota(c *gin.Context, providerName string, texts []string) bool {
+	err := middleware.ChargeCharacters(c, providerName, sourceCharacters(texts))
+	if err == nil {
+		return true
+	}
+	var quotaErr *middleware.QuotaError
+	if errors.As(err, &quotaErr) {
+		middleware.SetRetryAfter(c, quotaErr.RetryAfter)
+	}
+	c.JSON(http.StatusTooManyRequests, models.TranslationResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+	return false
+}
+
+// sourceCharacters 统计源文字符数
+func sourceCharacters(texts []string) int64 {
+	var characters int64
+	for _, text := range texts {
+		characters += int64(utf8.RuneCountInString(text))
+	}
+	return characters
+}
+
+// outputFilename 生成翻译后的文件名
+func outputFilename(filename, targetLanguage, outputFormat, position string) string {
+	fileExt := filepath.Ext(filename)
+	fileBase := strings.TrimSuffix(filename, fileExt)
+
+	if outputFormat == "original_and_translation" {
+		// 双语字幕
+		if position == "above" {
+			return fmt.Sprintf("%s_%s_bilingual_above%s", fileBase, targetLanguage, fileExt)
+		}
+		return fmt.Sprintf("%s_%s_bilingual%s", fileBase, targetLanguage, fileExt)
+	}
+	// 仅译文
+	return fmt.Sprintf("%s_%s%s", fileBase, targetLanguage, fileExt)
+}
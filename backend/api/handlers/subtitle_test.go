@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTranslateSubtitleCanceled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 模拟响应缓慢的提供商，直到客户端断开才返回
+	release := make(chan struct{})
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer provider.Close()
+	defer close(release)
+
+	body, _ := json.Marshal(models.TranslationRequest{
+		Filename:       "test.srt",
+		Content:        "1\n00:00:01,000 --> 00:00:02,000\n你好，世界\n",
+		SourceLanguage: "zh",
+		TargetLanguage: "en",
+		Provider:       "google",
+		OutputFormat:   "translation_only",
+		ApiKey:         "test",
+		ApiUrl:         provider.URL,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/subtitle/translate", bytes.NewReader(body)).WithContext(ctx)
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	TranslateSubtitle(c)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("取消后%v才返回", elapsed)
+	}
+	if w.Code != http.StatusRequestTimeout {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusRequestTimeout, w.Body.String())
+	}
+}
+
+func TestTranslateSubtitleRejectsApiUrlWithoutKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 调用方指定的地址不应收到任何请求，否则服务器的密钥会被发送出去
+	called := false
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer provider.Close()
+
+	body, _ := json.Marshal(models.TranslationRequest{
+		Filename:       "test.srt",
+		Content:        "1\n00:00:01,000 --> 00:00:02,000\n你好，世界\n",
+		SourceLanguage: "zh",
+		TargetLanguage: "en",
+		Provider:       "google",
+		OutputFormat:   "translation_only",
+		ApiUrl:         provider.URL,
+	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/subtitle/translate", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	TranslateSubtitle(c)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	if called {
+		t.Error("未携带apiKey时不应请求调用方指定的apiUrl")
+	}
+}
+
+func TestTranslateSubtitleProviderDetectedLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 字幕只有数字和符号，离线识别不出语言，使用Google识别出的语言
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"translations": [{"translatedText": "42 !", "detectedSourceLanguage": "fr"}]}}`))
+	}))
+	defer provider.Close()
+
+	body, _ := json.Marshal(models.TranslationRequest{
+		Filename:       "test.srt",
+		Content:        "1\n00:00:01,000 --> 00:00:02,000\n42 !\n",
+		SourceLanguage: "auto",
+		TargetLanguage: "en",
+		Provider:       "google",
+		OutputFormat:   "translation_only",
+		ApiKey:         "test",
+		ApiUrl:         provider.URL,
+	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/subtitle/translate", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	TranslateSubtitle(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp models.TranslationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Data.DetectedLanguage; got == nil || got.Provider != "fr" || got.Language != "" {
+		t.Errorf("detectedLanguage = %+v，期望 provider 为 fr", got)
+	}
+	if resp.Data.SourceLanguage != "fr" {
+		t.Errorf("sourceLanguage = %q，期望 fr", resp.Data.SourceLanguage)
+	}
+}
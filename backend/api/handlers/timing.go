@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+	"github.com/frank0/subtitleTranslate/internal/timing"
+	"github.com/gin-gonic/gin"
+)
+
+// AdjustTiming 处理字幕时间轴调整请求（平移、缩放、帧率转换、两点同步）
+func AdjustTiming(c *gin.Context) {
+	var req models.TimingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{FPS: req.FPS})
+	entries, diagnostics, err := parseWith(factory, req.Filename, req.Content, req.ContentEncoding)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success:     false,
+			Error:       err.Error(),
+			Diagnostics: diagnostics,
+		})
+		return
+	}
+
+	entries, err = timing.Apply(entries, req.Timing)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.TranslationResponse{
+			Success: false,
+			Error:   "调整时间轴失败: " + err.Error(),
+		})
+		return
+	}
+
+	content, encoding := encodeContent(req.Filename, factory.Build(req.Filename, entries, "translation_only"))
+	diagnostics = append(diagnostics, factory.Validate(req.Filename, entries)...)
+	fileExt := filepath.Ext(req.Filename)
+	fileBase := strings.TrimSuffix(req.Filename, fileExt)
+
+	c.JSON(http.StatusOK, models.TranslationResponse{
+		Success: true,
+		Data: &models.TranslationResult{
+			OriginalFilename:   req.Filename,
+			TranslatedFilename: fileBase + "_retimed" + fileExt,
+			Content:            content,
+			ContentEncoding:    encoding,
+		},
+		Diagnostics: diagnostics,
+	})
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/api/middleware"
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/auth"
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageHandler 查询配额和用量的接口
+type UsageHandler struct {
+	limiter *middleware.Limiter
+}
+
+// NewUsageHandler 创建用量查询接口
+func NewUsageHandler(limiter *middleware.Limiter) *UsageHandler {
+	return &UsageHandler{limiter: limiter}
+}
+
+// Get 返回调用方的配额和用量
+// admin密钥可以通过 client 参数查询其他客户端；from、to（2006-01-02）指定明细的日期范围，默认为本月
+func (h *UsageHandler) Get(c *gin.Context) {
+	client, ok := usageClient(c)
+	if !ok {
+		return
+	}
+	if client == "" {
+		client = middleware.ClientID(c)
+	}
+
+	now := time.Now()
+	today := usage.Day(now)
+	from, to, ok := dateRange(c, now)
+	if !ok {
+		return
+	}
+
+	limits := h.limiter.Limits()
+	store := h.limiter.Usage()
+	summary := models.UsageSummary{
+		Client:                client,
+		RequestsPerMinute:     limits.RequestsPerMinute,
+		DailyCharacters:       store.Characters(client, today, today),
+		DailyCharacterLimit:   limits.DailyCharacters,
+		MonthlyCharacters:     store.Characters(client, middleware.MonthStart(now), today),
+		MonthlyCharacterLimit: limits.MonthlyCharacters,
+		Records:               []models.UsageRecord{},
+	}
+	for _, r := range store.Records(client, from, to) {
+		summary.Records = append(summary.Records, models.UsageRecord(r))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    summary,
+	})
+}
+
+// Ledger 导出用量台账（CSV），按天、客户端、提供商记录请求数、计入配额的字符数、实际发送的字符数和费用
+// admin密钥默认导出所有客户端，其他密钥只能导出自己的用量；from、to 默认为本月
+func (h *UsageHandler) Ledger(c *gin.Context) {
+	client, ok := usageClient(c)
+	if !ok {
+		return
+	}
+	if key := middleware.KeyFromContext(c); client == "" && (key == nil || !key.HasScope(auth.ScopeAdmin)) {
+		client = middleware.ClientID(c)
+	}
+	from, to, ok := dateRange(c, time.Now())
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := usage.WriteCSV(&buf, h.limiter.Usage().Records(client, from, to), config.Current().Pricing); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "导出用量台账失败: " + err.Error(),
+		})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="usage_%s_%s.csv"`, from, to))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// usageClient 返回 client 参数指定的客户端，查询其他客户端需要admin权限
+// 未指定时返回空字符串；没有权限时返回403并返回false
+func usageClient(c *gin.Context) (string, bool) {
+	other := c.Query("client")
+	if other == "" || other == middleware.ClientID(c) {
+		return other, true
+	}
+	if key := middleware.KeyFromContext(c); key == nil || !key.HasScope(auth.ScopeAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "查询其他客户端的用量需要admin权限",
+		})
+		return "", false
+	}
+	return other, true
+}
+
+// dateRange 读取 from、to 参数（2006-01-02，UTC），默认为本月；格式无效时返回400并返回false
+func dateRange(c *gin.Context, now time.Time) (string, string, bool) {
+	from, to := c.DefaultQuery("from", middleware.MonthStart(now)), c.DefaultQuery("to", usage.Day(now))
+	for _, day := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "日期格式无效，应为 2006-01-02: " + day,
+			})
+			return "", "", false
+		}
+	}
+	return from, to, true
+}
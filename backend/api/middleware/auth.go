@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyContextKey 认证通过的密钥在gin上下文中的键
+const apiKeyContextKey = "apiKey"
+
+// Auth 校验 Authorization: Bearer 令牌并要求指定的权限范围
+// store为nil时不启用认证，所有请求直接放行
+func Auth(store *auth.Store, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.Next()
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="subtitleTranslate"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "缺少API令牌，请在Authorization头中提供 Bearer 令牌",
+			})
+			return
+		}
+
+		key, err := store.Authenticate(strings.TrimSpace(token))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="subtitleTranslate", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		if !key.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "API令牌没有" + scope + "权限",
+			})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// KeyFromContext 返回认证通过的密钥，未启用认证时返回nil
+func KeyFromContext(c *gin.Context) *auth.Key {
+	if v, ok := c.Get(apiKeyContextKey); ok {
+		if key, ok := v.(*auth.Key); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// AllowsServerCredentials 判断请求是否可以使用服务器配置的提供商密钥
+// 未启用认证时保持原有行为；启用认证时需要密钥允许使用服务器凭据，admin密钥默认允许
+func AllowsServerCredentials(c *gin.Context) bool {
+	if _, authenticated := c.Get(apiKeyContextKey); !authenticated {
+		return true
+	}
+	key := KeyFromContext(c)
+	return key != nil && (key.AllowServerCredentials || key.HasScope(auth.ScopeAdmin))
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store, err := auth.Open(filepath.Join(t.TempDir(), "keys.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, translateToken, err := store.Create("translate", []string{auth.ScopeTranslate}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, adminToken, err := store.Create("admin", []string{auth.ScopeAdmin}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, revokedToken, err := store.Create("revoked", []string{auth.ScopeAdmin}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Revoke(revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		store      *auth.Store
+		scope      string
+		header     string
+		wantStatus int
+		wantServer bool // 是否允许使用服务器配置的提供商密钥
+	}{
+		{name: "未启用认证", scope: auth.ScopeAdmin, wantStatus: http.StatusOK, wantServer: true},
+		{name: "缺少令牌", store: store, scope: auth.ScopeTranslate, wantStatus: http.StatusUnauthorized},
+		{name: "不是Bearer令牌", store: store, scope: auth.ScopeTranslate, header: "Basic " + translateToken, wantStatus: http.StatusUnauthorized},
+		{name: "无效令牌", store: store, scope: auth.ScopeTranslate, header: "Bearer stk_wrong", wantStatus: http.StatusUnauthorized},
+		{name: "已吊销", store: store, scope: auth.ScopeTranslate, header: "Bearer " + revokedToken, wantStatus: http.StatusUnauthorized},
+		{name: "权限不足", store: store, scope: auth.ScopeAdmin, header: "Bearer " + translateToken, wantStatus: http.StatusForbidden},
+		{name: "translate权限", store: store, scope: auth.ScopeTranslate, header: "Bearer " + translateToken, wantStatus: http.StatusOK},
+		{name: "admin包含translate权限", store: store, scope: auth.ScopeTranslate, header: "Bearer " + adminToken, wantStatus: http.StatusOK, wantServer: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var allowsServer bool
+			router := gin.New()
+			router.GET("/", Auth(tt.store, tt.scope), func(c *gin.Context) {
+				allowsServer = AllowsServerCredentials(c)
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d，期望 %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401响应缺少WWW-Authenticate头")
+			}
+			if tt.wantStatus == http.StatusOK && allowsServer != tt.wantServer {
+				t.Errorf("AllowsServerCredentials = %v，期望 %v", allowsServer, tt.wantServer)
+			}
+		})
+	}
+}
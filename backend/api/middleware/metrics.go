@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 按路由记录请求数和处理时间
+// 路由使用注册时的模板（例如 /api/keys/:id），未匹配的请求（包括前端静态文件）记为 other
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "other"
+		}
+		method := c.Request.Method
+		metrics.HTTPRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// limiterContextKey 限流器在gin上下文中的键
+const limiterContextKey = "limiter"
+
+// maxIdleBuckets 令牌桶数量超过该值时清理已回满的桶
+const maxIdleBuckets = 1024
+
+// Limiter 按客户端（API密钥或IP）限制请求速率，并检查每日/每月源文字符配额
+type Limiter struct {
+	limits config.LimitsConfig
+	usage  *usage.Store
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket 令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter 创建限流器，用量记录在store中
+func NewLimiter(limits config.LimitsConfig, store *usage.Store) *Limiter {
+	l := &Limiter{
+		usage:   store,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits 更新限流和配额配置，配置重新加载时调用
+// 已有的令牌桶保留，按新的速率继续补充；用量记录文件不随之改变
+func (l *Limiter) SetLimits(limits config.LimitsConfig) {
+	if limits.Burst <= 0 {
+		limits.Burst = limits.RequestsPerMinute
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+}
+
+// Limits 返回限流和配额配置
+func (l *Limiter) Limits() config.LimitsConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limits
+}
+
+// Usage 返回用量记录
+func (l *Limiter) Usage() *usage.Store {
+	return l.usage
+}
+
+// QuotaError 字符配额不足
+type QuotaError struct {
+	Period     string        // "daily" 或 "monthly"
+	Limit      int64         // 配额
+	Used       int64         // 已使用
+	Requested  int64         // 本次请求需要的字符数
+	RetryAfter time.Duration // 配额重置前的等待时间
+}
+
+func (e *QuotaError) Error() string {
+	period := "每日"
+	if e.Period == "monthly" {
+		period = "每月"
+	}
+	if e.Requested > e.Limit {
+		return fmt.Sprintf("本次请求需要%d字符，超过%s配额%d字符", e.Requested, period, e.Limit)
+	}
+	return fmt.Sprintf("%s字符配额不足：已使用%d/%d字符，本次请求需要%d字符", period, e.Used, e.Limit, e.Requested)
+}
+
+// allow 从客户端的令牌桶中取一个令牌，不足时返回需要等待的时间
+func (l *Limiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limits.RequestsPerMinute <= 0 {
+		return true, 0
+	}
+	rate := float64(l.limits.RequestsPerMinute) / 60 // 每秒补充的令牌数
+	burst := float64(l.limits.Burst)
+	now := l.now()
+	if len(l.buckets) >= maxIdleBuckets {
+		for id, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*rate >= burst {
+				delete(l.buckets, id)
+			}
+		}
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// Charge 检查配额并记录本次请求发送给提供商的源文字符数
+// 配额不足时返回 *QuotaError，不记录用量
+func (l *Limiter) Charge(client, provider string, characters int64) error {
+	now := l.now().UTC()
+	today := usage.Day(now)
+
+	// 检查和记录需要原子完成，避免并发请求同时通过检查
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit := l.limits.DailyCharacters; limit > 0 {
+		used := l.usage.Characters(client, today, today)
+		if used+characters > limit {
+			next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+			return &QuotaError{Period: "daily", Limit: limit, Used: used, Requested: characters, RetryAfter: next.Sub(now)}
+		}
+	}
+	if limit := l.limits.MonthlyCharacters; limit > 0 {
+		used := l.usage.Characters(client, MonthStart(now), today)
+		if used+characters > limit {
+			next := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			return &QuotaError{Period: "monthly", Limit: limit, Used: used, Requested: characters, RetryAfter: next.Sub(now)}
+		}
+	}
+	// 只更新内存中的记录，文件由 usage.Store.FlushEvery 定期写入，不在持有锁时做磁盘I/O
+	l.usage.Add(now, client, provider, characters)
+	return nil
+}
+
+// RecordSent 记录翻译成功后实际发送给提供商的字符数
+func (l *Limiter) RecordSent(client, provider string, characters int64) {
+	l.usage.AddSent(l.now(), client, provider, characters)
+}
+
+// MonthStart 返回时间所在月份第一天的日期（UTC）
+func MonthStart(t time.Time) string {
+	t = t.UTC()
+	return usage.Day(time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC))
+}
+
+// ClientID 返回请求的客户端标识：认证通过时为API密钥ID，否则为 ip:客户端地址
+func ClientID(c *gin.Context) string {
+	if key := KeyFromContext(c); key != nil {
+		return key.ID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimit 按客户端限制请求速率，超出时返回429和Retry-After
+// 同时把限流器放入上下文，供处理函数在调用提供商前检查字符配额；limiter为nil时不限制
+func RateLimit(limiter *Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		c.Set(limiterContextKey, limiter)
+
+		if ok, wait := limiter.allow(ClientID(c)); !ok {
+			SetRetryAfter(c, wait)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("请求过于频繁，每分钟最多%d次，请稍后重试", limiter.Limits().RequestsPerMinute),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ChargeCharacters 在调用提供商前检查并记录字符配额
+// 配额不足时返回 *QuotaError；未启用限流时直接返回nil
+func ChargeCharacters(c *gin.Context, provider string, characters int64) error {
+	v, ok := c.Get(limiterContextKey)
+	if !ok {
+		return nil
+	}
+	return v.(*Limiter).Charge(ClientID(c), provider, characters)
+}
+
+// RecordSent 在用量记录中登记实际发送给提供商的字符数；未启用限流时不记录
+func RecordSent(c *gin.Context, provider string, characters int64) {
+	if v, ok := c.Get(limiterContextKey); ok {
+		v.(*Limiter).RecordSent(ClientID(c), provider, characters)
+	}
+}
+
+// SetRetryAfter 设置Retry-After响应头（秒，向上取整）
+func SetRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", retryAfterSeconds(wait))
+}
+
+// retryAfterSeconds 将等待时间转换为Retry-After的秒数，至少为1
+func retryAfterSeconds(wait time.Duration) string {
+	seconds := int64(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
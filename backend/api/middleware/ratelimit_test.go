@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// newTestLimiter 创建使用内存用量记录和固定时钟的限流器
+func newTestLimiter(t *testing.T, limits config.LimitsConfig, now *time.Time) *Limiter {
+	t.Helper()
+	store, err := usage.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLimiter(limits, store)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(t, config.LimitsConfig{RequestsPerMinute: 60, Burst: 2}, &now)
+	router := gin.New()
+	router.GET("/", RateLimit(limiter), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		ip         string
+		wantStatus int
+		retryAfter string
+	}{
+		{name: "突发第1次", ip: "10.0.0.1", wantStatus: http.StatusOK},
+		{name: "突发第2次", ip: "10.0.0.1", wantStatus: http.StatusOK},
+		{name: "超出突发", ip: "10.0.0.1", wantStatus: http.StatusTooManyRequests, retryAfter: "1"},
+		{name: "其他客户端不受影响", ip: "10.0.0.2", wantStatus: http.StatusOK},
+		{name: "半秒后仍不足一个令牌", advance: 500 * time.Millisecond, ip: "10.0.0.1", wantStatus: http.StatusTooManyRequests, retryAfter: "1"},
+		{name: "一秒后补充一个令牌", advance: 500 * time.Millisecond, ip: "10.0.0.1", wantStatus: http.StatusOK},
+		{name: "令牌再次用完", ip: "10.0.0.1", wantStatus: http.StatusTooManyRequests, retryAfter: "1"},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		w := request(step.ip)
+		if w.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d，期望 %d", step.name, w.Code, step.wantStatus)
+		}
+		if got := w.Header().Get("Retry-After"); got != step.retryAfter {
+			t.Errorf("%s: Retry-After = %q，期望 %q", step.name, got, step.retryAfter)
+		}
+	}
+
+	// 未配置每分钟请求数时不限制
+	limiter.SetLimits(config.LimitsConfig{})
+	for i := 0; i < 5; i++ {
+		if w := request("10.0.0.1"); w.Code != http.StatusOK {
+			t.Fatalf("取消限制后 status = %d", w.Code)
+		}
+	}
+}
+
+func TestCharge(t *testing.T) {
+	now := time.Date(2024, 1, 30, 18, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(t, config.LimitsConfig{DailyCharacters: 100, MonthlyCharacters: 150}, &now)
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		characters int64
+		period     string        // 期望的配额错误，为空表示通过
+		retryAfter time.Duration // 距配额重置的时间
+	}{
+		{name: "每日配额内", characters: 60},
+		{name: "恰好用完每日配额", characters: 40},
+		{name: "超出每日配额", characters: 1, period: "daily", retryAfter: 6 * time.Hour},
+		{name: "单次请求超过每日配额", characters: 101, period: "daily", retryAfter: 6 * time.Hour},
+		// 第二天（仍是1月）每日配额重置，但每月配额剩余50
+		{name: "次日超出每月配额", advance: 12 * time.Hour, characters: 60, period: "monthly", retryAfter: 18 * time.Hour},
+		{name: "次日每月配额内", characters: 50},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		err := limiter.Charge("key_1", "google", step.characters)
+		if step.period == "" {
+			if err != nil {
+				t.Fatalf("%s: Charge = %v，期望通过", step.name, err)
+			}
+			continue
+		}
+		var quotaErr *QuotaError
+		if !errors.As(err, &quotaErr) {
+			t.Fatalf("%s: Charge = %v，期望 *QuotaError", step.name, err)
+		}
+		if quotaErr.Period != step.period || quotaErr.RetryAfter != step.retryAfter || quotaErr.Requested != step.characters {
+			t.Errorf("%s: 配额错误 = %+v，期望 %s 配额，%v 后重置", step.name, quotaErr, step.period, step.retryAfter)
+		}
+	}
+
+	// 配额不足时不记录用量
+	records := limiter.Usage().Records("key_1", "", "")
+	if len(records) != 2 || records[0].Characters != 100 || records[1].Characters != 50 || records[1].Requests != 1 {
+		t.Errorf("用量记录 = %+v", records)
+	}
+	// 其他客户端有自己的配额
+	if err := limiter.Charge("key_2", "google", 100); err != nil {
+		t.Errorf("其他客户端 Charge = %v，期望通过", err)
+	}
+}
+
+func TestQuotaErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *QuotaError
+		want string
+	}{
+		{&QuotaError{Period: "daily", Limit: 100, Used: 90, Requested: 20}, "每日字符配额不足：已使用90/100字符，本次请求需要20字符"},
+		{&QuotaError{Period: "monthly", Limit: 100, Requested: 120}, "本次请求需要120字符，超过每月配额100字符"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q，期望 %q", got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := map[time.Duration]string{0: "1", 200 * time.Millisecond: "1", time.Second: "1", 1500 * time.Millisecond: "2", 6 * time.Hour: "21600"}
+	for wait, want := range tests {
+		if got := retryAfterSeconds(wait); got != want {
+			t.Errorf("retryAfterSeconds(%v) = %q，期望 %q", wait, got, want)
+		}
+	}
+}
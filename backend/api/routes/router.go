@@ -2,30 +2,64 @@ package routes
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/frank0/subtitleTranslate/api/handlers"
 	"github.com/frank0/subtitleTranslate/api/middleware"
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/auth"
+	"github.com/frank0/subtitleTranslate/internal/metrics"
 	"github.com/frank0/subtitleTranslate/internal/static"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+// Options 路由依赖的服务
+type Options struct {
+	Keys    *auth.Store         // API密钥库，为nil时不启用认证，也不提供密钥管理接口
+	Limiter *middleware.Limiter // 限流和字符配额，为nil时不限制
+	Server  config.ServerConfig // 请求超时、跨域和可信代理设置，未配置时超时90秒、允许所有来源、不信任代理
+}
+
 // SetupRouter 设置API路由
-func SetupRouter() *gin.Engine {
-	// 创建默认的gin路由器
-	router := gin.Default()
+func SetupRouter(opts Options) *gin.Engine {
+	keys := opts.Keys
+
+	// 创建gin路由器，请求日志由 middleware.Logger 以结构化格式记录
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	// 只信任配置的反向代理转发的X-Forwarded-For，否则任何客户端都能伪造地址绕过按IP的限流和配额
+	if err := router.SetTrustedProxies(opts.Server.TrustedProxies); err != nil {
+		slog.Error("invalid trusted proxies, ignoring X-Forwarded-For", "error", err)
+		router.SetTrustedProxies(nil)
+	}
+
+	// 分配请求ID，后续的日志和提供商调用都会带上
+	router.Use(middleware.RequestID())
 
-	// 添加请求超时中间件 - 90秒超时
-	router.Use(timeoutMiddleware(90 * time.Second))
+	// 记录请求数和处理时间
+	router.Use(middleware.Metrics())
+
+	// 添加请求超时中间件
+	timeout := 90 * time.Second
+	if opts.Server.RequestTimeoutSeconds > 0 {
+		timeout = time.Duration(opts.Server.RequestTimeoutSeconds) * time.Second
+	}
+	router.Use(timeoutMiddleware(timeout))
 
 	// 配置CORS
+	origins := opts.Server.AllowedOrigins
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
+		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader, "Retry-After"},
 		AllowCredentials: true,
 	}))
 
@@ -39,14 +73,50 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
+	// Prometheus指标
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// API路由组
 	api := router.Group("/api")
 	{
-		// 字幕翻译路由
-		subtitle := api.Group("/subtitle")
+		// 翻译提供商及支持的语言
+		api.GET("/providers", handlers.ListProviders)
+
+		// 字幕翻译路由，启用认证时需要translate权限
+		subtitle := api.Group("/subtitle", middleware.Auth(keys, auth.ScopeTranslate), middleware.RateLimit(opts.Limiter))
 		{
 			// 翻译字幕文件
 			subtitle.POST("/translate", handlers.TranslateSubtitle)
+			// 调整字幕时间轴
+			subtitle.POST("/timing", handlers.AdjustTiming)
+			// 字幕质量检查
+			subtitle.POST("/qa", handlers.CheckQuality)
+			// 导出供译员使用的交换文件（XLIFF/PO/CSV）
+			subtitle.POST("/export", handlers.ExportSubtitle)
+			// 导入译员返回的交换文件并合并回原字幕
+			subtitle.POST("/import", handlers.ImportSubtitle)
+		}
+
+		// 配额和用量查询
+		if opts.Limiter != nil {
+			usageHandler := handlers.NewUsageHandler(opts.Limiter)
+			usageGroup := api.Group("/usage", middleware.Auth(keys, auth.ScopeTranslate))
+			{
+				usageGroup.GET("", usageHandler.Get)
+				// 用量台账（CSV）
+				usageGroup.GET("/ledger", usageHandler.Ledger)
+			}
+		}
+
+		// API密钥管理，需要admin权限
+		if keys != nil {
+			keyHandler := handlers.NewKeyHandler(keys)
+			admin := api.Group("/keys", middleware.Auth(keys, auth.ScopeAdmin))
+			{
+				admin.GET("", keyHandler.List)
+				admin.POST("", keyHandler.Create)
+				admin.DELETE("/:id", keyHandler.Revoke)
+			}
 		}
 	}
 
@@ -95,8 +165,8 @@ func timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 		// 继续处理请求
 		c.Next()
 
-		// 检查是否超时
-		if ctx.Err() == context.DeadlineExceeded {
+		// 检查是否超时，处理函数已经返回响应时不再重复写入
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
 			c.AbortWithStatusJSON(http.StatusRequestTimeout, gin.H{
 				"success": false,
 				"error":   "请求处理超时，请稍后重试",
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/api/middleware"
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSpoofedForwardedForDoesNotResetQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantSecond     int // 第二个请求伪造不同的X-Forwarded-For时的状态码
+	}{
+		// 未配置可信代理时按连接地址识别客户端，伪造的头不起作用
+		{name: "不信任代理", wantSecond: http.StatusTooManyRequests},
+		// 来自可信代理的请求按X-Forwarded-For识别客户端
+		{name: "可信代理", trustedProxies: []string{"203.0.113.0/24"}, wantSecond: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := usage.Open("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			limiter := middleware.NewLimiter(config.LimitsConfig{DailyCharacters: 10}, store)
+			router := SetupRouter(Options{
+				Limiter: limiter,
+				Server:  config.ServerConfig{TrustedProxies: tt.trustedProxies},
+			})
+			// 每个请求消耗10字符，正好是每日配额
+			router.POST("/api/test/charge", middleware.RateLimit(limiter), func(c *gin.Context) {
+				if err := middleware.ChargeCharacters(c, "google", 10); err != nil {
+					c.String(http.StatusTooManyRequests, err.Error())
+					return
+				}
+				c.String(http.StatusOK, middleware.ClientID(c))
+			})
+
+			request := func(forwardedFor string) *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/api/test/charge", nil)
+				req.RemoteAddr = "203.0.113.5:40000"
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				router.ServeHTTP(w, req)
+				return w
+			}
+			if w := request("198.51.100.1"); w.Code != http.StatusOK {
+				t.Fatalf("第一个请求 status = %d: %s", w.Code, w.Body.String())
+			}
+			if w := request("198.51.100.2"); w.Code != tt.wantSecond {
+				t.Fatalf("伪造X-Forwarded-For后 status = %d，期望 %d: %s", w.Code, tt.wantSecond, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,10 +1,10 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 )
 
 // Config 应用程序配置结构
@@ -12,13 +12,27 @@ type Config struct {
 	Server     ServerConfig     `json:"server"`
 	Volcengine VolcengineConfig `json:"volcengine"`
 	Google     GoogleConfig     `json:"google"`
+	Tencent    TencentConfig    `json:"tencent"`
+	Aliyun     AliyunConfig     `json:"aliyun"`
+	Auth       AuthConfig       `json:"auth"`
+	Limits     LimitsConfig     `json:"limits"`
+	Pricing    map[string]Price `json:"pricing"` // 各提供商的价格，键为提供商标识（volce、google、tencent、aliyun）
+	Log        LogConfig        `json:"log"`
+	Secrets    SecretsConfig    `json:"secrets"`
+	// Providers 各提供商的并发、批量、限流和分段设置，键为提供商标识，未配置或为0的字段使用默认值
+	Providers map[string]ProviderConfig `json:"providers"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port                int `json:"port"`
-	ReadTimeoutSeconds  int `json:"readTimeoutSeconds"`
-	WriteTimeoutSeconds int `json:"writeTimeoutSeconds"`
+	Port                   int      `json:"port"`
+	ReadTimeoutSeconds     int      `json:"readTimeoutSeconds"`
+	WriteTimeoutSeconds    int      `json:"writeTimeoutSeconds"`
+	RequestTimeoutSeconds  int      `json:"requestTimeoutSeconds"`  // 单个请求的处理超时，超时后停止翻译并返回408
+	ShutdownTimeoutSeconds int      `json:"shutdownTimeoutSeconds"` // 优雅关闭时等待进行中请求的时间
+	AllowedOrigins         []string `json:"allowedOrigins"`         // 允许跨域请求的来源，默认允许所有来源
+	ConfigWatchSeconds     int      `json:"configWatchSeconds"`     // 检查配置文件变化的间隔秒数，0表示只在收到SIGHUP时重新加载
+	TrustedProxies         []string `json:"trustedProxies"`         // 可信的反向代理IP或CIDR，只采用它们转发的X-Forwarded-For；默认为空，按连接的对端地址识别客户端
 }
 
 // VolcengineConfig 火山引擎翻译API配置
@@ -32,97 +46,306 @@ type VolcengineConfig struct {
 
 // GoogleConfig Google翻译API配置
 type GoogleConfig struct {
-	APIKey string `json:"apiKey"`
+	APIKey       string `json:"apiKey"`
+	TranslateURL string `json:"translateURL"` // 翻译API地址，默认为Google Cloud Translation v2
+}
+
+// TencentConfig 腾讯云机器翻译API配置，区域在providers.tencent中设置
+type TencentConfig struct {
+	SecretId  string `json:"secretId"`
+	SecretKey string `json:"secretKey"`
+}
+
+// AliyunConfig 阿里云机器翻译API配置，区域在providers.aliyun中设置
+type AliyunConfig struct {
+	AccessKeyId     string `json:"accessKeyId"`
+	AccessKeySecret string `json:"accessKeySecret"`
+}
+
+// AuthConfig API认证配置
+type AuthConfig struct {
+	Enabled  bool   `json:"enabled"`  // 是否要求请求携带 Bearer 令牌
+	KeyStore string `json:"keyStore"` // 密钥库文件路径，默认为配置文件所在目录下的apikeys.json
+}
+
+// LimitsConfig 每个客户端（API密钥，未启用认证时为IP）的限流和字符配额，0表示不限制
+type LimitsConfig struct {
+	RequestsPerMinute int    `json:"requestsPerMinute"` // 每分钟请求数
+	Burst             int    `json:"burst"`             // 允许的突发请求数，默认等于每分钟请求数
+	DailyCharacters   int64  `json:"dailyCharacters"`   // 每天（UTC）可翻译的源文字符数
+	MonthlyCharacters int64  `json:"monthlyCharacters"` // 每月（UTC）可翻译的源文字符数
+	UsageFile         string `json:"usageFile"`         // 用量记录文件，默认为配置文件所在目录下的usage.json
+}
+
+// SecretsConfig 加密凭据库配置，口令只能通过环境变量 SECRETS_PASSPHRASE 或 SECRETS_PASSPHRASE_FILE 提供
+type SecretsConfig struct {
+	Store string `json:"store"` // 加密凭据库文件路径，默认为配置文件所在目录下的secrets.enc，文件不存在时不使用
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level   string `json:"level"`   // 日志级别: debug、info、warn、error，默认info
+	Format  string `json:"format"`  // 输出格式: json 或 text，默认json
+	Content bool   `json:"content"` // 是否在日志中记录字幕内容，默认隐藏（凭据总是隐藏）
+}
+
+// ProviderConfig 提供商的请求设置
+type ProviderConfig struct {
+	Concurrency       int         `json:"concurrency"`               // 同时发送的请求数
+	BatchSize         int         `json:"batchSize,omitempty"`       // 每个请求包含的字幕条数（火山引擎、Google）
+	RequestsPerSecond int         `json:"requestsPerSecond"`         // 每秒最多发送的请求数，-1表示不限制
+	MaxCharacters     int         `json:"maxCharacters"`             // 单条文本超过该字符数时分段翻译
+	ChunkCharacters   int         `json:"chunkCharacters"`           // 超长文本每段的字符数
+	MergeCharacters   int         `json:"mergeCharacters,omitempty"` // 短字幕合并后每个请求的字符数上限（阿里云、腾讯云）
+	Region            string      `json:"region,omitempty"`          // 服务区域（阿里云、腾讯云）
+	Retry             RetryConfig `json:"retry"`                     // 临时错误的重试和熔断设置
+}
+
+// RetryConfig 提供商临时错误（超时、5xx、限流）的重试和熔断设置，未配置或为0的字段使用默认值
+type RetryConfig struct {
+	MaxAttempts      int `json:"maxAttempts"`      // 最多尝试次数（包括第一次），1表示不重试
+	InitialBackoffMs int `json:"initialBackoffMs"` // 第一次重试前的等待毫秒数，之后每次翻倍并随机抖动
+	MaxBackoffMs     int `json:"maxBackoffMs"`     // 单次等待的最大毫秒数，提供商返回的 Retry-After 优先
+	FailureThreshold int `json:"failureThreshold"` // 连续多少次失败后熔断，-1表示不熔断
+	CooldownSeconds  int `json:"cooldownSeconds"`  // 熔断多少秒后允许试探请求
+}
+
+// DefaultRetry 返回默认的重试和熔断设置
+func DefaultRetry() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:      3,
+		InitialBackoffMs: 500,
+		MaxBackoffMs:     10000,
+		FailureThreshold: 5,
+		CooldownSeconds:  30,
+	}
+}
+
+// ProviderNames 支持配置的提供商标识
+var ProviderNames = []string{"volce", "google", "tencent", "aliyun"}
+
+// maxBatchSize 提供商单个请求允许的最大条数
+var maxBatchSize = map[string]int{
+	"volce":  16,
+	"google": 128,
+}
+
+// DefaultProviders 返回各提供商的默认设置，与提供商免费版的限制一致
+func DefaultProviders() map[string]ProviderConfig {
+	return map[string]ProviderConfig{
+		"volce": {
+			Concurrency:       5,
+			BatchSize:         16,
+			RequestsPerSecond: -1,
+			MaxCharacters:     5000,
+			ChunkCharacters:   4000,
+			Retry:             DefaultRetry(),
+		},
+		"google": {
+			Concurrency:       1,
+			BatchSize:         128,
+			RequestsPerSecond: -1,
+			MaxCharacters:     5000,
+			ChunkCharacters:   4000,
+			Retry:             DefaultRetry(),
+		},
+		"tencent": {
+			Concurrency:       1,
+			RequestsPerSecond: 5,
+			MaxCharacters:     6000,
+			ChunkCharacters:   5000,
+			MergeCharacters:   5500,
+			Region:            "ap-beijing",
+			Retry:             DefaultRetry(),
+		},
+		"aliyun": {
+			Concurrency:       1,
+			RequestsPerSecond: 50,
+			MaxCharacters:     5000,
+			ChunkCharacters:   4500,
+			MergeCharacters:   4500,
+			Region:            "cn-hangzhou",
+			Retry:             DefaultRetry(),
+		},
+	}
+}
+
+// Provider 返回提供商的设置，未配置的提供商使用默认设置
+func (c *Config) Provider(name string) ProviderConfig {
+	if p, ok := c.Providers[name]; ok {
+		return p
+	}
+	return DefaultProviders()[name]
+}
+
+// fillProviderDefaults 用默认值补全未配置的提供商和字段
+func fillProviderDefaults(cfg *Config) {
+	if cfg.Providers == nil {
+		cfg.Providers = make(map[string]ProviderConfig)
+	}
+	for name, def := range DefaultProviders() {
+		p := cfg.Providers[name]
+		if p.Concurrency == 0 {
+			p.Concurrency = def.Concurrency
+		}
+		if p.BatchSize == 0 {
+			p.BatchSize = def.BatchSize
+		}
+		if p.RequestsPerSecond == 0 {
+			p.RequestsPerSecond = def.RequestsPerSecond
+		}
+		if p.MaxCharacters == 0 {
+			p.MaxCharacters = def.MaxCharacters
+		}
+		if p.ChunkCharacters == 0 {
+			p.ChunkCharacters = def.ChunkCharacters
+		}
+		if p.MergeCharacters == 0 {
+			p.MergeCharacters = def.MergeCharacters
+		}
+		if p.Region == "" {
+			p.Region = def.Region
+		}
+		if p.Retry.MaxAttempts == 0 {
+			p.Retry.MaxAttempts = def.Retry.MaxAttempts
+		}
+		if p.Retry.InitialBackoffMs == 0 {
+			p.Retry.InitialBackoffMs = def.Retry.InitialBackoffMs
+		}
+		if p.Retry.MaxBackoffMs == 0 {
+			p.Retry.MaxBackoffMs = def.Retry.MaxBackoffMs
+		}
+		if p.Retry.FailureThreshold == 0 {
+			p.Retry.FailureThreshold = def.Retry.FailureThreshold
+		}
+		if p.Retry.CooldownSeconds == 0 {
+			p.Retry.CooldownSeconds = def.Retry.CooldownSeconds
+		}
+		cfg.Providers[name] = p
+	}
+}
+
+// Price 提供商的翻译价格，用于估算费用
+type Price struct {
+	PerMillionCharacters float64 `json:"perMillionCharacters"` // 每百万字符的价格
+	Currency             string  `json:"currency"`             // 币种，例如 CNY、USD
 }
 
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:                8080,
-			ReadTimeoutSeconds:  30,
-			WriteTimeoutSeconds: 120, // 增加到2分钟，处理大文件翻译
+			Port:                   8080,
+			ReadTimeoutSeconds:     30,
+			WriteTimeoutSeconds:    120, // 增加到2分钟，处理大文件翻译
+			RequestTimeoutSeconds:  90,
+			ShutdownTimeoutSeconds: 5,
+			AllowedOrigins:         []string{"*"},
 		},
 		Volcengine: VolcengineConfig{
 			Region:       "cn-north-1",
 			Endpoint:     "open.volcengineapi.com",
 			TranslateURL: "https://translate.volcengineapi.com",
 		},
-		Google: GoogleConfig{},
+		Google: GoogleConfig{
+			TranslateURL: "https://translation.googleapis.com/language/translate/v2",
+		},
+		Providers: DefaultProviders(),
+	}
+}
+
+// current 最近一次加载的配置
+var current atomic.Pointer[Config]
+
+// Current 返回最近一次加载的配置，尚未加载时返回默认配置
+func Current() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
 	}
+	return DefaultConfig()
 }
 
-// Load 加载配置文件
+// Load 从 Path 返回的文件加载配置，成功后成为当前配置
 func Load() (*Config, error) {
+	return LoadFile(Path())
+}
+
+// LoadFile 从指定文件加载配置，成功后成为当前配置；失败时当前配置保持不变
+func LoadFile(path string) (*Config, error) {
+	cfg, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	current.Store(cfg)
+	return cfg, nil
+}
+
+// Parse 读取并校验配置，不改变当前配置
+// 文件不存在时使用默认配置；按扩展名支持 JSON、YAML（.yaml/.yml）和 TOML（.toml）
+// 凭据的优先级：环境变量（或 *_FILE 指定的文件）> 加密凭据库 > 配置文件
+func Parse(path string) (*Config, error) {
 	// 首先使用默认配置
 	cfg := DefaultConfig()
 
 	// 尝试从配置文件加载
-	configPath := getConfigPath()
-	if _, err := os.Stat(configPath); err == nil {
-		file, err := os.Open(configPath)
-		if err != nil {
-			return nil, fmt.Errorf("无法打开配置文件: %w", err)
-		}
-		defer file.Close()
-
-		decoder := json.NewDecoder(file)
-		if err := decoder.Decode(cfg); err != nil {
-			return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	if _, err := os.Stat(path); err == nil {
+		if err := decodeFile(path, cfg); err != nil {
+			return nil, err
 		}
 	} else if !os.IsNotExist(err) {
 		return nil, fmt.Errorf("检查配置文件状态失败: %w", err)
 	}
 
 	// 从环境变量覆盖配置
-	overrideFromEnv(cfg)
+	fillProviderDefaults(cfg)
+	if err := overrideFromEnv(cfg); err != nil {
+		return nil, fmt.Errorf("环境变量无效: %w", err)
+	}
 
-	return cfg, nil
-}
+	// 环境变量未提供的凭据从加密凭据库读取
+	if cfg.Secrets.Store == "" {
+		cfg.Secrets.Store = filepath.Join(filepath.Dir(path), "secrets.enc")
+	}
+	if err := applySecretsStore(cfg); err != nil {
+		return nil, err
+	}
+	registerSecrets(cfg)
 
-// getConfigPath 获取配置文件路径
-func getConfigPath() string {
-	// 首先检查环境变量中是否指定了配置文件路径
-	if path := os.Getenv("CONFIG_PATH"); path != "" {
-		return path
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("配置无效: %w", err)
 	}
 
-	// 默认使用当前目录下的config.json
-	execPath, err := os.Executable()
-	if err != nil {
-		return "config.json"
+	if cfg.Auth.KeyStore == "" {
+		cfg.Auth.KeyStore = filepath.Join(filepath.Dir(path), "apikeys.json")
+	}
+	if cfg.Limits.UsageFile == "" {
+		cfg.Limits.UsageFile = filepath.Join(filepath.Dir(path), "usage.json")
 	}
 
-	execDir := filepath.Dir(execPath)
-	return filepath.Join(execDir, "config.json")
+	return cfg, nil
 }
 
-// overrideFromEnv 从环境变量覆盖配置
-func overrideFromEnv(cfg *Config) {
-	// 服务器配置
-	if port := os.Getenv("SERVER_PORT"); port != "" {
-		fmt.Sscanf(port, "%d", &cfg.Server.Port)
-	}
+// configNames 未指定CONFIG_PATH时依次查找的配置文件
+var configNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
 
-	// 火山引擎配置
-	if key := os.Getenv("VOLCENGINE_ACCESS_KEY"); key != "" {
-		cfg.Volcengine.AccessKey = key
-	}
-	if key := os.Getenv("VOLCENGINE_SECRET_KEY"); key != "" {
-		cfg.Volcengine.SecretKey = key
-	}
-	if region := os.Getenv("VOLCENGINE_REGION"); region != "" {
-		cfg.Volcengine.Region = region
-	}
-	if endpoint := os.Getenv("VOLCENGINE_ENDPOINT"); endpoint != "" {
-		cfg.Volcengine.Endpoint = endpoint
-	}
-	if url := os.Getenv("VOLCENGINE_TRANSLATE_URL"); url != "" {
-		cfg.Volcengine.TranslateURL = url
+// Path 获取配置文件路径
+// 优先使用环境变量CONFIG_PATH，否则使用可执行文件所在目录下第一个存在的config.json/yaml/yml/toml
+func Path() string {
+	// 首先检查环境变量中是否指定了配置文件路径
+	if path := os.Getenv("CONFIG_PATH"); path != "" {
+		return path
 	}
 
-	// Google配置
-	if key := os.Getenv("GOOGLE_API_KEY"); key != "" {
-		cfg.Google.APIKey = key
+	// 默认使用可执行文件所在目录
+	execDir := "."
+	if execPath, err := os.Executable(); err == nil {
+		execDir = filepath.Dir(execPath)
 	}
+	for _, name := range configNames {
+		path := filepath.Join(execDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(execDir, "config.json")
 }
\ No newline at end of file
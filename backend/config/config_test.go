@@ -0,0 +1,222 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/secrets"
+)
+
+// writeConfig 在临时目录中写入配置文件并返回路径
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFormats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{
+  "server": {"port": 9090, "allowedOrigins": ["https://example.com"]},
+  "tencent": {"secretId": "id", "secretKey": "key"},
+  "providers": {"tencent": {"requestsPerSecond": 20, "retry": {"maxAttempts": 5}}}
+}`,
+		"config.yaml": `
+server:
+  port: 9090
+  allowedOrigins: [https://example.com]
+tencent:
+  secretId: id
+  secretKey: key
+providers:
+  tencent:
+    requestsPerSecond: 20
+    retry:
+      maxAttempts: 5
+`,
+		"config.toml": `
+[server]
+port = 9090
+allowedOrigins = ["https://example.com"]
+
+[tencent]
+secretId = "id"
+secretKey = "key"
+
+[providers.tencent]
+requestsPerSecond = 20
+
+[providers.tencent.retry]
+maxAttempts = 5
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Parse(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Server.Port != 9090 || cfg.Server.AllowedOrigins[0] != "https://example.com" {
+				t.Errorf("server = %+v", cfg.Server)
+			}
+			if cfg.Tencent.SecretId != "id" || cfg.Tencent.SecretKey != "key" {
+				t.Errorf("tencent = %+v", cfg.Tencent)
+			}
+			tencent := cfg.Provider("tencent")
+			if tencent.RequestsPerSecond != 20 || tencent.Retry.MaxAttempts != 5 {
+				t.Errorf("providers.tencent = %+v", tencent)
+			}
+			// 未配置的字段使用默认值
+			if tencent.Region != "ap-beijing" || tencent.Retry.CooldownSeconds != DefaultRetry().CooldownSeconds {
+				t.Errorf("未配置的字段没有使用默认值: %+v", tencent)
+			}
+			if cfg.Server.RequestTimeoutSeconds != 90 {
+				t.Errorf("requestTimeoutSeconds = %d, want 90", cfg.Server.RequestTimeoutSeconds)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		want                string
+	}{
+		{"未知字段", "config.yaml", "server:\n  prot: 8080\n", `未知的配置项"prot"`},
+		{"类型错误", "config.toml", "[server]\nport = \"8080\"\n", "配置项server.port的类型错误"},
+		{"JSON语法错误", "config.json", "{\n  \"server\": {\n    \"port\": 8080,\n  }\n}", "第4行"},
+		{"不支持的格式", "config.ini", "port=8080", "不支持的配置文件格式.ini"},
+		{"验证失败", "config.json", `{"server": {"port": 70000}, "log": {"level": "loud"}}`, "server.port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(writeConfig(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	path := writeConfig(t, "config.json", `{
+  "server": {"port": 70000, "trustedProxies": ["10.0.0.0/8", "proxy.local"]},
+  "aliyun": {"accessKeyId": "id"},
+  "log": {"format": "xml"},
+  "pricing": {"deepl": {"perMillionCharacters": 20}},
+  "providers": {"google": {"batchSize": 500}}
+}`)
+	_, err := Parse(path)
+	if err == nil {
+		t.Fatal("配置无效时应返回错误")
+	}
+	for _, want := range []string{
+		"server.port",
+		`server.trustedProxies: "proxy.local"`,
+		"aliyun.accessKeyId和aliyun.accessKeySecret必须同时配置",
+		"log.format",
+		"pricing.deepl",
+		"providers.google.batchSize",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误中缺少 %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestParseEnvOverride(t *testing.T) {
+	path := writeConfig(t, "config.json", `{"server": {"port": 9090}}`)
+
+	t.Setenv("SERVER_PORT", "9191")
+	t.Setenv("PROVIDER_ALIYUN_REGION", "cn-shanghai")
+	cfg, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9191 || cfg.Provider("aliyun").Region != "cn-shanghai" {
+		t.Errorf("环境变量没有覆盖配置: port=%d region=%s", cfg.Server.Port, cfg.Provider("aliyun").Region)
+	}
+
+	t.Setenv("PROVIDER_TENCENT_CONCURRENCY", "many")
+	if _, err := Parse(path); err == nil || !strings.Contains(err.Error(), "PROVIDER_TENCENT_CONCURRENCY") {
+		t.Fatalf("err = %v, want PROVIDER_TENCENT_CONCURRENCY", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := writeConfig(t, "config.json", `{}`)
+	changed := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go Watch(ctx, path, 10*time.Millisecond, func() { changed <- struct{}{} })
+
+	select {
+	case <-changed:
+		t.Fatal("文件未修改时不应通知")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(path, []byte(`{"server": {"port": 9090}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("文件修改后没有通知")
+	}
+}
+
+func TestSecretsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"google": {"apiKey": "from-config"}, "tencent": {"secretId": "id-config", "secretKey": "key-config"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SECRETS_PASSPHRASE", "passphrase")
+	store, err := secrets.Open(filepath.Join(dir, "secrets.enc"), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("GOOGLE_API_KEY", "from-store")
+	store.Set("TENCENT_SECRET_KEY", "key-store")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "tencent_key")
+	if err := os.WriteFile(keyFile, []byte("key-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 凭据库优先于配置文件
+	cfg, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Google.APIKey != "from-store" || cfg.Tencent.SecretKey != "key-store" || cfg.Tencent.SecretId != "id-config" {
+		t.Errorf("google=%q tencent=%+v", cfg.Google.APIKey, cfg.Tencent)
+	}
+
+	// 环境变量和 *_FILE 优先于凭据库
+	t.Setenv("GOOGLE_API_KEY", "from-env")
+	t.Setenv("TENCENT_SECRET_KEY_FILE", keyFile)
+	if cfg, err = Parse(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Google.APIKey != "from-env" || cfg.Tencent.SecretKey != "key-file" {
+		t.Errorf("google=%q tencent=%+v", cfg.Google.APIKey, cfg.Tencent)
+	}
+
+	// 凭据库存在但口令错误时报错
+	t.Setenv("SECRETS_PASSPHRASE", "wrong")
+	if _, err := Parse(path); !errors.Is(err, secrets.ErrWrongPassphrase) {
+		t.Errorf("err = %v, want ErrWrongPassphrase", err)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeFile 按扩展名解析配置文件并合并到cfg
+// YAML和TOML先转换为JSON，三种格式使用相同的字段名，未知字段和类型错误都会报错
+func decodeFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("无法读取配置文件: %w", err)
+	}
+
+	format := strings.ToLower(filepath.Ext(path))
+	switch format {
+	case ".yaml", ".yml":
+		var raw map[string]any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("解析配置文件%s失败: %w", path, err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return fmt.Errorf("解析配置文件%s失败: %w", path, err)
+		}
+	case ".toml":
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			var decodeErr *toml.DecodeError
+			if errors.As(err, &decodeErr) {
+				row, col := decodeErr.Position()
+				return fmt.Errorf("解析配置文件%s失败: 第%d行第%d列: %w", path, row, col, err)
+			}
+			return fmt.Errorf("解析配置文件%s失败: %w", path, err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return fmt.Errorf("解析配置文件%s失败: %w", path, err)
+		}
+	case ".json", "":
+	default:
+		return fmt.Errorf("不支持的配置文件格式%s，可选: .json、.yaml、.yml、.toml", format)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("解析配置文件%s失败: %w", path, describeJSONError(err, data, format))
+	}
+	return nil
+}
+
+// describeJSONError 把JSON解码错误转换为包含位置或字段名的说明
+func describeJSONError(err error, data []byte, format string) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && (format == ".json" || format == "") {
+		line := 1 + bytes.Count(data[:syntaxErr.Offset], []byte("\n"))
+		return fmt.Errorf("第%d行: %w", line, err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("配置项%s的类型错误: 需要%s，实际为%s", typeErr.Field, kindName(typeErr.Type), valueName(typeErr.Value))
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return fmt.Errorf("未知的配置项%s，请检查拼写", field)
+	}
+	return err
+}
+
+// valueNames JSON值类型的说明，YAML和TOML也先转换为JSON再解析
+var valueNames = map[string]string{
+	"number": "数字",
+	"string": "字符串",
+	"bool":   "布尔值",
+	"array":  "列表",
+	"object": "对象",
+}
+
+// valueName 返回实际值类型的说明，数值超出范围或不是整数时带上原值，例如"number 2.5"
+func valueName(value string) string {
+	kind, literal, _ := strings.Cut(value, " ")
+	name, ok := valueNames[kind]
+	if !ok {
+		return value
+	}
+	if literal != "" {
+		return name + literal
+	}
+	return name
+}
+
+// kindName 返回配置项类型的说明
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64:
+		return "整数"
+	case reflect.Float64:
+		return "数字"
+	case reflect.Bool:
+		return "true 或 false"
+	case reflect.String:
+		return "字符串"
+	case reflect.Slice:
+		return kindName(t.Elem()) + "列表"
+	case reflect.Struct, reflect.Map:
+		return "对象"
+	}
+	return t.String()
+}
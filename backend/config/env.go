@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/secrets"
+)
+
+// envParser 读取环境变量覆盖配置，记录无法解析的值
+type envParser struct {
+	errs []error
+}
+
+// string 环境变量非空时覆盖dst
+func (e *envParser) string(name string, dst *string) {
+	if value := os.Getenv(name); value != "" {
+		*dst = value
+	}
+}
+
+// secret 环境变量或 name_FILE 指定的文件非空时覆盖dst
+func (e *envParser) secret(name string, dst *string) {
+	value, ok, err := secrets.FromEnv(name)
+	if err != nil {
+		e.errs = append(e.errs, err)
+		return
+	}
+	if ok {
+		*dst = value
+	}
+}
+
+// int 环境变量非空时解析为整数覆盖dst
+func (e *envParser) int(name string, dst *int) {
+	if value := os.Getenv(name); value != "" {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s=%q: 需要整数", name, value))
+			return
+		}
+		*dst = n
+	}
+}
+
+// int64 环境变量非空时解析为整数覆盖dst
+func (e *envParser) int64(name string, dst *int64) {
+	if value := os.Getenv(name); value != "" {
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s=%q: 需要整数", name, value))
+			return
+		}
+		*dst = n
+	}
+}
+
+// float 环境变量非空时解析为数字覆盖dst，返回是否设置了该变量
+func (e *envParser) float(name string, dst *float64) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s=%q: 需要数字", name, value))
+		return true
+	}
+	*dst = f
+	return true
+}
+
+// bool 环境变量非空时解析为布尔值覆盖dst，接受 true/false/1/0
+func (e *envParser) bool(name string, dst *bool) {
+	if value := os.Getenv(name); value != "" {
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s=%q: 需要 true 或 false", name, value))
+			return
+		}
+		*dst = b
+	}
+}
+
+// list 环境变量非空时按逗号分隔覆盖dst
+func (e *envParser) list(name string, dst *[]string) {
+	if value := os.Getenv(name); value != "" {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
+
+// overrideFromEnv 从环境变量覆盖配置，返回所有无法解析的变量
+func overrideFromEnv(cfg *Config) error {
+	var e envParser
+
+	// 服务器配置，READ_TIMEOUT_SECONDS/WRITE_TIMEOUT_SECONDS 与 .env.example 保持一致
+	e.int("SERVER_PORT", &cfg.Server.Port)
+	e.int("READ_TIMEOUT_SECONDS", &cfg.Server.ReadTimeoutSeconds)
+	e.int("WRITE_TIMEOUT_SECONDS", &cfg.Server.WriteTimeoutSeconds)
+	e.int("REQUEST_TIMEOUT_SECONDS", &cfg.Server.RequestTimeoutSeconds)
+	e.int("SHUTDOWN_TIMEOUT_SECONDS", &cfg.Server.ShutdownTimeoutSeconds)
+	e.list("CORS_ALLOWED_ORIGINS", &cfg.Server.AllowedOrigins)
+	e.int("CONFIG_WATCH_SECONDS", &cfg.Server.ConfigWatchSeconds)
+	e.list("TRUSTED_PROXIES", &cfg.Server.TrustedProxies)
+
+	// 提供商凭据，也可以用 *_FILE 从文件读取，例如 GOOGLE_API_KEY_FILE=/run/secrets/google_api_key
+	for _, c := range credentials {
+		e.secret(c.name, c.field(cfg))
+	}
+
+	// 火山引擎配置
+	e.string("VOLCENGINE_REGION", &cfg.Volcengine.Region)
+	e.string("VOLCENGINE_ENDPOINT", &cfg.Volcengine.Endpoint)
+	e.string("VOLCENGINE_TRANSLATE_URL", &cfg.Volcengine.TranslateURL)
+
+	// Google配置
+	e.string("GOOGLE_TRANSLATE_URL", &cfg.Google.TranslateURL)
+
+	// 加密凭据库
+	e.string("SECRETS_STORE", &cfg.Secrets.Store)
+
+	// 认证配置
+	e.bool("AUTH_ENABLED", &cfg.Auth.Enabled)
+	e.string("AUTH_KEYSTORE", &cfg.Auth.KeyStore)
+
+	// 限流和配额配置
+	e.int("RATE_LIMIT_PER_MINUTE", &cfg.Limits.RequestsPerMinute)
+	e.int("RATE_LIMIT_BURST", &cfg.Limits.Burst)
+	e.int64("QUOTA_DAILY_CHARACTERS", &cfg.Limits.DailyCharacters)
+	e.int64("QUOTA_MONTHLY_CHARACTERS", &cfg.Limits.MonthlyCharacters)
+	e.string("USAGE_FILE", &cfg.Limits.UsageFile)
+
+	// 日志配置
+	e.string("LOG_LEVEL", &cfg.Log.Level)
+	e.string("LOG_FORMAT", &cfg.Log.Format)
+	e.bool("LOG_CONTENT", &cfg.Log.Content)
+
+	// 提供商设置，例如 PROVIDER_TENCENT_REQUESTS_PER_SECOND=20 PROVIDER_ALIYUN_REGION=cn-shanghai PROVIDER_GOOGLE_RETRY_MAX_ATTEMPTS=5
+	for _, provider := range ProviderNames {
+		env := "PROVIDER_" + strings.ToUpper(provider) + "_"
+		p := cfg.Providers[provider]
+		e.int(env+"CONCURRENCY", &p.Concurrency)
+		e.int(env+"BATCH_SIZE", &p.BatchSize)
+		e.int(env+"REQUESTS_PER_SECOND", &p.RequestsPerSecond)
+		e.int(env+"MAX_CHARACTERS", &p.MaxCharacters)
+		e.int(env+"CHUNK_CHARACTERS", &p.ChunkCharacters)
+		e.int(env+"MERGE_CHARACTERS", &p.MergeCharacters)
+		e.string(env+"REGION", &p.Region)
+		e.int(env+"RETRY_MAX_ATTEMPTS", &p.Retry.MaxAttempts)
+		e.int(env+"RETRY_INITIAL_MS", &p.Retry.InitialBackoffMs)
+		e.int(env+"RETRY_MAX_MS", &p.Retry.MaxBackoffMs)
+		e.int(env+"BREAKER_THRESHOLD", &p.Retry.FailureThreshold)
+		e.int(env+"BREAKER_COOLDOWN", &p.Retry.CooldownSeconds)
+		cfg.Providers[provider] = p
+	}
+
+	// 价格配置，例如 PRICE_GOOGLE=20 PRICE_GOOGLE_CURRENCY=USD
+	for _, provider := range ProviderNames {
+		env := "PRICE_" + strings.ToUpper(provider)
+		price, ok := cfg.Pricing[provider]
+		if e.float(env, &price.PerMillionCharacters) {
+			ok = true
+		}
+		if currency := os.Getenv(env + "_CURRENCY"); currency != "" {
+			price.Currency = currency
+			ok = true
+		}
+		if ok {
+			if cfg.Pricing == nil {
+				cfg.Pricing = make(map[string]Price)
+			}
+			cfg.Pricing[provider] = price
+		}
+	}
+
+	return errors.Join(e.errs...)
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/frank0/subtitleTranslate/internal/secrets"
+)
+
+// credential 提供商凭据配置项，名称与对应的环境变量相同，也是在加密凭据库中的名称
+type credential struct {
+	name  string
+	field func(cfg *Config) *string
+}
+
+// credentials 所有提供商凭据
+var credentials = []credential{
+	{"VOLCENGINE_ACCESS_KEY", func(cfg *Config) *string { return &cfg.Volcengine.AccessKey }},
+	{"VOLCENGINE_SECRET_KEY", func(cfg *Config) *string { return &cfg.Volcengine.SecretKey }},
+	{"GOOGLE_API_KEY", func(cfg *Config) *string { return &cfg.Google.APIKey }},
+	{"TENCENT_SECRET_ID", func(cfg *Config) *string { return &cfg.Tencent.SecretId }},
+	{"TENCENT_SECRET_KEY", func(cfg *Config) *string { return &cfg.Tencent.SecretKey }},
+	{"ALIYUN_ACCESS_KEY_ID", func(cfg *Config) *string { return &cfg.Aliyun.AccessKeyId }},
+	{"ALIYUN_ACCESS_KEY_SECRET", func(cfg *Config) *string { return &cfg.Aliyun.AccessKeySecret }},
+}
+
+// SecretNames 返回可以保存在加密凭据库中的凭据名
+func SecretNames() []string {
+	names := make([]string, len(credentials))
+	for i, c := range credentials {
+		names[i] = c.name
+	}
+	return names
+}
+
+// applySecretsStore 从加密凭据库读取环境变量未提供的凭据，凭据库文件不存在时不做任何事
+func applySecretsStore(cfg *Config) error {
+	if _, err := os.Stat(cfg.Secrets.Store); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	passphrase, err := secrets.Passphrase()
+	if err != nil {
+		return fmt.Errorf("加密凭据库%s: %w", cfg.Secrets.Store, err)
+	}
+	store, err := secrets.Open(cfg.Secrets.Store, passphrase)
+	if err != nil {
+		return fmt.Errorf("加密凭据库%s: %w", cfg.Secrets.Store, err)
+	}
+	for _, c := range credentials {
+		if os.Getenv(c.name) != "" || os.Getenv(c.name+"_FILE") != "" {
+			continue
+		}
+		if value, ok := store.Get(c.name); ok {
+			*c.field(cfg) = value
+		}
+	}
+	return nil
+}
+
+// registerSecrets 登记配置中的凭据，之后出现在日志和错误信息中时会被隐藏
+func registerSecrets(cfg *Config) {
+	for _, c := range credentials {
+		secrets.Register(*c.field(cfg))
+	}
+	if passphrase, ok, _ := secrets.FromEnv(secrets.PassphraseEnv); ok {
+		secrets.Register(passphrase)
+	}
+}
+
+// SecretsStorePath 返回加密凭据库路径：SECRETS_STORE > 配置文件中的secrets.store > 配置文件所在目录下的secrets.enc
+// 只读取配置文件，不校验配置，也不打开凭据库，供命令行在配置尚不完整时管理凭据
+func SecretsStorePath(configPath string) (string, error) {
+	if path := os.Getenv("SECRETS_STORE"); path != "" {
+		return path, nil
+	}
+	var cfg Config
+	if _, err := os.Stat(configPath); err == nil {
+		if err := decodeFile(configPath, &cfg); err != nil {
+			return "", err
+		}
+	}
+	if cfg.Secrets.Store != "" {
+		return cfg.Secrets.Store, nil
+	}
+	return filepath.Join(filepath.Dir(configPath), "secrets.enc"), nil
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Validate 检查配置是否有效，返回所有发现的问题
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, c.validateServer()...)
+	errs = append(errs, c.validateCredentials()...)
+	errs = append(errs, c.validateLimits()...)
+	errs = append(errs, c.validateLog()...)
+	errs = append(errs, c.validatePricing()...)
+	errs = append(errs, c.validateProviders()...)
+	return errors.Join(errs...)
+}
+
+// validateServer 检查服务器配置
+func (c *Config) validateServer() []error {
+	var errs []error
+	s := c.Server
+	if s.Port < 1 || s.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port: 必须在1到65535之间，当前为%d", s.Port))
+	}
+	timeouts := []struct {
+		name  string
+		value int
+	}{
+		{"readTimeoutSeconds", s.ReadTimeoutSeconds},
+		{"writeTimeoutSeconds", s.WriteTimeoutSeconds},
+		{"requestTimeoutSeconds", s.RequestTimeoutSeconds},
+		{"shutdownTimeoutSeconds", s.ShutdownTimeoutSeconds},
+	}
+	for _, t := range timeouts {
+		if t.value < 1 {
+			errs = append(errs, fmt.Errorf("server.%s: 必须大于0，当前为%d", t.name, t.value))
+		}
+	}
+	if len(s.AllowedOrigins) == 0 {
+		errs = append(errs, fmt.Errorf("server.allowedOrigins: 不能为空，允许所有来源请使用 [\"*\"]"))
+	}
+	for _, origin := range s.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("server.allowedOrigins: %q 不是有效的来源，例如 https://example.com", origin))
+		}
+	}
+	for _, proxy := range s.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err != nil {
+			errs = append(errs, fmt.Errorf("server.trustedProxies: %q 不是有效的IP地址或CIDR，例如 10.0.0.1 或 10.0.0.0/8", proxy))
+		}
+	}
+	if s.ConfigWatchSeconds < 0 {
+		errs = append(errs, fmt.Errorf("server.configWatchSeconds: 不能为负数，当前为%d", s.ConfigWatchSeconds))
+	}
+	return errs
+}
+
+// validateCredentials 检查提供商凭据：成对的密钥要么都配置，要么都不配置
+func (c *Config) validateCredentials() []error {
+	var errs []error
+	pairs := []struct {
+		id, secret         string
+		idName, secretName string
+	}{
+		{c.Volcengine.AccessKey, c.Volcengine.SecretKey, "volcengine.accessKey", "volcengine.secretKey"},
+		{c.Tencent.SecretId, c.Tencent.SecretKey, "tencent.secretId", "tencent.secretKey"},
+		{c.Aliyun.AccessKeyId, c.Aliyun.AccessKeySecret, "aliyun.accessKeyId", "aliyun.accessKeySecret"},
+	}
+	for _, p := range pairs {
+		if (p.id == "") != (p.secret == "") {
+			errs = append(errs, fmt.Errorf("%s和%s必须同时配置", p.idName, p.secretName))
+		}
+	}
+	if u, err := url.Parse(c.Google.TranslateURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("google.translateURL: %q 不是有效的URL", c.Google.TranslateURL))
+	}
+	return errs
+}
+
+// validateLimits 检查限流和配额配置
+func (c *Config) validateLimits() []error {
+	var errs []error
+	l := c.Limits
+	if l.RequestsPerMinute < 0 {
+		errs = append(errs, fmt.Errorf("limits.requestsPerMinute: 不能为负数，当前为%d", l.RequestsPerMinute))
+	}
+	if l.Burst < 0 {
+		errs = append(errs, fmt.Errorf("limits.burst: 不能为负数，当前为%d", l.Burst))
+	}
+	if l.DailyCharacters < 0 {
+		errs = append(errs, fmt.Errorf("limits.dailyCharacters: 不能为负数，当前为%d", l.DailyCharacters))
+	}
+	if l.MonthlyCharacters < 0 {
+		errs = append(errs, fmt.Errorf("limits.monthlyCharacters: 不能为负数，当前为%d", l.MonthlyCharacters))
+	}
+	if l.DailyCharacters > 0 && l.MonthlyCharacters > 0 && l.DailyCharacters > l.MonthlyCharacters {
+		errs = append(errs, fmt.Errorf("limits.dailyCharacters: 不能大于monthlyCharacters(%d)，当前为%d", l.MonthlyCharacters, l.DailyCharacters))
+	}
+	return errs
+}
+
+// validateLog 检查日志配置，空值使用默认值
+func (c *Config) validateLog() []error {
+	var errs []error
+	switch strings.ToLower(c.Log.Level) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level: 未知的日志级别%q，可选: debug、info、warn、error", c.Log.Level))
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "", "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("log.format: 未知的输出格式%q，可选: json、text", c.Log.Format))
+	}
+	return errs
+}
+
+// validatePricing 检查价格配置
+func (c *Config) validatePricing() []error {
+	var errs []error
+	for _, name := range sortedKeys(c.Pricing) {
+		price := c.Pricing[name]
+		if _, known := DefaultProviders()[name]; !known {
+			errs = append(errs, fmt.Errorf("pricing.%s: 未知的提供商，可选值: %s", name, strings.Join(ProviderNames, ", ")))
+			continue
+		}
+		if price.PerMillionCharacters < 0 {
+			errs = append(errs, fmt.Errorf("pricing.%s.perMillionCharacters: 不能为负数，当前为%g", name, price.PerMillionCharacters))
+		}
+		if price.PerMillionCharacters > 0 && price.Currency == "" {
+			errs = append(errs, fmt.Errorf("pricing.%s.currency: 设置价格时必须指定币种，例如 CNY、USD", name))
+		}
+	}
+	return errs
+}
+
+// validateProviders 检查各提供商的请求设置
+func (c *Config) validateProviders() []error {
+	var errs []error
+	for _, name := range sortedKeys(c.Providers) {
+		p := c.Providers[name]
+		def, known := DefaultProviders()[name]
+		if !known {
+			errs = append(errs, fmt.Errorf("providers.%s: 未知的提供商，可选值: %s", name, strings.Join(ProviderNames, ", ")))
+			continue
+		}
+		if p.Concurrency < 1 || p.Concurrency > 100 {
+			errs = append(errs, fmt.Errorf("providers.%s.concurrency: 必须在1到100之间，当前为%d", name, p.Concurrency))
+		}
+		if limit, ok := maxBatchSize[name]; ok && (p.BatchSize < 1 || p.BatchSize > limit) {
+			errs = append(errs, fmt.Errorf("providers.%s.batchSize: 必须在1到%d之间，当前为%d", name, limit, p.BatchSize))
+		}
+		if p.RequestsPerSecond == 0 || p.RequestsPerSecond < -1 || p.RequestsPerSecond > 1000 {
+			errs = append(errs, fmt.Errorf("providers.%s.requestsPerSecond: 必须在1到1000之间，-1表示不限制，当前为%d", name, p.RequestsPerSecond))
+		}
+		if p.MaxCharacters < 1 {
+			errs = append(errs, fmt.Errorf("providers.%s.maxCharacters: 必须大于0，当前为%d", name, p.MaxCharacters))
+		}
+		if p.ChunkCharacters < 1 || p.ChunkCharacters > p.MaxCharacters {
+			errs = append(errs, fmt.Errorf("providers.%s.chunkCharacters: 必须在1到maxCharacters(%d)之间，当前为%d", name, p.MaxCharacters, p.ChunkCharacters))
+		}
+		if def.MergeCharacters > 0 && (p.MergeCharacters < 1 || p.MergeCharacters > p.MaxCharacters) {
+			errs = append(errs, fmt.Errorf("providers.%s.mergeCharacters: 必须在1到maxCharacters(%d)之间，当前为%d", name, p.MaxCharacters, p.MergeCharacters))
+		}
+		if def.Region != "" && p.Region == "" {
+			errs = append(errs, fmt.Errorf("providers.%s.region: 不能为空", name))
+		}
+		r := p.Retry
+		if r.MaxAttempts < 1 || r.MaxAttempts > 10 {
+			errs = append(errs, fmt.Errorf("providers.%s.retry.maxAttempts: 必须在1到10之间，当前为%d", name, r.MaxAttempts))
+		}
+		if r.InitialBackoffMs < 1 {
+			errs = append(errs, fmt.Errorf("providers.%s.retry.initialBackoffMs: 必须大于0，当前为%d", name, r.InitialBackoffMs))
+		}
+		if r.MaxBackoffMs < r.InitialBackoffMs {
+			errs = append(errs, fmt.Errorf("providers.%s.retry.maxBackoffMs: 不能小于initialBackoffMs(%d)，当前为%d", name, r.InitialBackoffMs, r.MaxBackoffMs))
+		}
+		if r.FailureThreshold < -1 || r.FailureThreshold == 0 {
+			errs = append(errs, fmt.Errorf("providers.%s.retry.failureThreshold: 必须大于0，-1表示不熔断，当前为%d", name, r.FailureThreshold))
+		}
+		if r.CooldownSeconds < 1 {
+			errs = append(errs, fmt.Errorf("providers.%s.retry.cooldownSeconds: 必须大于0，当前为%d", name, r.CooldownSeconds))
+		}
+	}
+	return errs
+}
+
+// sortedKeys 返回按字母排序的键，使错误信息的顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
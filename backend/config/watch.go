@@ -0,0 +1,46 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Watch 每隔interval检查一次配置文件，修改时间或大小变化时调用onChange，ctx取消后返回
+// 使用轮询而不是文件系统通知，编辑器替换文件、Kubernetes ConfigMap 更新符号链接时同样有效
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	last, _ := stat(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, ok := stat(path)
+		if info == last {
+			continue
+		}
+		last = info
+		if ok {
+			onChange()
+		}
+	}
+}
+
+// fileInfo 判断配置文件是否变化所需的信息
+type fileInfo struct {
+	modTime time.Time
+	size    int64
+}
+
+// stat 返回文件的修改时间和大小，文件不存在时返回false
+func stat(path string) (fileInfo, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileInfo{}, false
+	}
+	return fileInfo{modTime: info.ModTime(), size: info.Size()}, true
+}
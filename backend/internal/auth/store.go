@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 密钥的权限范围
+const (
+	ScopeTranslate = "translate" // 调用字幕相关接口
+	ScopeAdmin     = "admin"     // 管理API密钥，包含所有权限
+)
+
+// tokenPrefix 令牌前缀，便于在日志和代码仓库中识别泄露的令牌
+const tokenPrefix = "stk_"
+
+// ErrInvalidToken 令牌不存在或已吊销
+var ErrInvalidToken = errors.New("无效或已吊销的API令牌")
+
+// ErrKeyNotFound 密钥ID不存在
+var ErrKeyNotFound = errors.New("密钥不存在")
+
+// Key 一个服务器管理的API密钥，只保存令牌的哈希
+type Key struct {
+	ID                     string     `json:"id"`                               // 密钥ID
+	Name                   string     `json:"name"`                             // 名称，便于识别用途
+	Prefix                 string     `json:"prefix"`                           // 令牌开头几位，用于识别
+	Hash                   string     `json:"hash"`                             // 令牌的SHA-256哈希
+	Scopes                 []string   `json:"scopes"`                           // 权限范围
+	AllowServerCredentials bool       `json:"allowServerCredentials,omitempty"` // 请求未携带自己的提供商密钥时，是否允许使用服务器配置的密钥
+	CreatedAt              time.Time  `json:"createdAt"`                        // 创建时间
+	RevokedAt              *time.Time `json:"revokedAt,omitempty"`              // 吊销时间
+}
+
+// HasScope 判断密钥是否具有指定权限，admin包含所有权限
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoked 判断密钥是否已吊销
+func (k *Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Store 保存在JSON文件中的密钥库，并发安全
+// 文件可能被 keys 命令或其他进程修改：每次认证、创建、吊销和列出前检查文件的修改时间，
+// 变化时重新读取；写入时与磁盘上的文件合并，不会覆盖其他进程新增或吊销的密钥
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	keys    []*Key
+	modTime time.Time // 上次读取或写入时文件的修改时间
+	size    int64     // 上次读取或写入时文件的大小
+}
+
+// storeFile 密钥库文件结构
+type storeFile struct {
+	Keys []*Key `json:"keys"`
+}
+
+// Open 打开密钥库文件，文件不存在时创建空的密钥库
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload 重新读取密钥库文件，例如收到SIGHUP时；文件不存在时密钥库为空
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// load 读取密钥库文件并记录其修改时间，调用方需持有锁
+func (s *Store) load() error {
+	keys, info, err := readStoreFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	s.modTime, s.size = fileStamp(info)
+	return nil
+}
+
+// refresh 文件的修改时间或大小变化时重新读取，调用方需持有锁
+// 读取失败时保留内存中的密钥，避免文件写到一半时拒绝所有请求
+func (s *Store) refresh() {
+	info, err := os.Stat(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error("failed to check API key store", "path", s.path, "error", err)
+		return
+	}
+	if modTime, size := fileStamp(info); modTime.Equal(s.modTime) && size == s.size {
+		return
+	}
+	if err := s.load(); err != nil {
+		slog.Error("failed to reload API key store, keeping the previous keys", "path", s.path, "error", err)
+		return
+	}
+	slog.Info("API key store reloaded", "path", s.path, "keys", len(s.keys))
+}
+
+// readStoreFile 读取密钥库文件，文件不存在时返回空列表和nil的FileInfo
+func readStoreFile(path string) ([]*Key, os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取密钥库失败: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取密钥库失败: %w", err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, fmt.Errorf("解析密钥库失败: %w", err)
+	}
+	return file.Keys, info, nil
+}
+
+// fileStamp 返回文件的修改时间和大小，info为nil（文件不存在）时返回零值
+func fileStamp(info os.FileInfo) (time.Time, int64) {
+	if info == nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
+
+// ValidateScopes 检查权限范围是否有效
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("至少需要一个权限范围（%s 或 %s）", ScopeTranslate, ScopeAdmin)
+	}
+	for _, s := range scopes {
+		if s != ScopeTranslate && s != ScopeAdmin {
+			return fmt.Errorf("无效的权限范围: %s（可选 %s、%s）", s, ScopeTranslate, ScopeAdmin)
+		}
+	}
+	return nil
+}
+
+// Create 创建密钥并保存，返回密钥和明文令牌；令牌只在创建时返回一次
+func (s *Store) Create(name string, scopes []string, allowServerCredentials bool) (*Key, string, error) {
+	if err := ValidateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", fmt.Errorf("生成密钥ID失败: %w", err)
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	key := &Key{
+		ID:                     "key_" + hex.EncodeToString(id),
+		Name:                   name,
+		Prefix:                 token[:len(tokenPrefix)+6],
+		Hash:                   hashToken(token),
+		Scopes:                 scopes,
+		AllowServerCredentials: allowServerCredentials,
+		CreatedAt:              time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	s.keys = append(s.keys, key)
+	if err := s.save(); err != nil {
+		s.keys = slices.DeleteFunc(s.keys, func(k *Key) bool { return k == key })
+		return nil, "", err
+	}
+	return key, token, nil
+}
+
+// Revoke 吊销密钥，已吊销的密钥保留在密钥库中以便审计
+func (s *Store) Revoke(id string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	for _, key := range s.keys {
+		if key.ID != id {
+			continue
+		}
+		if key.Revoked() {
+			return key, nil
+		}
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+		if err := s.save(); err != nil {
+			key.RevokedAt = nil
+			return nil, err
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+}
+
+// List 返回所有密钥（包括已吊销的），按创建时间排序
+func (s *Store) List() []Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	keys := make([]Key, len(s.keys))
+	for i, key := range s.keys {
+		keys[i] = *key
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// Authenticate 根据明文令牌查找有效的密钥
+func (s *Store) Authenticate(token string) (*Key, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	hash := hashToken(token)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+	for _, key := range s.keys {
+		if key.Hash == hash && !key.Revoked() {
+			k := *key
+			return &k, nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+// save 与磁盘上的密钥库合并后原子地写入，调用方需持有锁
+// 只在文件中的密钥会被保留，任一方已吊销的密钥保持吊销
+func (s *Store) save() error {
+	disk, _, err := readStoreFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.keys = mergeKeys(s.keys, disk)
+
+	data, err := json.MarshalIndent(storeFile{Keys: s.keys}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化密钥库失败: %w", err)
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("创建密钥库目录失败: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入密钥库失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("写入密钥库失败: %w", err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime, s.size = fileStamp(info)
+	}
+	return nil
+}
+
+// mergeKeys 将磁盘上的密钥合并到内存中的密钥：补上内存中没有的密钥，并保留磁盘上的吊销
+func mergeKeys(keys, disk []*Key) []*Key {
+	byID := make(map[string]*Key, len(keys))
+	for _, key := range keys {
+		byID[key.ID] = key
+	}
+	for _, d := range disk {
+		key, ok := byID[d.ID]
+		if !ok {
+			keys = append(keys, d)
+			continue
+		}
+		if !key.Revoked() && d.Revoked() {
+			key.RevokedAt = d.RevokedAt
+		}
+	}
+	return keys
+}
+
+// hashToken 计算令牌的SHA-256哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
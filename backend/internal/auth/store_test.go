@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{"translate", []string{ScopeTranslate}, false},
+		{"admin和translate", []string{ScopeAdmin, ScopeTranslate}, false},
+		{"空权限", nil, true},
+		{"未知权限", []string{ScopeTranslate, "write"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateScopes(tt.scopes); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateScopes(%v) = %v，期望出错 %v", tt.scopes, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{ScopeTranslate}, ScopeTranslate, true},
+		{[]string{ScopeTranslate}, ScopeAdmin, false},
+		// admin包含所有权限
+		{[]string{ScopeAdmin}, ScopeTranslate, true},
+		{[]string{ScopeAdmin}, ScopeAdmin, true},
+		{nil, ScopeTranslate, false},
+	}
+	for _, tt := range tests {
+		key := &Key{Scopes: tt.scopes}
+		if got := key.HasScope(tt.scope); got != tt.want {
+			t.Errorf("%v.HasScope(%q) = %v，期望 %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestStoreLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "keys.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开不存在的密钥库失败: %v", err)
+	}
+	if keys := store.List(); len(keys) != 0 {
+		t.Fatalf("新密钥库 = %+v，期望为空", keys)
+	}
+
+	key, token, err := store.Create("ci", []string{ScopeTranslate}, true)
+	if err != nil {
+		t.Fatalf("创建密钥失败: %v", err)
+	}
+	if !strings.HasPrefix(token, tokenPrefix) || !strings.HasPrefix(token, key.Prefix) {
+		t.Errorf("令牌 %q 与前缀 %q 不符", token, key.Prefix)
+	}
+	if key.Hash == token || key.Hash != hashToken(token) {
+		t.Error("密钥库应只保存令牌的哈希")
+	}
+	if _, _, err := store.Create("bad", []string{"write"}, false); err == nil {
+		t.Error("无效的权限范围应返回错误")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取密钥库文件失败: %v", err)
+	}
+	if strings.Contains(string(data), token) {
+		t.Error("密钥库文件中不应出现明文令牌")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("密钥库文件权限 = %v，期望 0600", info.Mode().Perm())
+	}
+
+	// 重新打开后仍能认证
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("重新打开密钥库失败: %v", err)
+	}
+	got, err := reopened.Authenticate(token)
+	if err != nil {
+		t.Fatalf("认证失败: %v", err)
+	}
+	if got.ID != key.ID || got.Name != "ci" || !got.AllowServerCredentials || !got.HasScope(ScopeTranslate) {
+		t.Errorf("认证结果 = %+v，期望 %+v", got, key)
+	}
+	for _, bad := range []string{"", "stk_wrong", strings.TrimPrefix(token, tokenPrefix)} {
+		if _, err := reopened.Authenticate(bad); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Authenticate(%q) = %v，期望 ErrInvalidToken", bad, err)
+		}
+	}
+
+	// 吊销后不能认证，密钥仍保留在列表中
+	revoked, err := reopened.Revoke(key.ID)
+	if err != nil || !revoked.Revoked() {
+		t.Fatalf("吊销密钥 = %+v, %v", revoked, err)
+	}
+	if _, err := reopened.Revoke(key.ID); err != nil {
+		t.Errorf("重复吊销 = %v，期望成功", err)
+	}
+	if _, err := reopened.Authenticate(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("吊销后认证 = %v，期望 ErrInvalidToken", err)
+	}
+	if _, err := reopened.Revoke("key_missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("吊销不存在的密钥 = %v，期望 ErrKeyNotFound", err)
+	}
+
+	final, err := Open(path)
+	if err != nil {
+		t.Fatalf("重新打开密钥库失败: %v", err)
+	}
+	if keys := final.List(); len(keys) != 1 || !keys[0].Revoked() {
+		t.Errorf("吊销应持久化: %+v", keys)
+	}
+}
+
+func TestOpenInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("无效的密钥库文件应返回错误")
+	}
+}
+
+func TestStoreSeesChangesFromOtherProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	server, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, token, err := server.Create("leaked", []string{ScopeTranslate}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Authenticate(token); err != nil {
+		t.Fatalf("认证失败: %v", err)
+	}
+
+	// 另一个进程（例如 keys revoke 命令）吊销密钥后，运行中的密钥库立即拒绝该令牌
+	cli, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Revoke(key.ID); err != nil {
+		t.Fatalf("通过第二个密钥库吊销失败: %v", err)
+	}
+	if _, err := server.Authenticate(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("其他进程吊销后认证 = %v，期望 ErrInvalidToken", err)
+	}
+
+	// 其他进程创建的密钥可以直接使用，之后的写入不会覆盖它
+	_, cliToken, err := cli.Create("from-cli", []string{ScopeTranslate}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.Authenticate(cliToken); err != nil {
+		t.Errorf("其他进程创建的密钥认证失败: %v", err)
+	}
+	if _, _, err := server.Create("from-server", []string{ScopeAdmin}, false); err != nil {
+		t.Fatal(err)
+	}
+	if keys := cli.List(); len(keys) != 3 {
+		t.Errorf("密钥数 = %d，期望 3: %+v", len(keys), keys)
+	}
+}
+
+func TestSaveMergesWithFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	server, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, revokedToken, err := server.Create("revoked", []string{ScopeTranslate}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, cliToken, err := cli.Create("from-cli", []string{ScopeTranslate}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cli.Revoke(revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟修改时间精度不足，检测不到文件变化：写入时仍需与文件合并
+	server.modTime, server.size = cli.modTime, cli.size
+	if _, _, err := server.Create("from-server", []string{ScopeTranslate}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	final, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := final.List(); len(keys) != 3 {
+		t.Errorf("合并后密钥数 = %d，期望 3: %+v", len(keys), keys)
+	}
+	if _, err := final.Authenticate(cliToken); err != nil {
+		t.Errorf("其他进程创建的密钥被覆盖: %v", err)
+	}
+	if _, err := final.Authenticate(revokedToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("其他进程的吊销被覆盖: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command 命令行子命令
+type command struct {
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) error
+}
+
+// commands 所有已注册的子命令
+var commands = map[string]command{
+	"config":   {usage: "检查配置文件和环境变量是否有效（validate）", run: runConfig},
+	"estimate": {usage: "估算翻译字幕的计费字符数和各提供商的费用", run: runEstimate},
+	"export":   {usage: "导出供译员使用的交换文件（XLIFF 1.2/2.0、PO、CSV）", run: runExport},
+	"import":   {usage: "将译员返回的交换文件合并回原字幕", run: runImport},
+	"keys":     {usage: "管理服务器API密钥（create、list、revoke）", run: runKeys},
+	"qa":       {usage: "检查字幕质量（重叠、时长、阅读速度、漏译等）", run: runQA},
+	"secrets":  {usage: "管理加密凭据库中的提供商凭据（set、list、delete、passwd）", run: runSecrets},
+	"timing":   {usage: "调整字幕时间轴（平移、缩放、帧率转换、两点同步）", run: runTiming},
+	"usage":    {usage: "导出用量台账（CSV）", run: runUsage},
+}
+
+// IsCommand 判断参数是否为已注册的子命令
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok || name == "help"
+}
+
+// Run 执行子命令，返回进程退出码
+func Run(args []string) int {
+	return run(args, os.Stdout, os.Stderr)
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" {
+		printUsage(stderr)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "未知的子命令: %s\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	if err := cmd.run(args[1:], stdout, stderr); err != nil {
+		fmt.Fprintf(stderr, "错误: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printUsage 打印子命令列表
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: subtitleTranslate [子命令] [参数]")
+	fmt.Fprintln(w, "不带子命令时启动HTTP服务器。")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "子命令:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
+
+// readInput 读取输入文件，路径为"-"时读取标准输入
+func readInput(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("必须指定输入文件 -in")
+	}
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取输入文件失败: %w", err)
+	}
+	return data, nil
+}
+
+// writeOutput 写入输出文件，路径为空或"-"时写入标准输出
+func writeOutput(path string, content []byte, stdout io.Writer) error {
+	if path == "" || path == "-" {
+		_, err := stdout.Write(content)
+		return err
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	return nil
+}
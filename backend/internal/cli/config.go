@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/frank0/subtitleTranslate/config"
+)
+
+// runConfig 执行 config 子命令，目前支持 validate：检查配置文件和环境变量是否有效
+func runConfig(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return fmt.Errorf("用法: config validate [-config 配置文件]")
+	}
+
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("config", "", "配置文件（.json、.yaml、.yml、.toml），默认与服务器相同：CONFIG_PATH 或可执行文件所在目录下的config.*")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *path == "" {
+		*path = config.Path()
+	}
+
+	if _, err := config.Parse(*path); err != nil {
+		problems := splitErrors(err)
+		fmt.Fprintf(stdout, "%s: 发现%d个问题\n", *path, len(problems))
+		for _, problem := range problems {
+			fmt.Fprintf(stdout, "  - %v\n", problem)
+		}
+		return fmt.Errorf("配置无效")
+	}
+	fmt.Fprintf(stdout, "%s: 配置有效\n", *path)
+	return nil
+}
+
+// splitErrors 把 errors.Join 合并的错误拆开，便于逐条显示
+func splitErrors(err error) []error {
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
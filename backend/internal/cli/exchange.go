@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/exchange"
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+)
+
+// runExport 执行 export 子命令，将字幕导出为XLIFF/PO/CSV交换文件
+func runExport(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	in := fs.String("in", "", "字幕文件")
+	out := fs.String("out", "", "输出的交换文件，默认写入标准输出")
+	format := fs.String("format", "", "交换格式: xliff12、xliff20、po 或 csv，默认按 -out 扩展名选择")
+	source := fs.String("source", "", "源语言，写入交换文件")
+	target := fs.String("target", "", "目标语言，写入交换文件")
+	lostThreshold := fs.Float64("lost-threshold", 0, "允许丢弃的字幕比例（0~1），默认0.1")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = exchangeFormatFor(*out)
+	}
+	normalized, err := exchange.Normalize(*format)
+	if err != nil {
+		return err
+	}
+
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{LostCueThreshold: *lostThreshold})
+	entries, err := parseFile(factory, *in, stderr)
+	if err != nil {
+		return err
+	}
+	content, err := exchange.Export(normalized, exchange.Units(entries, nil), exchange.Meta{
+		Original:       filepath.Base(*in),
+		SourceLanguage: *source,
+		TargetLanguage: *target,
+	})
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, content, stdout)
+}
+
+// runImport 执行 import 子命令，将译员返回的交换文件合并回原字幕
+func runImport(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	in := fs.String("in", "", "原字幕文件")
+	translation := fs.String("translation", "", "译员返回的交换文件（.xlf/.xliff/.po/.csv）")
+	out := fs.String("out", "", "输出文件，格式按扩展名选择，默认与原字幕相同并写入标准输出")
+	bilingual := fs.Bool("bilingual", false, "输出双语字幕")
+	above := fs.Bool("above", false, "双语字幕中译文在原文上方")
+	lostThreshold := fs.Float64("lost-threshold", 0, "允许丢弃的字幕比例（0~1），默认0.1")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *translation == "" {
+		return fmt.Errorf("必须指定交换文件 -translation")
+	}
+
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{LostCueThreshold: *lostThreshold})
+	entries, err := parseFile(factory, *in, stderr)
+	if err != nil {
+		return err
+	}
+	data, err := readInput(*translation)
+	if err != nil {
+		return err
+	}
+	units, err := exchange.Parse(*translation, data)
+	if err != nil {
+		return err
+	}
+	translated, diagnostics, err := exchange.Match(entries, units)
+	printDiagnostics(stderr, diagnostics)
+	if err != nil {
+		return err
+	}
+
+	outputFormat, position := "translation_only", "below"
+	if *bilingual {
+		outputFormat = "original_and_translation"
+	}
+	if *above {
+		position = "above"
+	}
+	subtitle.ApplyTranslations(entries, translated, outputFormat, position)
+
+	outName := *in
+	if *out != "" && *out != "-" {
+		outName = *out
+	}
+	printDiagnostics(stderr, factory.Validate(outName, entries))
+	return writeOutput(*out, factory.Build(outName, entries, outputFormat), stdout)
+}
+
+// exchangeFormatFor 根据输出文件扩展名选择交换格式
+func exchangeFormatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlf", ".xliff":
+		return exchange.FormatXLIFF12
+	case ".po":
+		return exchange.FormatPO
+	case ".csv":
+		return exchange.FormatCSV
+	}
+	return ""
+}
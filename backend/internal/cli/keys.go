@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/auth"
+)
+
+// runKeys 执行 keys 子命令，管理服务器API密钥（create、list、revoke）
+func runKeys(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: keys create|list|revoke [参数]")
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("keys "+action, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	storePath := fs.String("store", "", "密钥库文件，默认使用配置中的auth.keyStore")
+	var name, scopes, id *string
+	var allowServer *bool
+	switch action {
+	case "create":
+		name = fs.String("name", "", "密钥名称")
+		scopes = fs.String("scopes", auth.ScopeTranslate, "权限范围，逗号分隔: translate、admin")
+		allowServer = fs.Bool("allow-server-credentials", false, "允许未携带提供商密钥的请求使用服务器配置的密钥")
+	case "revoke":
+		id = fs.String("id", "", "要吊销的密钥ID")
+	case "list":
+	default:
+		return fmt.Errorf("未知的操作: %s（可选 create、list、revoke）", action)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *storePath == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		*storePath = cfg.Auth.KeyStore
+	}
+	store, err := auth.Open(*storePath)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "create":
+		if *name == "" {
+			return fmt.Errorf("必须指定密钥名称 -name")
+		}
+		key, token, err := store.Create(*name, splitList(*scopes), *allowServer)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "已创建密钥 %s，令牌只显示这一次，请妥善保存\n", key.ID)
+		fmt.Fprintln(stdout, token)
+	case "revoke":
+		if *id == "" {
+			return fmt.Errorf("必须指定密钥ID -id")
+		}
+		if _, err := store.Revoke(*id); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "已吊销密钥 %s\n", *id)
+	case "list":
+		for _, key := range store.List() {
+			status := "有效"
+			if key.Revoked() {
+				status = "已吊销"
+			}
+			server := ""
+			if key.AllowServerCredentials {
+				server = " 服务器凭据"
+			}
+			fmt.Fprintf(stdout, "%s\t%s\t%s...\t%s%s\t%s\t%s\n", key.ID, key.Name, key.Prefix,
+				strings.Join(key.Scopes, ","), server, key.CreatedAt.Format("2006-01-02 15:04:05"), status)
+		}
+	}
+	return nil
+}
+
+// splitList 拆分逗号分隔的参数
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
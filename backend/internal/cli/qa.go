@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/qa"
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+)
+
+// runQA 执行 qa 子命令，发现错误时返回非零退出码
+func runQA(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("qa", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	in := fs.String("in", "", "待检查的字幕文件")
+	source := fs.String("source", "", "原文字幕文件（可选），用于检查译文")
+	lang := fs.String("lang", "", "译文语言，例如 zh、en")
+	minDuration := fs.Duration("min-duration", 0, "最短持续时间，默认833ms")
+	maxCPS := fs.Float64("max-cps", 0, "每秒最大字符数，默认按语言选择")
+	maxChars := fs.Int("max-chars", 0, "每行最大字符数，默认按语言选择")
+	maxLines := fs.Int("max-lines", 0, "每条字幕最大行数，默认按语言选择")
+	asJSON := fs.Bool("json", false, "以JSON格式输出报告")
+	lostThreshold := fs.Float64("lost-threshold", 0, "允许丢弃的字幕比例（0~1），默认0.1")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{LostCueThreshold: *lostThreshold})
+	entries, err := parseFile(factory, *in, stderr)
+	if err != nil {
+		return err
+	}
+	var sourceEntries []models.SubtitleEntry
+	if *source != "" {
+		if sourceEntries, err = parseFile(factory, *source, stderr); err != nil {
+			return err
+		}
+	}
+
+	opts := qa.FromRequest(models.QAOptions{
+		MinDurationMs:   minDuration.Milliseconds(),
+		MaxCPS:          *maxCPS,
+		MaxCharsPerLine: *maxChars,
+		MaxLines:        *maxLines,
+	}, *lang)
+	report := qa.Check(entries, sourceEntries, opts)
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range report.Findings {
+			fmt.Fprintf(stdout, "#%-5d %-7s %-18s %s\n", f.Index, f.Severity, f.Code, f.Message)
+		}
+		fmt.Fprintf(stdout, "共 %d 个错误，%d 个警告，%d 个提示\n", report.Errors, report.Warnings, report.Infos)
+	}
+
+	if report.Errors > 0 {
+		return fmt.Errorf("质量检查发现%d个错误", report.Errors)
+	}
+	return nil
+}
+
+// parseFile 读取并解析字幕文件，诊断信息写入stderr
+func parseFile(factory *subtitle.ParserFactory, path string, stderr io.Writer) ([]models.SubtitleEntry, error) {
+	content, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := factory.GetParser(path); err != nil {
+		return nil, err
+	}
+	entries, diagnostics, err := factory.Parse(path, content)
+	printDiagnostics(stderr, diagnostics)
+	if err != nil {
+		return nil, fmt.Errorf("解析字幕文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// printDiagnostics 输出解析诊断信息
+func printDiagnostics(w io.Writer, diagnostics []models.Diagnostic) {
+	for _, d := range diagnostics {
+		if d.Line == 0 {
+			// 输出格式的检查结果没有对应的输入行
+			fmt.Fprintf(w, "%-7s %s\n", d.Severity, d.Message)
+			continue
+		}
+		fmt.Fprintf(w, "第%d行 %-7s %s\n", d.Line, d.Severity, d.Message)
+	}
+}
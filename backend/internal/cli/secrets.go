@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/secrets"
+)
+
+// runSecrets 执行 secrets 子命令，管理加密凭据库（set、list、delete、passwd）
+// 口令从 SECRETS_PASSPHRASE 或 SECRETS_PASSPHRASE_FILE 读取；凭据值从标准输入或文件读取，不出现在命令行参数中
+func runSecrets(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: secrets set|list|delete|passwd [参数]")
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("secrets "+action, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	storePath := fs.String("store", "", "加密凭据库文件，默认使用配置中的secrets.store")
+	var name, valueFile *string
+	switch action {
+	case "set":
+		name = fs.String("name", "", "凭据名: "+strings.Join(config.SecretNames(), "、"))
+		valueFile = fs.String("value-file", "-", "从文件读取凭据值，默认读取标准输入")
+	case "delete":
+		name = fs.String("name", "", "要删除的凭据名")
+	case "list", "passwd":
+	default:
+		return fmt.Errorf("未知的操作: %s（可选 set、list、delete、passwd）", action)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *storePath == "" {
+		path, err := config.SecretsStorePath(config.Path())
+		if err != nil {
+			return err
+		}
+		*storePath = path
+	}
+	passphrase, err := secrets.Passphrase()
+	if err != nil {
+		return err
+	}
+	store, err := secrets.Open(*storePath, passphrase)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "set":
+		if !slices.Contains(config.SecretNames(), *name) {
+			return fmt.Errorf("无效的凭据名%q，可选: %s", *name, strings.Join(config.SecretNames(), "、"))
+		}
+		data, err := readValue(*valueFile)
+		if err != nil {
+			return err
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+		if value == "" {
+			return fmt.Errorf("凭据值不能为空")
+		}
+		store.Set(*name, value)
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "已保存 %s 到 %s，运行中的服务器收到SIGHUP后生效\n", *name, *storePath)
+	case "delete":
+		if !store.Delete(*name) {
+			return fmt.Errorf("凭据%s不存在", *name)
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "已删除 %s\n", *name)
+	case "list":
+		// 只列出名称，不显示凭据值
+		for _, name := range store.Names() {
+			fmt.Fprintln(stdout, name)
+		}
+	case "passwd":
+		newPassphrase, ok, err := secrets.FromEnv("SECRETS_NEW_PASSPHRASE")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("请用SECRETS_NEW_PASSPHRASE或SECRETS_NEW_PASSPHRASE_FILE提供新口令")
+		}
+		if err := store.ChangePassphrase(newPassphrase); err != nil {
+			return err
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "已更换 %s 的口令，请同时更新服务器的SECRETS_PASSPHRASE\n", *storePath)
+	}
+	return nil
+}
+
+// readValue 读取凭据值，路径为"-"时读取标准输入
+func readValue(path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("读取标准输入失败: %w", err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取凭据文件失败: %w", err)
+	}
+	return data, nil
+}
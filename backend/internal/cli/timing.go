@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+	"github.com/frank0/subtitleTranslate/internal/timing"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+)
+
+// anchorList 可重复的 -sync 参数
+type anchorList []timing.Anchor
+
+func (l *anchorList) String() string {
+	return fmt.Sprint(len(*l))
+}
+
+// Set 解析 "序号=时间" 形式的锚点，例如 1=00:00:05,000
+func (l *anchorList) Set(value string) error {
+	index, ts, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("锚点格式应为 序号=时间: %s", value)
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(index))
+	if err != nil {
+		return fmt.Errorf("无效的字幕序号: %s", index)
+	}
+	t, err := utils.ParseTimestamp(ts)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, timing.Anchor{Index: i, Time: t})
+	return nil
+}
+
+// runTiming 执行 timing 子命令
+func runTiming(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("timing", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	in := fs.String("in", "", "输入字幕文件，\"-\" 表示标准输入")
+	out := fs.String("out", "", "输出字幕文件，默认写入标准输出")
+	format := fs.String("format", "", "输入格式扩展名（读取标准输入时使用），例如 srt")
+	shift := fs.Duration("shift", 0, "整体平移，例如 2s、-1500ms")
+	scale := fs.Float64("scale", 0, "线性缩放系数")
+	fps := fs.String("fps", "", "帧率转换，格式为 原帧率:目标帧率，例如 25:23.976")
+	var anchors anchorList
+	fs.Var(&anchors, "sync", "两点同步锚点 序号=时间，需指定两次")
+	subFPS := fs.Float64("sub-fps", 0, "MicroDVD字幕的帧率，默认读取文件头或使用23.976")
+	lostThreshold := fs.Float64("lost-threshold", 0, "允许丢弃的字幕比例（0~1），默认0.1")
+	samiClass := fs.String("sami-class", "", "SAMI字幕的语言类（例如KRCC），默认使用第一个语言类")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	content, err := readInput(*in)
+	if err != nil {
+		return err
+	}
+
+	filename := *in
+	if *format != "" {
+		filename = "input." + strings.TrimPrefix(*format, ".")
+	}
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{LostCueThreshold: *lostThreshold, FPS: *subFPS, SAMISourceClass: *samiClass})
+	if _, err := factory.GetParser(filename); err != nil {
+		return err
+	}
+	entries, diagnostics, err := factory.Parse(filename, content)
+	printDiagnostics(stderr, diagnostics)
+	if err != nil {
+		return fmt.Errorf("解析字幕文件失败: %w", err)
+	}
+
+	applied := false
+	if *shift != 0 {
+		if entries, err = timing.Shift(entries, *shift); err != nil {
+			return err
+		}
+		applied = true
+	}
+	if *scale != 0 {
+		if entries, err = timing.Scale(entries, *scale); err != nil {
+			return err
+		}
+		applied = true
+	}
+	if *fps != "" {
+		from, to, err := parseFPSPair(*fps)
+		if err != nil {
+			return err
+		}
+		if entries, err = timing.ConvertFramerate(entries, from, to); err != nil {
+			return err
+		}
+		applied = true
+	}
+	if len(anchors) > 0 {
+		if len(anchors) != 2 {
+			return fmt.Errorf("两点同步需要恰好两个 -sync 锚点")
+		}
+		if entries, err = timing.Sync(entries, anchors[0], anchors[1]); err != nil {
+			return err
+		}
+		applied = true
+	}
+	if !applied {
+		return fmt.Errorf("至少需要指定 -shift、-scale、-fps 或 -sync 之一")
+	}
+
+	outName := filename
+	if *out != "" && *out != "-" {
+		outName = *out
+	}
+	printDiagnostics(stderr, factory.Validate(outName, entries))
+	return writeOutput(*out, factory.Build(outName, entries, "translation_only"), stdout)
+}
+
+// parseFPSPair 解析 "25:23.976" 形式的帧率对
+func parseFPSPair(value string) (float64, float64, error) {
+	fromStr, toStr, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("帧率格式应为 原帧率:目标帧率: %s", value)
+	}
+	from, err := strconv.ParseFloat(strings.TrimSpace(fromStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的帧率: %s", fromStr)
+	}
+	to, err := strconv.ParseFloat(strings.TrimSpace(toStr), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无效的帧率: %s", toStr)
+	}
+	return from, to, nil
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/language"
+	"github.com/frank0/subtitleTranslate/internal/subtitle"
+	"github.com/frank0/subtitleTranslate/internal/usage"
+)
+
+// runEstimate 执行 estimate 子命令，估算翻译字幕的计费字符数和各提供商的费用
+func runEstimate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("estimate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	in := fs.String("in", "", "字幕文件")
+	providers := fs.String("provider", "", "提供商，逗号分隔，默认估算所有提供商")
+	asJSON := fs.Bool("json", false, "以JSON格式输出")
+	lostThreshold := fs.Float64("lost-threshold", 0, "允许丢弃的字幕比例（0~1），默认0.1")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	names := language.ProviderNames()
+	if *providers != "" {
+		names = splitList(*providers)
+		for _, name := range names {
+			if _, err := language.GetProvider(name); err != nil {
+				return err
+			}
+		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	factory := subtitle.NewParserFactoryWithOptions(subtitle.Options{LostCueThreshold: *lostThreshold})
+	entries, err := parseFile(factory, *in, stderr)
+	if err != nil {
+		return err
+	}
+	texts := make([]string, len(entries))
+	for i, entry := range entries {
+		texts[i] = entry.Content
+	}
+	estimate := usage.Estimate(texts, names, cfg.Pricing)
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(estimate)
+	}
+	fmt.Fprintf(stdout, "字幕 %d 条，源文 %d 字符，重复字幕节省 %d 字符，空白字幕 %d 条\n",
+		estimate.Cues, estimate.SourceCharacters, estimate.DuplicateCharacters, estimate.BlankCues)
+	for _, p := range estimate.Providers {
+		cost := "未配置价格"
+		if p.Cost != nil {
+			cost = fmt.Sprintf("%.4f %s", *p.Cost, p.Currency)
+		}
+		fmt.Fprintf(stdout, "  %-8s 计费 %8d 字符  %s\n", p.Provider, p.BillableCharacters, cost)
+	}
+	return nil
+}
+
+// runUsage 执行 usage 子命令，将服务器的用量台账导出为CSV
+func runUsage(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("file", "", "用量记录文件，默认使用配置中的limits.usageFile")
+	client := fs.String("client", "", "只导出指定客户端（API密钥ID或 ip:地址）")
+	from := fs.String("from", "", "起始日期（UTC），格式 2006-01-02")
+	to := fs.String("to", "", "结束日期（UTC），格式 2006-01-02")
+	out := fs.String("out", "", "输出的CSV文件，默认写入标准输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		*file = cfg.Limits.UsageFile
+	}
+	store, err := usage.Open(*file)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := usage.WriteCSV(&buf, store.Records(*client, *from, *to), cfg.Pricing); err != nil {
+		return err
+	}
+	return writeOutput(*out, buf.Bytes(), stdout)
+}
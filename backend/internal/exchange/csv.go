@@ -0,0 +1,82 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// csvHeader CSV导出的表头，导入时按表头名称查找列，列顺序可以调整
+var csvHeader = []string{"id", "timing", "source", "target", "status"}
+
+// exportCSV 导出CSV，文件开头写入UTF-8 BOM以便表格软件识别编码
+func exportCSV(units []Unit) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	for _, unit := range units {
+		status := ""
+		if unit.Fuzzy {
+			status = "fuzzy"
+		}
+		if err := w.Write([]string{unit.ID, unit.Note, unit.Source, unit.Target, status}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("生成CSV失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseCSV 解析CSV，需要id和target列，source和status列可选
+func parseCSV(data []byte) ([]Unit, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\uFEFF"))))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析CSV失败: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV为空")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	idCol, ok := columns["id"]
+	if !ok {
+		return nil, fmt.Errorf("CSV缺少id列")
+	}
+	targetCol, ok := columns["target"]
+	if !ok {
+		return nil, fmt.Errorf("CSV缺少target列")
+	}
+	cell := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var units []Unit
+	for n, record := range records[1:] {
+		if len(record) <= idCol || len(record) <= targetCol {
+			return nil, fmt.Errorf("CSV第%d行列数不足", n+2)
+		}
+		units = append(units, Unit{
+			ID:     strings.TrimSpace(record[idCol]),
+			Source: cell(record, "source"),
+			Target: record[targetCol],
+			Note:   cell(record, "timing"),
+			Fuzzy:  strings.EqualFold(strings.TrimSpace(cell(record, "status")), "fuzzy"),
+		})
+	}
+	return units, nil
+}
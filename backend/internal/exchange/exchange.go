@@ -0,0 +1,210 @@
+package exchange
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// 支持的交换格式
+const (
+	FormatXLIFF12 = "xliff12" // XLIFF 1.2
+	FormatXLIFF20 = "xliff20" // XLIFF 2.0
+	FormatPO      = "po"      // gettext PO
+	FormatCSV     = "csv"     // 逗号分隔表格
+)
+
+// Unit 一个翻译单元，对应一条字幕
+type Unit struct {
+	ID     string // 单元ID，即字幕在文件中的序号（从1开始）
+	Source string // 原文
+	Target string // 译文，导出时为空或机器翻译预填
+	Note   string // 时间轴，作为注释提供给译员
+	Fuzzy  bool   // 译文需要审校（机器翻译预填）
+}
+
+// Meta 交换文件的元数据
+type Meta struct {
+	Original       string // 原始字幕文件名
+	SourceLanguage string
+	TargetLanguage string
+}
+
+// Units 将字幕条目转换为翻译单元，prefill不为空时作为需要审校的译文预填
+func Units(entries []models.SubtitleEntry, prefill []string) []Unit {
+	units := make([]Unit, len(entries))
+	for i, entry := range entries {
+		units[i] = Unit{
+			ID:     strconv.Itoa(i + 1),
+			Source: entry.Content,
+			Note:   entry.TimeRange,
+		}
+		if i < len(prefill) {
+			units[i].Target = prefill[i]
+			units[i].Fuzzy = true
+		}
+	}
+	return units
+}
+
+// Normalize 规范化格式名，例如 "xliff"、"XLIFF 1.2" -> xliff12
+func Normalize(format string) (string, error) {
+	key := strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "", ".", "").Replace(format))
+	switch key {
+	case "xliff", "xliff12", "xlf":
+		return FormatXLIFF12, nil
+	case "xliff2", "xliff20":
+		return FormatXLIFF20, nil
+	case "po", "gettext":
+		return FormatPO, nil
+	case "csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("不支持的交换格式: %s（可选 xliff12、xliff20、po、csv）", format)
+}
+
+// Extension 返回格式对应的文件扩展名
+func Extension(format string) string {
+	switch format {
+	case FormatXLIFF12, FormatXLIFF20:
+		return ".xlf"
+	case FormatPO:
+		return ".po"
+	}
+	return ".csv"
+}
+
+// Export 将翻译单元导出为指定格式
+func Export(format string, units []Unit, meta Meta) ([]byte, error) {
+	format, err := Normalize(format)
+	if err != nil {
+		return nil, err
+	}
+	if meta.SourceLanguage == "" {
+		// XLIFF要求声明源语言，未知时使用und（未确定）
+		meta.SourceLanguage = "und"
+	}
+	switch format {
+	case FormatXLIFF12:
+		return exportXLIFF12(units, meta)
+	case FormatXLIFF20:
+		return exportXLIFF20(units, meta)
+	case FormatPO:
+		return exportPO(units, meta), nil
+	default:
+		return exportCSV(units)
+	}
+}
+
+// Parse 根据文件扩展名解析译员返回的交换文件，XLIFF版本根据文件内容识别
+func Parse(filename string, data []byte) ([]Unit, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlf", ".xliff":
+		return parseXLIFF(data)
+	case ".po":
+		return parsePO(string(data))
+	case ".csv":
+		return parseCSV(data)
+	}
+	return nil, fmt.Errorf("不支持的交换文件格式: %s", filepath.Ext(filename))
+}
+
+// Match 将译文单元与字幕条目按ID对应，返回每条字幕的译文
+// 缺少的单元和不存在的ID都视为错误；原文被改动或译文为空时给出警告，空译文保留原文
+func Match(entries []models.SubtitleEntry, units []Unit) ([]string, []models.Diagnostic, error) {
+	var diagnostics []models.Diagnostic
+	byID := make(map[string]Unit, len(units))
+	var unknown, duplicate []string
+	for _, unit := range units {
+		id := strings.TrimSpace(unit.ID)
+		n, err := strconv.Atoi(id)
+		if err != nil || n < 1 || n > len(entries) {
+			unknown = append(unknown, unit.ID)
+			continue
+		}
+		if _, exists := byID[id]; exists {
+			duplicate = append(duplicate, id)
+			continue
+		}
+		byID[id] = unit
+	}
+
+	var missing []string
+	translated := make([]string, len(entries))
+	for i, entry := range entries {
+		id := strconv.Itoa(i + 1)
+		unit, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if unit.Source != "" && normalizeText(unit.Source) != normalizeText(entry.Content) {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("单元%s的原文与字幕不一致，请确认交换文件对应的是同一版本", id),
+				Raw:      unit.Source,
+			})
+		}
+		switch {
+		case strings.TrimSpace(unit.Target) == "":
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("单元%s没有译文，保留原文", id),
+				Raw:      entry.Content,
+			})
+			translated[i] = entry.Content
+		default:
+			if unit.Fuzzy {
+				diagnostics = append(diagnostics, models.Diagnostic{
+					Line:     i + 1,
+					Severity: models.SeverityInfo,
+					Message:  fmt.Sprintf("单元%s的译文标记为需要审校", id),
+					Raw:      unit.Target,
+				})
+			}
+			translated[i] = unit.Target
+		}
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "缺少单元: "+summarizeIDs(missing))
+	}
+	if len(unknown) > 0 {
+		problems = append(problems, "未知的单元ID: "+summarizeIDs(unknown))
+	}
+	if len(duplicate) > 0 {
+		problems = append(problems, "重复的单元ID: "+summarizeIDs(duplicate))
+	}
+	if len(problems) > 0 {
+		return nil, diagnostics, fmt.Errorf("交换文件与字幕不匹配（字幕%d条，单元%d个）：%s", len(entries), len(units), strings.Join(problems, "；"))
+	}
+	return translated, diagnostics, nil
+}
+
+// normalizeText 比较原文时忽略换行和多余空白的差异
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// summarizeIDs 列出ID，过多时只显示前10个
+func summarizeIDs(ids []string) string {
+	sort.SliceStable(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
+	if len(ids) > 10 {
+		return strings.Join(ids[:10], ", ") + fmt.Sprintf(" 等%d个", len(ids))
+	}
+	return strings.Join(ids, ", ")
+}
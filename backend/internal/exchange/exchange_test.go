@@ -0,0 +1,206 @@
+package exchange
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// sampleUnits 覆盖多行文本、引号、标记、逗号、重复原文、预填译文和空译文
+func sampleUnits() []Unit {
+	return []Unit{
+		{ID: "1", Source: "Hello, \"world\"!", Target: "你好，“世界”！", Note: "00:00:01,000 --> 00:00:02,000"},
+		{ID: "2", Source: "<i>Line one</i>\nLine two & three", Target: "第一行\n第二行和第三行", Note: "00:00:03,000 --> 00:00:04,000", Fuzzy: true},
+		{ID: "3", Source: "Hello, \"world\"!", Note: "00:00:05,000 --> 00:00:06,000"},
+	}
+}
+
+func TestExportParseRoundTrip(t *testing.T) {
+	meta := Meta{Original: "movie.srt", SourceLanguage: "en", TargetLanguage: "zh"}
+	for _, format := range []string{"xliff", "XLIFF 2.0", "po", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			normalized, err := Normalize(format)
+			if err != nil {
+				t.Fatalf("Normalize(%q) 返回错误: %v", format, err)
+			}
+			data, err := Export(format, sampleUnits(), meta)
+			if err != nil {
+				t.Fatalf("导出失败: %v", err)
+			}
+			got, err := Parse("movie"+Extension(normalized), data)
+			if err != nil {
+				t.Fatalf("解析导出结果失败: %v\n%s", err, data)
+			}
+
+			want := sampleUnits()
+			// 只有CSV保留时间轴列，其他格式的注释不读回
+			if normalized != FormatCSV {
+				for i := range want {
+					want[i].Note = ""
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("往返后 = %+v\n期望 %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{"xlf": FormatXLIFF12, "XLIFF-1.2": FormatXLIFF12, "xliff_2": FormatXLIFF20, "gettext": FormatPO, "CSV": FormatCSV}
+	for input, want := range tests {
+		if got, err := Normalize(input); err != nil || got != want {
+			t.Errorf("Normalize(%q) = %q, %v，期望 %q", input, got, err, want)
+		}
+	}
+	if _, err := Normalize("docx"); err == nil {
+		t.Error("不支持的格式应返回错误")
+	}
+}
+
+func TestParseXLIFFFromTools(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Unit
+	}{
+		{
+			name: "XLIFF 1.2 内联标记和候选译文",
+			data: `<?xml version="1.0"?>
+<xliff version="1.2"><file source-language="en" target-language="zh" datatype="plaintext" original="a.srt"><body>
+<trans-unit id="1"><source>Hello <g id="b">world</g></source><target state="needs-review-translation">你好<g id="b">世界</g></target>
+<alt-trans><target>候选</target></alt-trans><note>00:00:01,000 --> 00:00:02,000</note></trans-unit>
+</body></file></xliff>`,
+			want: []Unit{{ID: "1", Source: "Hello world", Target: "你好世界", Fuzzy: true}},
+		},
+		{
+			name: "XLIFF 2.0 多个segment",
+			data: `<?xml version="1.0"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="zh"><file id="f1">
+<unit id="2"><notes><note>timing</note></notes><segment state="translated"><source>One. </source><target>一。</target></segment><segment><source>Two.</source><target>二。</target></segment></unit>
+</file></xliff>`,
+			want: []Unit{{ID: "2", Source: "One. Two.", Target: "一。二。"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("a.xliff", []byte(tt.data))
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("解析结果 = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		want     string
+	}{
+		{"不支持的扩展名", "a.txt", "", "不支持的交换文件格式"},
+		{"XLIFF缺少版本", "a.xlf", "<xliff></xliff>", "缺少<xliff version>"},
+		{"PO缺少msgctxt", "a.po", "msgid \"Hello\"\nmsgstr \"你好\"\n", "缺少msgctxt"},
+		{"PO无法识别的关键字", "a.po", "msgctxt \"1\"\nmsgfoo \"x\"\n", "PO第2行"},
+		{"CSV缺少target列", "a.csv", "id,source\n1,Hello\n", "缺少target列"},
+		{"CSV列数不足", "a.csv", "id,source,target\n1,Hello,你好\n2\n", "CSV第3行"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.filename, []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("错误 = %v，期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVColumnOrder(t *testing.T) {
+	data := "\uFEFFTarget,status,ID\n\"你好,世界\",FUZZY,1\n"
+	got, err := Parse("a.csv", []byte(data))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	want := []Unit{{ID: "1", Target: "你好,世界", Fuzzy: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("解析结果 = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, Content: "Hello"},
+		{Index: 2, Content: "Line one\nLine two"},
+		{Index: 3, Content: "Bye"},
+	}
+	tests := []struct {
+		name        string
+		units       []Unit
+		want        []string
+		diagnostics []string
+		wantErr     string
+	}{
+		{
+			name: "全部对应",
+			units: []Unit{
+				{ID: "3", Source: "Bye", Target: "再见"},
+				{ID: "1", Source: "Hello", Target: "你好"},
+				// 原文换行和空白的差异不算改动
+				{ID: " 2 ", Source: "Line one Line  two", Target: "第一行\n第二行"},
+			},
+			want: []string{"你好", "第一行\n第二行", "再见"},
+		},
+		{
+			name: "原文改动、空译文和需要审校",
+			units: []Unit{
+				{ID: "1", Source: "Hi", Target: "你好"},
+				{ID: "2", Target: " "},
+				{ID: "3", Target: "再见", Fuzzy: true},
+			},
+			want:        []string{"你好", "Line one\nLine two", "再见"},
+			diagnostics: []string{"warning:单元1的原文与字幕不一致", "warning:单元2没有译文", "info:单元3的译文标记为需要审校"},
+		},
+		{
+			name:    "缺少单元",
+			units:   []Unit{{ID: "1", Target: "你好"}},
+			wantErr: "缺少单元: 2, 3",
+		},
+		{
+			name:    "未知和重复的ID",
+			units:   []Unit{{ID: "1", Target: "a"}, {ID: "2", Target: "b"}, {ID: "3", Target: "c"}, {ID: "4", Target: "d"}, {ID: "x", Target: "e"}, {ID: "0", Target: "f"}, {ID: "2", Target: "g"}},
+			wantErr: "未知的单元ID: 0, 4, x；重复的单元ID: 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diagnostics, err := Match(entries, tt.units)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Match 返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("译文 = %q，期望 %q", got, tt.want)
+			}
+			if len(diagnostics) != len(tt.diagnostics) {
+				t.Fatalf("诊断信息 = %+v，期望 %d 条", diagnostics, len(tt.diagnostics))
+			}
+			for i, d := range diagnostics {
+				severity, message, _ := strings.Cut(tt.diagnostics[i], ":")
+				if d.Severity != severity || !strings.HasPrefix(d.Message, message) || d.Line != i+1 {
+					t.Errorf("第%d条诊断信息 = %+v，期望 %s", i+1, d, tt.diagnostics[i])
+				}
+			}
+		})
+	}
+}
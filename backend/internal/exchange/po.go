@@ -0,0 +1,167 @@
+package exchange
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// exportPO 导出gettext PO文件
+// 相同原文可能出现多次，以msgctxt区分单元ID；时间轴写为提取注释（#.），预填译文标记为fuzzy
+func exportPO(units []Unit, meta Meta) []byte {
+	var b strings.Builder
+	b.WriteString("msgid \"\"\nmsgstr \"\"\n")
+	b.WriteString("\"Content-Type: text/plain; charset=UTF-8\\n\"\n")
+	b.WriteString("\"Content-Transfer-Encoding: 8bit\\n\"\n")
+	if meta.TargetLanguage != "" {
+		b.WriteString(poQuote("Language: "+meta.TargetLanguage+"\n") + "\n")
+	}
+	if meta.SourceLanguage != "" {
+		b.WriteString(poQuote("X-Source-Language: "+meta.SourceLanguage+"\n") + "\n")
+	}
+
+	for _, unit := range units {
+		b.WriteString("\n")
+		if unit.Note != "" {
+			b.WriteString("#. " + unit.Note + "\n")
+		}
+		if meta.Original != "" {
+			b.WriteString("#: " + meta.Original + ":" + unit.ID + "\n")
+		}
+		if unit.Fuzzy {
+			b.WriteString("#, fuzzy\n")
+		}
+		b.WriteString("msgctxt " + poQuote(unit.ID) + "\n")
+		writePOString(&b, "msgid", unit.Source)
+		writePOString(&b, "msgstr", unit.Target)
+	}
+	return []byte(b.String())
+}
+
+// writePOString 写入关键字和字符串，多行文本按换行拆为多个字符串
+func writePOString(b *strings.Builder, keyword, s string) {
+	if !strings.Contains(s, "\n") {
+		b.WriteString(keyword + " " + poQuote(s) + "\n")
+		return
+	}
+	b.WriteString(keyword + " \"\"\n")
+	lines := strings.SplitAfter(s, "\n")
+	for _, line := range lines {
+		if line != "" {
+			b.WriteString(poQuote(line) + "\n")
+		}
+	}
+}
+
+// poQuote 按PO格式转义并加引号
+func poQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// parsePO 解析PO文件，msgctxt为单元ID，跳过文件头和已废弃（#~）的条目
+func parsePO(content string) ([]Unit, error) {
+	var units []Unit
+	var current *Unit
+	var fuzzy bool
+	var field *string // 当前续行写入的字段
+	var ctxt, id, str string
+	hasCtxt := false
+
+	flush := func() {
+		if current != nil && !(id == "" && !hasCtxt) {
+			current.ID, current.Source, current.Target, current.Fuzzy = ctxt, id, str, fuzzy
+			units = append(units, *current)
+		}
+		current, field = nil, nil
+		ctxt, id, str, fuzzy, hasCtxt = "", "", "", false, false
+	}
+
+	for n, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#~"):
+			continue
+		case strings.HasPrefix(line, "#,"):
+			if current != nil && (id != "" || str != "") {
+				flush()
+			}
+			fuzzy = fuzzy || strings.Contains(line, "fuzzy")
+			continue
+		case strings.HasPrefix(line, "#"):
+			if current != nil && (id != "" || str != "") {
+				flush()
+			}
+			continue
+		case strings.HasPrefix(line, `"`):
+			if field == nil {
+				return nil, fmt.Errorf("PO第%d行：续行之前没有msgid或msgstr", n+1)
+			}
+			s, err := poUnquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("PO第%d行：%w", n+1, err)
+			}
+			*field += s
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		s, err := poUnquote(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("PO第%d行：%w", n+1, err)
+		}
+		switch {
+		case keyword == "msgctxt":
+			if current != nil {
+				flush()
+			}
+			current = &Unit{}
+			ctxt, hasCtxt, field = s, true, &ctxt
+		case keyword == "msgid":
+			if current != nil && (id != "" || str != "") {
+				flush()
+			}
+			if current == nil {
+				current = &Unit{}
+			}
+			id, field = s, &id
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			if current == nil {
+				return nil, fmt.Errorf("PO第%d行：msgstr之前没有msgid", n+1)
+			}
+			// 复数形式只取第一个
+			if keyword == "msgstr" || keyword == "msgstr[0]" {
+				str, field = s, &str
+			} else {
+				field = new(string)
+			}
+		case keyword == "msgid_plural":
+			field = new(string)
+		default:
+			return nil, fmt.Errorf("PO第%d行：无法识别的关键字 %s", n+1, keyword)
+		}
+	}
+	flush()
+
+	// 没有msgctxt的PO（例如被工具去掉）无法对应字幕
+	for i, unit := range units {
+		if unit.ID == "" {
+			return nil, fmt.Errorf("PO中第%d个条目缺少msgctxt（单元ID）: %s", i+1, unit.Source)
+		}
+	}
+	return units, nil
+}
+
+// poUnquote 去掉引号并还原转义
+func poUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("字符串缺少引号: %s", s)
+	}
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("无效的转义: %s", s)
+	}
+	return unquoted, nil
+}
@@ -0,0 +1,223 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XLIFF 1.2 文档结构（仅导出用到的部分）
+type xliff12Doc struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string      `xml:"version,attr"`
+	File    xliff12File `xml:"file"`
+}
+
+type xliff12File struct {
+	Original       string        `xml:"original,attr"`
+	SourceLanguage string        `xml:"source-language,attr"`
+	TargetLanguage string        `xml:"target-language,attr,omitempty"`
+	Datatype       string        `xml:"datatype,attr"`
+	Units          []xliff12Unit `xml:"body>trans-unit"`
+}
+
+type xliff12Unit struct {
+	ID     string         `xml:"id,attr"`
+	Space  string         `xml:"http://www.w3.org/XML/1998/namespace space,attr"`
+	Source string         `xml:"source"`
+	Target *xliff12Target `xml:"target,omitempty"`
+	Note   string         `xml:"note,omitempty"`
+}
+
+type xliff12Target struct {
+	State string `xml:"state,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+// XLIFF 2.0 文档结构
+type xliff20Doc struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:2.0 xliff"`
+	Version string      `xml:"version,attr"`
+	SrcLang string      `xml:"srcLang,attr"`
+	TrgLang string      `xml:"trgLang,attr,omitempty"`
+	File    xliff20File `xml:"file"`
+}
+
+type xliff20File struct {
+	ID       string        `xml:"id,attr"`
+	Original string        `xml:"original,attr,omitempty"`
+	Units    []xliff20Unit `xml:"unit"`
+}
+
+type xliff20Unit struct {
+	ID      string         `xml:"id,attr"`
+	Notes   []xliff20Note  `xml:"notes>note,omitempty"`
+	Segment xliff20Segment `xml:"segment"`
+}
+
+type xliff20Note struct {
+	Category string `xml:"category,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+type xliff20Segment struct {
+	State  string     `xml:"state,attr,omitempty"`
+	Source xliffText  `xml:"source"`
+	Target *xliffText `xml:"target,omitempty"`
+}
+
+type xliffText struct {
+	Space string `xml:"http://www.w3.org/XML/1998/namespace space,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+// marshalXML 序列化文档并加上XML声明
+func marshalXML(doc any) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成XLIFF失败: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// exportXLIFF12 导出XLIFF 1.2，预填的译文标记为needs-review-translation
+func exportXLIFF12(units []Unit, meta Meta) ([]byte, error) {
+	doc := xliff12Doc{
+		Version: "1.2",
+		File: xliff12File{
+			Original:       meta.Original,
+			SourceLanguage: meta.SourceLanguage,
+			TargetLanguage: meta.TargetLanguage,
+			Datatype:       "plaintext",
+		},
+	}
+	for _, unit := range units {
+		u := xliff12Unit{ID: unit.ID, Space: "preserve", Source: unit.Source, Note: unit.Note}
+		if unit.Target != "" {
+			u.Target = &xliff12Target{Text: unit.Target, State: "translated"}
+			if unit.Fuzzy {
+				u.Target.State = "needs-review-translation"
+			}
+		}
+		doc.File.Units = append(doc.File.Units, u)
+	}
+	return marshalXML(doc)
+}
+
+// exportXLIFF20 导出XLIFF 2.0，预填的译文所在segment状态为initial
+func exportXLIFF20(units []Unit, meta Meta) ([]byte, error) {
+	doc := xliff20Doc{
+		Version: "2.0",
+		SrcLang: meta.SourceLanguage,
+		TrgLang: meta.TargetLanguage,
+		File:    xliff20File{ID: "f1", Original: meta.Original},
+	}
+	for _, unit := range units {
+		u := xliff20Unit{
+			ID:      unit.ID,
+			Segment: xliff20Segment{Source: xliffText{Space: "preserve", Text: unit.Source}},
+		}
+		if unit.Note != "" {
+			u.Notes = []xliff20Note{{Category: "timing", Text: unit.Note}}
+		}
+		if unit.Target != "" {
+			u.Segment.Target = &xliffText{Space: "preserve", Text: unit.Target}
+			u.Segment.State = "translated"
+			if unit.Fuzzy {
+				u.Segment.State = "initial"
+			}
+		}
+		doc.File.Units = append(doc.File.Units, u)
+	}
+	return marshalXML(doc)
+}
+
+// parseXLIFF 解析XLIFF 1.2的trans-unit或2.0的unit
+// 只读取source和target的文本，内联标记（g、ph等）只保留其中的文字；
+// 忽略alt-trans等候选译文；2.0的多个segment按顺序拼接
+func parseXLIFF(data []byte) ([]Unit, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var units []Unit
+	var current *Unit
+	var capture *strings.Builder // 当前读取的source或target
+	var source, target strings.Builder
+	skip := 0     // 位于alt-trans、originalData等不读取的元素内的深度
+	captured := 0 // 位于source/target内的深度
+	version := ""
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析XLIFF失败: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			switch {
+			case skip > 0:
+				skip++
+			case name == "xliff":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "version" {
+						version = attr.Value
+					}
+				}
+			case name == "alt-trans" || name == "originalData" || name == "note" || name == "notes":
+				skip = 1
+			case current == nil && (name == "trans-unit" || name == "unit"):
+				current = &Unit{}
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "id" {
+						current.ID = attr.Value
+					}
+				}
+				source.Reset()
+				target.Reset()
+			case current != nil && captured == 0 && name == "source":
+				capture, captured = &source, 1
+			case current != nil && captured == 0 && name == "target":
+				capture, captured = &target, 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "state" && strings.HasPrefix(attr.Value, "needs-") {
+						current.Fuzzy = true
+					}
+				}
+			case current != nil && name == "segment":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "state" && attr.Value == "initial" {
+						current.Fuzzy = true
+					}
+				}
+			case captured > 0:
+				captured++
+			}
+		case xml.EndElement:
+			name := t.Name.Local
+			switch {
+			case skip > 0:
+				skip--
+			case captured > 0:
+				if captured--; captured == 0 {
+					capture = nil
+				}
+			case current != nil && (name == "trans-unit" || name == "unit"):
+				current.Source, current.Target = source.String(), target.String()
+				units = append(units, *current)
+				current = nil
+			}
+		case xml.CharData:
+			if capture != nil && skip == 0 {
+				capture.Write(t)
+			}
+		}
+	}
+	if version == "" {
+		return nil, fmt.Errorf("不是有效的XLIFF文件：缺少<xliff version>")
+	}
+	return units, nil
+}
@@ -0,0 +1,181 @@
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Result 语言识别结果
+type Result struct {
+	Language   string  `json:"language"`   // 识别出的语言代码，无法识别时为空
+	Script     string  `json:"script"`     // 主要文字系统，例如 Latin、Han
+	Confidence float64 `json:"confidence"` // 置信度 0~1
+}
+
+// MinConfidence 识别结果可用于替代"auto"的最低置信度
+const MinConfidence = 0.5
+
+// maxSampleRunes 参与识别的最大字符数，足够判断语言且避免大文件耗时
+const maxSampleRunes = 20000
+
+// scripts 参与判断的文字系统，顺序决定同票时的优先级
+var scripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Han", unicode.Han},
+	{"Hiragana", unicode.Hiragana},
+	{"Katakana", unicode.Katakana},
+	{"Hangul", unicode.Hangul},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Arabic", unicode.Arabic},
+	{"Thai", unicode.Thai},
+	{"Greek", unicode.Greek},
+	{"Hebrew", unicode.Hebrew},
+	{"Devanagari", unicode.Devanagari},
+}
+
+// scriptLanguages 只对应一种常用语言的文字系统
+var scriptLanguages = map[string]string{
+	"Hangul":     "ko",
+	"Cyrillic":   "ru",
+	"Arabic":     "ar",
+	"Thai":       "th",
+	"Greek":      "el",
+	"Hebrew":     "he",
+	"Devanagari": "hi",
+}
+
+// Detect 识别一组字幕文本的语言，不访问网络
+func Detect(texts []string) Result {
+	var sample strings.Builder
+	runes := 0
+	for _, text := range texts {
+		if runes >= maxSampleRunes {
+			break
+		}
+		sample.WriteString(text)
+		sample.WriteByte('\n')
+		runes += len([]rune(text))
+	}
+	return DetectText(sample.String())
+}
+
+// DetectText 识别单段文本的语言
+func DetectText(text string) Result {
+	counts := countScripts(text)
+	script, total := "", 0
+	for _, s := range scripts {
+		total += counts[s.name]
+	}
+	if total == 0 {
+		return Result{}
+	}
+
+	// 假名与汉字一起计入日文/中文的判断
+	cjk := counts["Han"] + counts["Hiragana"] + counts["Katakana"]
+	best := 0
+	for _, s := range scripts {
+		n := counts[s.name]
+		if s.name == "Han" {
+			n = cjk
+		} else if s.name == "Hiragana" || s.name == "Katakana" {
+			continue
+		}
+		if n > best {
+			script, best = s.name, n
+		}
+	}
+	share := float64(best) / float64(total)
+
+	switch script {
+	case "Han":
+		kana := counts["Hiragana"] + counts["Katakana"]
+		if kana*10 >= cjk {
+			return Result{Language: "ja", Script: script, Confidence: share}
+		}
+		return Result{Language: chineseVariant(text), Script: script, Confidence: share}
+	case "Latin":
+		lang, score := detectLatin(text)
+		return Result{Language: lang, Script: script, Confidence: share * score}
+	default:
+		return Result{Language: scriptLanguages[script], Script: script, Confidence: share}
+	}
+}
+
+// countScripts 统计各文字系统的字符数
+func countScripts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range text {
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.name]++
+				break
+			}
+		}
+	}
+	return counts
+}
+
+// Script 返回文本的主要文字系统，假名归入Han
+func Script(text string) string {
+	return DetectText(text).Script
+}
+
+// ExpectedScript 返回语言通常使用的文字系统，未知语言返回空字符串
+func ExpectedScript(language string) string {
+	switch Base(language) {
+	case "":
+		return ""
+	case "zh", "ja":
+		return "Han"
+	case "ko":
+		return "Hangul"
+	case "ru", "uk", "bg", "sr", "kk", "mn":
+		return "Cyrillic"
+	case "ar", "fa", "ur":
+		return "Arabic"
+	case "th":
+		return "Thai"
+	case "el":
+		return "Greek"
+	case "he":
+		return "Hebrew"
+	case "hi":
+		return "Devanagari"
+	default:
+		return "Latin"
+	}
+}
+
+// Base 返回语言代码的主语言部分，例如 zh-TW 返回 zh
+func Base(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	return language
+}
+
+// 常用繁体字与对应的简体字，用于区分简繁中文
+const (
+	traditionalChars = "們這個來說會時對國過還點樣麼沒為學開關電話頭發現當體問題讓應該經無後見長東車門馬魚鳥書買賣聽覺讀寫愛氣與從們將業錢"
+	simplifiedChars  = "们这个来说会时对国过还点样么没为学开关电话头发现当体问题让应该经无后见长东车门马鱼鸟书买卖听觉读写爱气与从们将业钱"
+)
+
+// chineseVariant 根据简繁特征字判断中文变体
+func chineseVariant(text string) string {
+	traditional, simplified := 0, 0
+	for _, r := range text {
+		if strings.ContainsRune(traditionalChars, r) && !strings.ContainsRune(simplifiedChars, r) {
+			traditional++
+		} else if strings.ContainsRune(simplifiedChars, r) && !strings.ContainsRune(traditionalChars, r) {
+			simplified++
+		}
+	}
+	if traditional > simplified {
+		return "zh-TW"
+	}
+	return "zh"
+}
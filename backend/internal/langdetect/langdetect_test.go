@@ -0,0 +1,73 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		texts    []string
+		language string
+		script   string
+	}{
+		{"英文", []string{"What are you doing here?", "I don't know what you mean."}, "en", "Latin"},
+		{"法文", []string{"Je ne sais pas ce que vous voulez.", "C'est très bien, merci."}, "fr", "Latin"},
+		{"德文", []string{"Ich weiß nicht, was du meinst.", "Das ist nicht schön."}, "de", "Latin"},
+		{"西班牙文", []string{"¿Qué estás haciendo aquí?", "No lo sé, pero está bien."}, "es", "Latin"},
+		{"简体中文", []string{"你在这里做什么？", "我们没有时间了。"}, "zh", "Han"},
+		{"繁体中文", []string{"你在這裡做什麼？", "我們沒有時間了，這個問題很麻煩。"}, "zh-TW", "Han"},
+		{"日文", []string{"ここで何をしているの？", "わかりません。"}, "ja", "Han"},
+		{"韩文", []string{"여기서 뭐 하는 거야?", "모르겠어요."}, "ko", "Hangul"},
+		{"俄文", []string{"Что ты здесь делаешь?", "Я не знаю."}, "ru", "Cyrillic"},
+		{"只有数字和标点", []string{"123", "...!"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.texts)
+			if got.Language != tt.language || got.Script != tt.script {
+				t.Errorf("Detect = %+v，期望语言 %q，文字 %q", got, tt.language, tt.script)
+			}
+			if tt.language != "" && got.Confidence < MinConfidence {
+				t.Errorf("置信度 %.2f 低于 %.2f", got.Confidence, MinConfidence)
+			}
+		})
+	}
+}
+
+func TestDetectMixedScriptsLowersConfidence(t *testing.T) {
+	pure := DetectText("你在这里做什么")
+	mixed := DetectText("你在这里做什么 OK")
+	if pure.Confidence != 1 {
+		t.Errorf("单一文字置信度 = %.2f，期望 1", pure.Confidence)
+	}
+	if mixed.Language != "zh" || mixed.Confidence >= pure.Confidence {
+		t.Errorf("混合文字 = %+v，期望 zh 且置信度低于 %.2f", mixed, pure.Confidence)
+	}
+}
+
+func TestExpectedScript(t *testing.T) {
+	tests := map[string]string{
+		"":      "",
+		"zh-TW": "Han",
+		"ja":    "Han",
+		"ko":    "Hangul",
+		"uk":    "Cyrillic",
+		"fa":    "Arabic",
+		"EN_us": "Latin",
+		"fr":    "Latin",
+	}
+	for language, want := range tests {
+		if got := ExpectedScript(language); got != want {
+			t.Errorf("ExpectedScript(%q) = %q，期望 %q", language, got, want)
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	tests := map[string]string{"zh-TW": "zh", " EN_us ": "en", "ja": "ja", "": ""}
+	for language, want := range tests {
+		if got := Base(language); got != want {
+			t.Errorf("Base(%q) = %q，期望 %q", language, got, want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// latinProfile 拉丁字母语言的识别特征
+type latinProfile struct {
+	language  string
+	words     string // 高频词（空格分隔），按单词一元模型计分
+	trigrams  string // 高频字符三元组（空格分隔，"_" 表示词边界）
+	signature string // 该语言特有或常见的字母
+}
+
+// latinProfiles 拉丁字母语言特征表
+var latinProfiles = []latinProfile{
+	{
+		language: "en",
+		words:    "the and you to of a i it is that in what this me we for my your have be not on do are was with he no all so just don't i'm it's can know get but like yes here there they now out up",
+		trigrams: "_th the he_ ing ng_ _an and nd_ _yo you ou_ _to hat tha _wh",
+	},
+	{
+		language:  "fr",
+		words:     "le la les de des et est un une je tu vous il elle pas ne que qui c'est j'ai en du au pour dans ce sur avec mais oui non on nous moi toi bien ça",
+		trigrams:  "_le les _de es_ _qu que ent _pa ais ous _vo _je nt_ ion",
+		signature: "éèêàçùœâîô",
+	},
+	{
+		language:  "de",
+		words:     "der die das und ist ich du nicht sie es ein eine zu den mit sich auf wir ihr was wie hat dass ja nein mir mich dich aber noch auch so schon hier",
+		trigrams:  "_de der die ich _ei ein sch che _un und nd_ en_ cht ist _ni",
+		signature: "äöüß",
+	},
+	{
+		language:  "es",
+		words:     "el la los las de que y en un una es no por con para lo se me te mi tu yo qué está estoy pero sí muy del al eso esto aquí bien",
+		trigrams:  "_de de_ _qu que ue_ _la _el os_ _es los nte _co ado ien",
+		signature: "ñ¿¡áíóú",
+	},
+	{
+		language:  "it",
+		words:     "il lo la gli le di che e è un una non per con mi ti si sono ho hai io tu lui lei noi voi cosa questo quello ma come bene qui ci del della",
+		trigrams:  "_di _ch che he_ _il _no non _la re_ to_ zio ell _co",
+		signature: "àèéìòù",
+	},
+	{
+		language:  "pt",
+		words:     "o a os as de do da que e é um uma não em para com eu você ele ela isso isto se me te por mas sim muito está estou aqui bem dos das",
+		trigrams:  "_de de_ _qu que _nã não ão_ ção _co _pa os_ _do com",
+		signature: "ãõçáéêóô",
+	},
+	{
+		language: "nl",
+		words:    "de het een en van ik je is dat niet zijn op te wat er maar met voor hij ze we jij mij hem dit nog wel ook al naar",
+		trigrams: "_de _he het een en_ _ee ij_ _va van aar _ni iet oor",
+	},
+	{
+		language:  "vi",
+		words:     "không là của và có tôi anh em một những cho được người này với đã các đi thì mà ở đó gì như nào rồi",
+		trigrams:  "_kh khô hôn ông _ng ng_ _nh _ch anh _tr inh _đư",
+		signature: "ăâđêôơưạảấầẩẫậắằẳẵặẹẻẽếềểễệỉịọỏốồổỗộớờởỡợụủứừửữựỳỵỷỹ",
+	},
+	{
+		language: "id",
+		words:    "yang dan di ini itu dengan untuk tidak aku kamu ada saya apa dari ke akan sudah bisa kita mereka dia juga tapi",
+		trigrams: "_ya yan ang ng_ _da dan an_ _me _ke _ak kan nya _di",
+	},
+}
+
+// detectLatin 使用单词一元模型和字符三元组对拉丁字母文本打分
+// 返回得分最高的语言以及与次高分拉开的差距（作为置信度系数）
+func detectLatin(text string) (string, float64) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) == 0 {
+		return "", 0
+	}
+
+	grams := make(map[string]int)
+	for _, w := range words {
+		padded := []rune("_" + w + "_")
+		for i := 0; i+3 <= len(padded); i++ {
+			grams[string(padded[i:i+3])]++
+		}
+	}
+
+	scores := make([]float64, len(latinProfiles))
+	for i, p := range latinProfiles {
+		wordSet := make(map[string]bool)
+		for _, w := range strings.Fields(p.words) {
+			wordSet[w] = true
+		}
+		for _, w := range words {
+			if wordSet[w] {
+				scores[i] += 2
+			}
+		}
+		for _, g := range strings.Fields(p.trigrams) {
+			scores[i] += float64(grams[g]) * 0.5
+		}
+		for _, r := range strings.ToLower(text) {
+			if p.signature != "" && strings.ContainsRune(p.signature, r) {
+				scores[i]++
+			}
+		}
+	}
+
+	best, second := -1, -1
+	for i := range scores {
+		if best < 0 || scores[i] > scores[best] {
+			best, second = i, best
+		} else if second < 0 || scores[i] > scores[second] {
+			second = i
+		}
+	}
+	if scores[best] == 0 {
+		return "", 0
+	}
+
+	margin := 1.0
+	if second >= 0 {
+		margin = (scores[best] - scores[second]) / scores[best]
+	}
+	// 差距较小时仍给出基础置信度，避免相近语言（如西葡）被完全否定
+	confidence := 0.4 + 0.6*margin
+	if confidence > 1 {
+		confidence = 1
+	}
+	return latinProfiles[best].language, confidence
+}
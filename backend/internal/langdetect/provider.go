@@ -0,0 +1,45 @@
+package langdetect
+
+import (
+	"context"
+	"sync"
+)
+
+// providerKey 提供商识别结果在context中的键
+type providerKey struct{}
+
+// providerResult 翻译提供商识别出的源语言，多个批次可能并发写入
+type providerResult struct {
+	mu       sync.Mutex
+	language string
+}
+
+// WithProviderDetection 返回可以记录翻译提供商识别结果的context
+func WithProviderDetection(ctx context.Context) context.Context {
+	return context.WithValue(ctx, providerKey{}, &providerResult{})
+}
+
+// RecordProvider 记录翻译提供商识别出的源语言（提供商的语言代码），只保留第一个非空结果
+// ctx不是由WithProviderDetection创建时忽略
+func RecordProvider(ctx context.Context, language string) {
+	result, ok := ctx.Value(providerKey{}).(*providerResult)
+	if !ok || language == "" {
+		return
+	}
+	result.mu.Lock()
+	defer result.mu.Unlock()
+	if result.language == "" {
+		result.language = language
+	}
+}
+
+// ProviderLanguage 返回ctx中记录的提供商识别结果，没有时返回空字符串
+func ProviderLanguage(ctx context.Context) string {
+	result, ok := ctx.Value(providerKey{}).(*providerResult)
+	if !ok {
+		return ""
+	}
+	result.mu.Lock()
+	defer result.mu.Unlock()
+	return result.language
+}
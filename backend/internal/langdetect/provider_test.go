@@ -0,0 +1,32 @@
+package langdetect
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestProviderLanguage(t *testing.T) {
+	// 没有用WithProviderDetection创建的context忽略记录
+	RecordProvider(context.Background(), "fr")
+	if got := ProviderLanguage(context.Background()); got != "" {
+		t.Errorf("ProviderLanguage = %q，期望为空", got)
+	}
+
+	// 并发的批次只保留第一个非空结果
+	ctx := WithProviderDetection(context.Background())
+	RecordProvider(ctx, "")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RecordProvider(ctx, "de")
+		}()
+	}
+	wg.Wait()
+	RecordProvider(ctx, "fr")
+	if got := ProviderLanguage(ctx); got != "de" {
+		t.Errorf("ProviderLanguage = %q，期望 de", got)
+	}
+}
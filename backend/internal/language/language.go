@@ -0,0 +1,262 @@
+package language
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Auto 表示自动检测源语言
+const Auto = "auto"
+
+// Language 规范语言定义，Code 为 BCP-47 标签
+type Language struct {
+	Code    string   `json:"code"`   // 规范代码，例如 zh-Hans、en
+	Name    string   `json:"name"`   // 英文名称
+	Native  string   `json:"native"` // 本地名称
+	aliases []string // 可识别的其他写法（ISO 639-2/3、地区变体、名称）
+}
+
+// languages 支持的语言表
+var languages = []Language{
+	{Code: "zh-Hans", Name: "Chinese (Simplified)", Native: "简体中文", aliases: []string{"zh", "zh-cn", "zh-sg", "zh-hans-cn", "chi", "zho", "cmn", "chinese", "simplified chinese", "中文", "简体", "汉语"}},
+	{Code: "zh-Hant", Name: "Chinese (Traditional)", Native: "繁體中文", aliases: []string{"zh-tw", "zh-hk", "zh-mo", "zh-hant-tw", "zh-hant-hk", "cht", "traditional chinese", "繁体中文", "繁体", "繁體"}},
+	{Code: "en", Name: "English", Native: "English", aliases: []string{"en-us", "en-gb", "eng", "english", "英语", "英文"}},
+	{Code: "ja", Name: "Japanese", Native: "日本語", aliases: []string{"ja-jp", "jp", "jpn", "japanese", "日语", "日文"}},
+	{Code: "ko", Name: "Korean", Native: "한국어", aliases: []string{"ko-kr", "kor", "korean", "韩语", "韩文"}},
+	{Code: "fr", Name: "French", Native: "Français", aliases: []string{"fr-fr", "fr-ca", "fre", "fra", "french", "法语"}},
+	{Code: "de", Name: "German", Native: "Deutsch", aliases: []string{"de-de", "ger", "deu", "german", "德语"}},
+	{Code: "es", Name: "Spanish", Native: "Español", aliases: []string{"es-es", "es-mx", "es-419", "spa", "spanish", "西班牙语"}},
+	{Code: "it", Name: "Italian", Native: "Italiano", aliases: []string{"it-it", "ita", "italian", "意大利语"}},
+	{Code: "ru", Name: "Russian", Native: "Русский", aliases: []string{"ru-ru", "rus", "russian", "俄语"}},
+	{Code: "pt", Name: "Portuguese", Native: "Português", aliases: []string{"pt-pt", "pt-br", "por", "portuguese", "葡萄牙语"}},
+	{Code: "ar", Name: "Arabic", Native: "العربية", aliases: []string{"ara", "arabic", "阿拉伯语"}},
+	{Code: "th", Name: "Thai", Native: "ไทย", aliases: []string{"tha", "thai", "泰语"}},
+	{Code: "vi", Name: "Vietnamese", Native: "Tiếng Việt", aliases: []string{"vie", "vietnamese", "越南语"}},
+	{Code: "id", Name: "Indonesian", Native: "Bahasa Indonesia", aliases: []string{"ind", "in", "indonesian", "印尼语"}},
+	{Code: "ms", Name: "Malay", Native: "Bahasa Melayu", aliases: []string{"msa", "may", "malay", "马来语"}},
+	{Code: "tr", Name: "Turkish", Native: "Türkçe", aliases: []string{"tur", "turkish", "土耳其语"}},
+	{Code: "nl", Name: "Dutch", Native: "Nederlands", aliases: []string{"nld", "dut", "dutch", "荷兰语"}},
+	{Code: "pl", Name: "Polish", Native: "Polski", aliases: []string{"pol", "polish", "波兰语"}},
+	{Code: "uk", Name: "Ukrainian", Native: "Українська", aliases: []string{"ukr", "ukrainian", "乌克兰语"}},
+	{Code: "el", Name: "Greek", Native: "Ελληνικά", aliases: []string{"ell", "gre", "greek", "希腊语"}},
+	{Code: "he", Name: "Hebrew", Native: "עברית", aliases: []string{"heb", "iw", "hebrew", "希伯来语"}},
+	{Code: "hi", Name: "Hindi", Native: "हिन्दी", aliases: []string{"hin", "hindi", "印地语"}},
+}
+
+// lookup 规范化后的写法 -> 规范代码
+var lookup = func() map[string]string {
+	m := make(map[string]string)
+	for _, l := range languages {
+		m[strings.ToLower(l.Code)] = l.Code
+		m[strings.ToLower(l.Name)] = l.Code
+		for _, a := range l.aliases {
+			m[a] = l.Code
+		}
+	}
+	return m
+}()
+
+// Normalize 将用户输入的语言（zh_CN、chi、Chinese、中文等）规范为BCP-47代码
+// 空字符串和"auto"返回Auto
+func Normalize(input string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(input))
+	key = strings.ReplaceAll(key, "_", "-")
+	if key == "" || key == Auto {
+		return Auto, nil
+	}
+	if code, ok := lookup[key]; ok {
+		return code, nil
+	}
+	// 带地区或文字子标签时回退到主语言，例如 fr-BE -> fr
+	switch {
+	case strings.HasPrefix(key, "zh-hant"):
+		return "zh-Hant", nil
+	case strings.HasPrefix(key, "zh-hans"):
+		return "zh-Hans", nil
+	}
+	if i := strings.Index(key, "-"); i > 0 {
+		if code, ok := lookup[key[:i]]; ok && code != "zh-Hans" {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("无法识别的语言: %s", input)
+}
+
+// Get 返回规范代码对应的语言定义
+func Get(code string) (Language, bool) {
+	for _, l := range languages {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Language{}, false
+}
+
+// All 返回所有支持的语言
+func All() []Language {
+	return append([]Language(nil), languages...)
+}
+
+// Provider 翻译提供商的语言方言与支持范围
+type Provider struct {
+	Name         string            // 提供商标识
+	SupportsAuto bool              // 是否支持自动检测源语言
+	codes        map[string]string // 规范代码 -> 提供商代码，未列出的语言不支持
+	pairs        func(source, target string) bool
+}
+
+// providers 各翻译提供商的语言支持
+var providers = map[string]*Provider{
+	"volce": {
+		Name:         "volce",
+		SupportsAuto: true,
+		codes: withOverrides(all(), map[string]string{
+			"zh-Hans": "zh",
+			"zh-Hant": "zh-Hant",
+		}),
+	},
+	"google": {
+		Name:         "google",
+		SupportsAuto: true,
+		codes: withOverrides(all(), map[string]string{
+			"zh-Hans": "zh-CN",
+			"zh-Hant": "zh-TW",
+			"he":      "iw",
+		}),
+	},
+	"tencent": {
+		Name:         "tencent",
+		SupportsAuto: false,
+		codes: withOverrides(only("zh-Hans", "zh-Hant", "en", "ja", "ko", "fr", "de", "es", "it", "ru", "pt", "ar", "th", "vi", "id", "ms", "tr", "hi"), map[string]string{
+			"zh-Hans": "zh",
+			"zh-Hant": "zh-TW",
+		}),
+		pairs: tencentPairs,
+	},
+	"aliyun": {
+		Name:         "aliyun",
+		SupportsAuto: true,
+		codes: withOverrides(all(), map[string]string{
+			"zh-Hans": "zh",
+			"zh-Hant": "zh-tw",
+		}),
+	},
+}
+
+// all 所有语言使用规范代码本身
+func all() map[string]string {
+	m := make(map[string]string)
+	for _, l := range languages {
+		m[l.Code] = l.Code
+	}
+	return m
+}
+
+// only 仅包含指定语言
+func only(codes ...string) map[string]string {
+	m := make(map[string]string)
+	for _, c := range codes {
+		m[c] = c
+	}
+	return m
+}
+
+// withOverrides 覆盖部分语言的提供商代码
+func withOverrides(m map[string]string, overrides map[string]string) map[string]string {
+	for k, v := range overrides {
+		m[k] = v
+	}
+	return m
+}
+
+// tencentPairs 腾讯云文本翻译支持的语言对
+func tencentPairs(source, target string) bool {
+	major := []string{"zh-Hans", "zh-Hant", "en", "ja", "ko", "fr", "es", "it", "de", "tr", "ru", "pt", "vi", "id", "th", "ms"}
+	switch source {
+	case "zh-Hans", "zh-Hant":
+		return contains(append(major, "ar"), target)
+	case "en":
+		return contains(append(major, "ar", "hi"), target)
+	case "ja", "ko":
+		return contains([]string{"zh-Hans", "zh-Hant", "en", "ja", "ko"}, target)
+	case "ar", "hi":
+		return contains([]string{"zh-Hans", "zh-Hant", "en"}, target)
+	default:
+		return contains(major, target)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProvider 返回提供商的语言支持定义
+func GetProvider(name string) (*Provider, error) {
+	p, ok := providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的翻译提供商: %s", name)
+	}
+	return p, nil
+}
+
+// ProviderNames 返回所有提供商标识
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Languages 返回提供商支持的规范语言代码
+func (p *Provider) Languages() []string {
+	var codes []string
+	for _, l := range languages {
+		if _, ok := p.codes[l.Code]; ok {
+			codes = append(codes, l.Code)
+		}
+	}
+	return codes
+}
+
+// Code 将规范代码转换为提供商代码，Auto原样返回
+func (p *Provider) Code(code string) (string, error) {
+	if code == Auto {
+		return Auto, nil
+	}
+	c, ok := p.codes[code]
+	if !ok {
+		return "", fmt.Errorf("翻译提供商%s不支持语言: %s", p.Name, code)
+	}
+	return c, nil
+}
+
+// Supports 判断提供商是否支持从source翻译到target（均为规范代码）
+func (p *Provider) Supports(source, target string) error {
+	if target == Auto {
+		return fmt.Errorf("目标语言不能为auto")
+	}
+	if _, err := p.Code(target); err != nil {
+		return err
+	}
+	if source == Auto {
+		if !p.SupportsAuto {
+			return fmt.Errorf("翻译提供商%s不支持自动检测源语言，请指定源语言", p.Name)
+		}
+		return nil
+	}
+	if _, err := p.Code(source); err != nil {
+		return err
+	}
+	if p.pairs != nil && source != target && !p.pairs(source, target) {
+		return fmt.Errorf("翻译提供商%s不支持从%s翻译到%s", p.Name, source, target)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package language
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "", want: Auto},
+		{input: " AUTO ", want: Auto},
+		{input: "zh_CN", want: "zh-Hans"},
+		{input: "chi", want: "zh-Hans"},
+		{input: "中文", want: "zh-Hans"},
+		{input: "zh-TW", want: "zh-Hant"},
+		{input: "zh-Hant-SG", want: "zh-Hant"},
+		{input: "zh-Hans-MY", want: "zh-Hans"},
+		{input: "English", want: "en"},
+		{input: "en-AU", want: "en"},
+		{input: "fr-BE", want: "fr"},
+		{input: "iw", want: "he"},
+		{input: "jpn", want: "ja"},
+		// 未知的中文地区不能猜测简繁
+		{input: "zh-XX", wantErr: true},
+		{input: "klingon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Normalize(%q) 错误 = %v，期望出错 %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q，期望 %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestProviderCode(t *testing.T) {
+	tests := []struct {
+		provider string
+		code     string
+		want     string
+		wantErr  bool
+	}{
+		{provider: "google", code: "zh-Hans", want: "zh-CN"},
+		{provider: "google", code: "he", want: "iw"},
+		{provider: "volce", code: "zh-Hans", want: "zh"},
+		{provider: "aliyun", code: "zh-Hant", want: "zh-tw"},
+		{provider: "tencent", code: "zh-Hant", want: "zh-TW"},
+		{provider: "tencent", code: Auto, want: Auto},
+		{provider: "tencent", code: "nl", wantErr: true},
+	}
+	for _, tt := range tests {
+		p, err := GetProvider(tt.provider)
+		if err != nil {
+			t.Fatalf("GetProvider(%q) 返回错误: %v", tt.provider, err)
+		}
+		got, err := p.Code(tt.code)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s.Code(%q) 错误 = %v，期望出错 %v", tt.provider, tt.code, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s.Code(%q) = %q，期望 %q", tt.provider, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestProviderSupports(t *testing.T) {
+	tests := []struct {
+		provider string
+		source   string
+		target   string
+		wantErr  bool
+	}{
+		{provider: "google", source: Auto, target: "zh-Hans"},
+		{provider: "google", source: "en", target: Auto, wantErr: true},
+		{provider: "tencent", source: Auto, target: "en", wantErr: true},
+		{provider: "tencent", source: "en", target: "hi"},
+		{provider: "tencent", source: "ja", target: "fr", wantErr: true},
+		{provider: "tencent", source: "hi", target: "zh-Hans"},
+		{provider: "tencent", source: "hi", target: "ja", wantErr: true},
+		{provider: "tencent", source: "en", target: "en"},
+	}
+	for _, tt := range tests {
+		p, _ := GetProvider(tt.provider)
+		if err := p.Supports(tt.source, tt.target); (err != nil) != tt.wantErr {
+			t.Errorf("%s.Supports(%q, %q) = %v，期望出错 %v", tt.provider, tt.source, tt.target, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGetProvider(t *testing.T) {
+	if _, err := GetProvider("Google"); err != nil {
+		t.Errorf("提供商名称应不区分大小写: %v", err)
+	}
+	if _, err := GetProvider("deepl"); err == nil {
+		t.Error("未知提供商应返回错误")
+	}
+	names := ProviderNames()
+	want := []string{"aliyun", "google", "tencent", "volce"}
+	if len(names) != len(want) {
+		t.Fatalf("ProviderNames = %v，期望 %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("ProviderNames = %v，期望 %v", names, want)
+			break
+		}
+	}
+}
+
+func TestProviderLanguagesAreCanonical(t *testing.T) {
+	for _, name := range ProviderNames() {
+		p, _ := GetProvider(name)
+		for _, code := range p.Languages() {
+			if _, ok := Get(code); !ok {
+				t.Errorf("%s 支持的语言 %q 不在语言表中", name, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/secrets"
+)
+
+// Options 日志配置
+type Options struct {
+	Level   string // debug、info、warn 或 error，默认info
+	Format  string // json 或 text，默认json
+	Content bool   // 是否记录字幕内容，默认隐藏
+}
+
+// redacted 替换敏感字段的值
+const redacted = secrets.Redacted
+
+// secretKeys 总是隐藏的字段（不区分大小写）
+var secretKeys = map[string]bool{
+	"apikey":        true,
+	"apisecret":     true,
+	"accesskey":     true,
+	"secretkey":     true,
+	"secretid":      true,
+	"secret":        true,
+	"token":         true,
+	"authorization": true,
+	"password":      true,
+}
+
+// contentKeys 字幕内容字段，Options.Content 为false时隐藏
+var contentKeys = map[string]bool{
+	"text":        true,
+	"texts":       true,
+	"content":     true,
+	"translation": true,
+}
+
+// secretParams 匹配URL查询参数中的凭据，例如错误信息中的 ?key=xxx、AccessKeyId=xxx
+var secretParams = regexp.MustCompile(`(?i)\b(key|api_?key|access_?key_?id|secret_?id|secret_?key|signature|token)=[^&\s"]+`)
+
+// New 按配置创建日志记录器，记录时自动附带上下文中的请求ID
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	handlerOpts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact(opts.Content),
+	}
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	case "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("无效的日志格式: %s（可选 json、text）", opts.Format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Setup 创建日志记录器并设为默认，标准库log的输出也会转到该记录器
+func Setup(w io.Writer, opts Options) error {
+	logger, err := New(w, opts)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	// 时间由slog记录，去掉标准库log在消息前加的时间
+	log.SetFlags(0)
+	return nil
+}
+
+// ParseLevel 解析日志级别，空字符串为info
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("无效的日志级别: %s（可选 debug、info、warn、error）", level)
+}
+
+// redact 返回隐藏凭据和字幕内容的 ReplaceAttr
+func redact(logContent bool) func(groups []string, a slog.Attr) slog.Attr {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		key := strings.ToLower(a.Key)
+		if secretKeys[key] || (!logContent && contentKeys[key]) {
+			return slog.String(a.Key, redacted)
+		}
+		// 错误信息中可能带有包含凭据的URL
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, RedactString(v.Error()))
+		case string:
+			return slog.String(a.Key, RedactString(v))
+		}
+		return a
+	}
+}
+
+// RedactString 隐藏字符串中URL查询参数形式的凭据，以及配置中登记过的凭据值
+func RedactString(s string) string {
+	return secrets.Redact(secretParams.ReplaceAllString(s, "${1}="+redacted))
+}
+
+// requestIDKey 请求ID在context中的键
+type requestIDKey struct{}
+
+// WithRequestID 返回携带请求ID的context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回context中的请求ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 为每条日志附加context中的请求ID
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/secrets"
+)
+
+// logOnce 用指定配置记录一条日志，返回解析后的JSON
+func logOnce(t *testing.T, opts Options, ctx context.Context, args ...any) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := New(&buf, opts)
+	if err != nil {
+		t.Fatalf("创建日志记录器失败: %v", err)
+	}
+	logger.InfoContext(ctx, "test", args...)
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("解析日志失败: %v\n%s", err, buf.String())
+	}
+	return entry
+}
+
+func TestRedact(t *testing.T) {
+	secrets.Register("registered-secret-value")
+
+	tests := []struct {
+		name    string
+		content bool
+		key     string
+		value   any
+		want    any
+	}{
+		{name: "凭据字段", key: "apiKey", value: "sk-123456", want: redacted},
+		{name: "凭据字段不区分大小写", key: "Authorization", value: "Bearer stk_abc", want: redacted},
+		{name: "凭据字段的非字符串值", key: "secretId", value: 42, want: redacted},
+		{name: "默认隐藏字幕内容", key: "text", value: "你好", want: redacted},
+		{name: "默认隐藏多条字幕", key: "texts", value: []string{"a", "b"}, want: redacted},
+		{name: "允许记录字幕内容", content: true, key: "text", value: "你好", want: "你好"},
+		{name: "允许记录内容时仍隐藏凭据", content: true, key: "token", value: "abc", want: redacted},
+		{name: "错误中URL的凭据参数", key: "error", value: errors.New(`Get "https://api.example.com/v2?key=AIzaSy123&q=hi": timeout`), want: `Get "https://api.example.com/v2?key=[REDACTED]&q=hi": timeout`},
+		{name: "字符串中的签名参数", key: "url", value: "https://x.example.com/?AccessKeyId=LTAI5t&Signature=abc%3D", want: "https://x.example.com/?AccessKeyId=[REDACTED]&Signature=[REDACTED]"},
+		{name: "已登记的凭据值", key: "detail", value: "provider rejected registered-secret-value", want: "provider rejected " + redacted},
+		{name: "普通字段", key: "provider", value: "google", want: "google"},
+		{name: "数字字段", key: "cues", value: 3, want: float64(3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := logOnce(t, Options{Content: tt.content}, context.Background(), tt.key, tt.value)
+			if got := entry[tt.key]; got != tt.want {
+				t.Errorf("%s = %v，期望 %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactInGroups(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Format: "text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.WithGroup("request").With("apiSecret", "s3cr3t-value").Info("test", slog.Group("body", "content", "字幕内容", "provider", "tencent"))
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t-value") || strings.Contains(out, "字幕内容") {
+		t.Errorf("分组中的凭据和字幕内容未隐藏: %s", out)
+	}
+	if !strings.Contains(out, "request.body.provider=tencent") {
+		t.Errorf("普通字段应保留: %s", out)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+	if got := RequestID(ctx); got != "req-1" {
+		t.Errorf("RequestID = %q，期望 req-1", got)
+	}
+	if got := RequestID(context.Background()); got != "" {
+		t.Errorf("没有请求ID时 = %q，期望为空", got)
+	}
+
+	entry := logOnce(t, Options{}, ctx, "provider", "google")
+	if entry["request_id"] != "req-1" {
+		t.Errorf("日志中的 request_id = %v，期望 req-1", entry["request_id"])
+	}
+	entry = logOnce(t, Options{}, context.Background())
+	if _, ok := entry["request_id"]; ok {
+		t.Errorf("没有请求ID时不应记录 request_id: %v", entry)
+	}
+
+	// With 和 WithGroup 派生的记录器同样附带请求ID
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Options{Format: "text"})
+	logger.With("job", "1").InfoContext(ctx, "test")
+	if !strings.Contains(buf.String(), "request_id=req-1") {
+		t.Errorf("派生的记录器缺少 request_id: %s", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"DEBUG", slog.LevelDebug, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"trace", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.level)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v，期望 %v，出错 %v", tt.level, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Error("无效的日志格式应返回错误")
+	}
+	if _, err := New(&bytes.Buffer{}, Options{Level: "verbose"}); err == nil {
+		t.Error("无效的日志级别应返回错误")
+	}
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "warn", Format: "TEXT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if out := buf.String(); strings.Contains(out, "hidden") || !strings.Contains(out, "level=WARN msg=shown") {
+		t.Errorf("日志级别过滤不正确: %s", out)
+	}
+}
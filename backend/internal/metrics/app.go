@@ -0,0 +1,50 @@
+package metrics
+
+import "time"
+
+// namespace 所有指标名称的前缀
+const namespace = "subtitle_translate_"
+
+// HTTP请求
+var (
+	HTTPRequests = NewCounterVec(namespace+"http_requests_total",
+		"HTTP请求数", "method", "route", "status")
+	HTTPRequestDuration = NewHistogramVec(namespace+"http_request_duration_seconds",
+		"HTTP请求处理时间（秒）", nil, "method", "route")
+)
+
+// 翻译任务
+var (
+	TranslationJobs = NewCounterVec(namespace+"translation_jobs_total",
+		"翻译任务数，status为 success、failed、canceled（请求取消或超时）、skipped（源语言与目标语言相同）或 estimated（试运行）", "provider", "status")
+	CuesTranslated = NewCounterVec(namespace+"cues_translated_total",
+		"翻译成功的字幕条数", "provider", "source", "target")
+	CharactersTranslated = NewCounterVec(namespace+"characters_translated_total",
+		"翻译成功的源文字符数", "provider", "source", "target")
+	DedupeLookups = NewCounterVec(namespace+"dedupe_lookups_total",
+		"需要翻译的非空字幕条数；去重率为 dedupe_hits_total / dedupe_lookups_total", "provider")
+	DedupeHits = NewCounterVec(namespace+"dedupe_hits_total",
+		"复用同一任务中相同字幕译文、未发送给提供商的字幕条数", "provider")
+)
+
+// 提供商调用
+var (
+	ProviderRequestDuration = NewHistogramVec(namespace+"provider_request_duration_seconds",
+		"调用翻译提供商API的耗时（秒），不含速率限制等待", nil, "provider")
+	ProviderErrors = NewCounterVec(namespace+"provider_errors_total",
+		"翻译提供商API调用失败次数，code为提供商返回的错误码、HTTP状态码或 network", "provider", "code")
+	ProviderRetries = NewCounterVec(namespace+"provider_retries_total",
+		"翻译提供商API调用的重试次数", "provider")
+	ProviderCircuitOpens = NewCounterVec(namespace+"provider_circuit_opens_total",
+		"提供商连续失败后熔断的次数", "provider")
+	RateLimiterWait = NewHistogramVec(namespace+"rate_limiter_wait_seconds",
+		"调用提供商前等待速率限制令牌的时间（秒）", []float64{.001, .005, .01, .05, .1, .25, .5, 1, 2.5, 5, 10}, "provider")
+)
+
+// ObserveProviderCall 记录一次提供商API调用的耗时，code不为空时同时记录错误
+func ObserveProviderCall(provider string, start time.Time, code string) {
+	ProviderRequestDuration.Observe(time.Since(start).Seconds(), provider)
+	if code != "" {
+		ProviderErrors.Inc(provider, code)
+	}
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// writeMetric 以文本格式写出单个指标
+func writeMetric(m metric) string {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	m.write(w)
+	w.Flush()
+	return buf.String()
+}
+
+func TestCounterVecText(t *testing.T) {
+	c := NewCounterVec("test_counter_total", "测试计数器\n第二行 C:\\path", "provider", "code")
+	c.Inc("google", "429")
+	c.Add(2.5, "google", "429")
+	c.Add(-1, "google", "429") // 负数被忽略
+	c.Inc("aliyun", "say \"hi\"\n")
+
+	want := `# HELP test_counter_total 测试计数器\n第二行 C:\\path
+# TYPE test_counter_total counter
+test_counter_total{provider="aliyun",code="say \"hi\"\n"} 1
+test_counter_total{provider="google",code="429"} 3.5
+`
+	if got := writeMetric(c); got != want {
+		t.Errorf("文本输出 =\n%s\n期望\n%s", got, want)
+	}
+}
+
+func TestHistogramVecText(t *testing.T) {
+	h := NewHistogramVec("test_duration_seconds", "测试直方图", []float64{1, 0.1}, "provider")
+	h.Observe(0.05, "google")
+	h.Observe(0.1, "google") // 等于上界的观测值计入该桶
+	h.Observe(3, "google")
+
+	want := `# HELP test_duration_seconds 测试直方图
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{provider="google",le="0.1"} 2
+test_duration_seconds_bucket{provider="google",le="1"} 2
+test_duration_seconds_bucket{provider="google",le="+Inf"} 3
+test_duration_seconds_sum{provider="google"} 3.15
+test_duration_seconds_count{provider="google"} 3
+`
+	if got := writeMetric(h); got != want {
+		t.Errorf("文本输出 =\n%s\n期望\n%s", got, want)
+	}
+
+	empty := NewHistogramVec("test_unlabeled_seconds", "无标签", nil)
+	empty.Observe(0.2)
+	if got := writeMetric(empty); !strings.Contains(got, `test_unlabeled_seconds_bucket{le="0.25"} 1`) || !strings.Contains(got, "test_unlabeled_seconds_count 1\n") {
+		t.Errorf("无标签直方图应使用默认桶:\n%s", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	DedupeLookups.Add(4, "test")
+	DedupeHits.Add(1, "test")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		`subtitle_translate_dedupe_lookups_total{provider="test"} 4`,
+		`subtitle_translate_dedupe_hits_total{provider="test"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("输出缺少 %s", line)
+		}
+	}
+	if strings.Contains(body, "cache_") {
+		t.Error("输出中不应有 cache_ 指标")
+	}
+
+	// 指标按名称排序
+	var names []string
+	for _, line := range strings.Split(body, "\n") {
+		if name, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			names = append(names, strings.Fields(name)[0])
+		}
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Errorf("指标未按名称排序: %s 在 %s 之前", names[i-1], names[i])
+		}
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"重复的指标名称", func() { NewCounterVec(namespace+"http_requests_total", "重复") }},
+		{"标签值数量不符", func() { HTTPRequests.Inc("GET") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("期望panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := map[float64]string{0: "0", 1.5: "1.5", 1e21: "1e+21", math.Inf(1): "+Inf", math.Inf(-1): "-Inf", math.NaN(): "NaN"}
+	for v, want := range tests {
+		if got := formatFloat(v); got != want {
+			t.Errorf("formatFloat(%v) = %q，期望 %q", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// CreateKeyRequest 表示创建API密钥的请求
+type CreateKeyRequest struct {
+	Name                   string   `json:"name" binding:"required"`          // 名称，便于识别用途
+	Scopes                 []string `json:"scopes"`                           // 权限范围: "translate"、"admin"，默认translate
+	AllowServerCredentials bool     `json:"allowServerCredentials,omitempty"` // 是否允许使用服务器配置的提供商密钥
+}
+
+// APIKeyInfo 表示一个API密钥的公开信息，不包含令牌
+type APIKeyInfo struct {
+	ID                     string     `json:"id"`                     // 密钥ID
+	Name                   string     `json:"name"`                   // 名称
+	Prefix                 string     `json:"prefix"`                 // 令牌开头几位，用于识别
+	Scopes                 []string   `json:"scopes"`                 // 权限范围
+	AllowServerCredentials bool       `json:"allowServerCredentials"` // 是否允许使用服务器配置的提供商密钥
+	CreatedAt              time.Time  `json:"createdAt"`              // 创建时间
+	RevokedAt              *time.Time `json:"revokedAt,omitempty"`    // 吊销时间
+}
+
+// CreateKeyResponse 表示创建API密钥的结果，令牌只在此时返回一次
+type CreateKeyResponse struct {
+	APIKeyInfo
+	Token string `json:"token"` // 明文令牌，请妥善保存
+}
@@ -8,16 +8,39 @@ type SubtitleFile struct {
 
 // SubtitleEntry 表示一个字幕条目
 type SubtitleEntry struct {
-	Index     int    `json:"index"`     // 字幕序号
-	TimeRange string `json:"timeRange"` // 时间范围
-	Content   string `json:"content"`   // 字幕内容
+	Index      int               `json:"index"`                // 字幕序号
+	TimeRange  string            `json:"timeRange"`            // 时间范围
+	Content    string            `json:"content"`              // 字幕内容
+	Attributes map[string]string `json:"attributes,omitempty"` // 格式相关的附加属性，例如TTML的region、style
+}
+
+// 诊断信息严重程度
+const (
+	SeverityError   = "error"   // 字幕被丢弃
+	SeverityWarning = "warning" // 格式有误，已修复
+	SeverityInfo    = "info"    // 提示信息
+)
+
+// Diagnostic 表示解析字幕时发现的问题
+type Diagnostic struct {
+	Line     int    `json:"line"`          // 行号（从1开始）
+	Severity string `json:"severity"`      // 严重程度: "error", "warning" 或 "info"
+	Message  string `json:"message"`       // 问题描述
+	Raw      string `json:"raw,omitempty"` // 原始文本
 }
 
 // TranslationResult 表示翻译结果
 type TranslationResult struct {
-	OriginalFilename   string `json:"originalFilename"`   // 原始文件名
-	TranslatedFilename string `json:"translatedFilename"` // 翻译后的文件名
-	Content            string `json:"content"`            // 翻译后的内容
+	OriginalFilename     string                `json:"originalFilename"`               // 原始文件名
+	TranslatedFilename   string                `json:"translatedFilename"`             // 翻译后的文件名
+	Content              string                `json:"content"`                        // 翻译后的内容
+	ContentEncoding      string                `json:"contentEncoding,omitempty"`      // 内容编码，二进制格式（EBU STL）为"base64"
+	ReadingSpeedWarnings []ReadingSpeedWarning `json:"readingSpeedWarnings,omitempty"` // 超出行长或阅读速度限制的字幕
+	QA                   *QAReport             `json:"qa,omitempty"`                   // 质量检查报告
+	DetectedLanguage     *DetectedLanguage     `json:"detectedLanguage,omitempty"`     // 识别出的源语言
+	SourceLanguage       string                `json:"sourceLanguage,omitempty"`       // 实际使用的源语言
+	Skipped              bool                  `json:"skipped,omitempty"`              // 源语言与目标语言相同，未调用翻译
+	Estimate             *CostEstimate         `json:"estimate,omitempty"`             // 试运行时的字符数和费用估算
 }
 
 // ApiSettings 表示API设置
@@ -29,21 +52,200 @@ type ApiSettings struct {
 
 // TranslationRequest 表示翻译请求
 type TranslationRequest struct {
-	Filename            string `json:"filename" binding:"required"`       // 文件名
-	Content             string `json:"content" binding:"required"`        // 文件内容
-	TargetLanguage      string `json:"targetLanguage" binding:"required"` // 目标语言
-	SourceLanguage      string `json:"sourceLanguage,omitempty"`          // 源语言，支持腾讯云等需要明确源语言的API
-	Provider            string `json:"provider" binding:"required"`       // 翻译提供商 (volcengine, google, tencent 或 aliyun)
-	OutputFormat        string `json:"outputFormat" binding:"required"`   // 输出格式: "translation_only" 或 "original_and_translation"
-	TranslationPosition string `json:"translationPosition"`               // 翻译位置: "below" 或 "above"
-	ApiKey              string `json:"apiKey,omitempty"`                 // API密钥
-	ApiSecret           string `json:"apiSecret,omitempty"`              // API密钥对应的Secret
-	ApiUrl              string `json:"apiUrl,omitempty"`                 // API地址
+	Filename            string         `json:"filename" binding:"required"`       // 文件名
+	Content             string         `json:"content" binding:"required"`        // 文件内容
+	ContentEncoding     string         `json:"contentEncoding,omitempty"`         // 内容编码: 留空表示文本，"base64"表示二进制内容（例如EBU STL）
+	TargetLanguage      string         `json:"targetLanguage" binding:"required"` // 目标语言
+	SourceLanguage      string         `json:"sourceLanguage,omitempty"`          // 源语言，支持腾讯云等需要明确源语言的API
+	Provider            string         `json:"provider" binding:"required"`       // 翻译提供商 (volcengine, google, tencent 或 aliyun)
+	OutputFormat        string         `json:"outputFormat" binding:"required"`   // 输出格式: "translation_only" 或 "original_and_translation"
+	TranslationPosition string         `json:"translationPosition"`               // 翻译位置: "below" 或 "above"
+	ApiKey              string         `json:"apiKey,omitempty"`                  // API密钥
+	ApiSecret           string         `json:"apiSecret,omitempty"`               // API密钥对应的Secret
+	ApiUrl              string         `json:"apiUrl,omitempty"`                  // API地址
+	Timing              *TimingOptions `json:"timing,omitempty"`                  // 翻译前的时间轴调整（可选）
+	Reflow              *ReflowOptions `json:"reflow,omitempty"`                  // 翻译后的重新断行（可选）
+	QA                  *QAOptions     `json:"qa,omitempty"`                      // 设置后在结果中附带质量检查报告
+	SDH                 *SDHOptions    `json:"sdh,omitempty"`                     // 听障字幕注释处理（可选）
+	Strict              bool           `json:"strict,omitempty"`                  // 严格解析：字幕格式有误时直接报错
+	LostCueThreshold    float64        `json:"lostCueThreshold,omitempty"`        // 允许丢弃的字幕比例（0~1），默认0.1
+	FPS                 float64        `json:"fps,omitempty"`                     // MicroDVD等基于帧的格式的帧率，默认读取文件头
+	SAMISourceClass     string         `json:"samiSourceClass,omitempty"`         // SAMI源语言类（例如KRCC），默认使用文件中的第一个语言类
+	SAMITargetClass     string         `json:"samiTargetClass,omitempty"`         // SAMI译文语言类，默认根据目标语言推断（例如ENCC）
+	DryRun              bool           `json:"dryRun,omitempty"`                  // 试运行：只解析文件并估算计费字符数和费用，不调用提供商
 }
 
 // TranslationResponse 表示翻译响应
 type TranslationResponse struct {
-	Success bool               `json:"success"`         // 是否成功
-	Data    *TranslationResult `json:"data,omitempty"`  // 翻译结果
-	Error   string             `json:"error,omitempty"` // 错误信息
+	Success     bool               `json:"success"`               // 是否成功
+	Data        *TranslationResult `json:"data,omitempty"`        // 翻译结果
+	Error       string             `json:"error,omitempty"`       // 错误信息
+	Diagnostics []Diagnostic       `json:"diagnostics,omitempty"` // 解析字幕时的诊断信息
+}
+
+// SyncAnchor 两点同步的锚点
+type SyncAnchor struct {
+	Index int    `json:"index"` // 字幕序号
+	Time  string `json:"time"`  // 目标时间，格式: 00:00:00,000
+}
+
+// TimingOptions 表示时间轴调整选项
+type TimingOptions struct {
+	Operation string      `json:"operation"`          // 操作: "shift", "scale", "framerate" 或 "sync"
+	OffsetMs  int64       `json:"offsetMs,omitempty"` // 平移量（毫秒），可为负数
+	Factor    float64     `json:"factor,omitempty"`   // 缩放系数
+	FromFPS   float64     `json:"fromFps,omitempty"`  // 原帧率
+	ToFPS     float64     `json:"toFps,omitempty"`    // 目标帧率
+	AnchorA   *SyncAnchor `json:"anchorA,omitempty"`  // 两点同步锚点A
+	AnchorB   *SyncAnchor `json:"anchorB,omitempty"`  // 两点同步锚点B
+}
+
+// TimingRequest 表示独立的时间轴调整请求
+type TimingRequest struct {
+	Filename        string        `json:"filename" binding:"required"` // 文件名
+	Content         string        `json:"content" binding:"required"`  // 文件内容
+	ContentEncoding string        `json:"contentEncoding,omitempty"`   // 内容编码: 留空表示文本，"base64"表示二进制内容
+	Timing          TimingOptions `json:"timing" binding:"required"`   // 时间轴调整选项
+	FPS             float64       `json:"fps,omitempty"`               // MicroDVD等基于帧的格式的帧率，默认读取文件头
+}
+
+// ReflowOptions 表示翻译后重新断行的选项，未设置的字段使用目标语言的默认值
+type ReflowOptions struct {
+	MaxCharsPerLine int     `json:"maxCharsPerLine,omitempty"` // 每行最大字符数
+	MaxLines        int     `json:"maxLines,omitempty"`        // 每条字幕最大行数
+	MaxCPS          float64 `json:"maxCps,omitempty"`          // 每秒最大字符数
+}
+
+// ReadingSpeedWarning 表示一条超出限制的字幕
+type ReadingSpeedWarning struct {
+	Index         int     `json:"index"`                   // 字幕序号
+	CPS           float64 `json:"cps,omitempty"`           // 实际每秒字符数（超限时）
+	MaxLineLength int     `json:"maxLineLength,omitempty"` // 最长行的字符数（超限时）
+	Lines         int     `json:"lines,omitempty"`         // 行数（超限时）
+}
+
+// QAOptions 表示质量检查选项，未设置的字段使用目标语言的默认值
+type QAOptions struct {
+	MinDurationMs   int64   `json:"minDurationMs,omitempty"`   // 最短持续时间（毫秒）
+	MaxCPS          float64 `json:"maxCps,omitempty"`          // 每秒最大字符数
+	MaxCharsPerLine int     `json:"maxCharsPerLine,omitempty"` // 每行最大字符数
+	MaxLines        int     `json:"maxLines,omitempty"`        // 每条字幕最大行数
+}
+
+// QAFinding 表示一条质量检查发现的问题
+type QAFinding struct {
+	Index    int    `json:"index"`    // 字幕序号
+	Severity string `json:"severity"` // 严重程度: "error", "warning" 或 "info"
+	Code     string `json:"code"`     // 问题代码
+	Message  string `json:"message"`  // 问题描述
+}
+
+// QAReport 表示质量检查报告
+type QAReport struct {
+	Errors   int         `json:"errors"`   // 错误数量
+	Warnings int         `json:"warnings"` // 警告数量
+	Infos    int         `json:"infos"`    // 提示数量
+	Findings []QAFinding `json:"findings"` // 问题列表
+}
+
+// QARequest 表示独立的质量检查请求
+type QARequest struct {
+	Filename              string    `json:"filename" binding:"required"`     // 待检查的字幕文件名
+	Content               string    `json:"content" binding:"required"`      // 待检查的字幕内容
+	ContentEncoding       string    `json:"contentEncoding,omitempty"`       // 内容编码: 留空表示文本，"base64"表示二进制内容
+	SourceFilename        string    `json:"sourceFilename,omitempty"`        // 原文字幕文件名，用于译文检查
+	SourceContent         string    `json:"sourceContent,omitempty"`         // 原文字幕内容
+	SourceContentEncoding string    `json:"sourceContentEncoding,omitempty"` // 原文内容编码
+	TargetLanguage        string    `json:"targetLanguage,omitempty"`        // 译文语言
+	Options               QAOptions `json:"options"`                         // 检查选项
+}
+
+// QAResponse 表示质量检查响应
+type QAResponse struct {
+	Success     bool         `json:"success"`               // 是否成功
+	Data        *QAReport    `json:"data,omitempty"`        // 检查报告
+	Error       string       `json:"error,omitempty"`       // 错误信息
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"` // 解析字幕时的诊断信息
+}
+
+// SDHOptions 表示听障字幕注释（[门响]、(笑声)、♪歌词♪、说话人标签）的处理选项
+type SDHOptions struct {
+	Mode            string `json:"mode"`                      // 处理方式: "strip", "keep" 或 "translate"，留空表示随正文翻译
+	ProtectSpeakers bool   `json:"protectSpeakers,omitempty"` // 说话人标签保持原样不翻译
+}
+
+// DetectedLanguage 表示离线识别出的字幕语言，以及翻译提供商识别出的语言
+type DetectedLanguage struct {
+	Language   string  `json:"language"`           // 语言代码，无法识别时为空
+	Script     string  `json:"script"`             // 主要文字系统
+	Confidence float64 `json:"confidence"`         // 置信度 0~1
+	Provider   string  `json:"provider,omitempty"` // 翻译提供商识别出的语言代码，目前只有未指定源语言的Google请求返回
+}
+
+// LanguageInfo 表示一种支持的语言
+type LanguageInfo struct {
+	Code   string `json:"code"`   // 规范语言代码（BCP-47）
+	Name   string `json:"name"`   // 英文名称
+	Native string `json:"native"` // 本地名称
+}
+
+// ProviderInfo 表示一个翻译提供商
+type ProviderInfo struct {
+	Name         string         `json:"name"`         // 提供商标识
+	SupportsAuto bool           `json:"supportsAuto"` // 是否支持自动检测源语言
+	Languages    []LanguageInfo `json:"languages"`    // 支持的语言
+	Limits       ProviderLimits `json:"limits"`       // 服务器配置的请求设置
+	Circuit      string         `json:"circuit"`      // 熔断器状态: closed、open 或 half-open
+}
+
+// ProviderLimits 表示提供商的并发、批量、限流和分段设置
+type ProviderLimits struct {
+	Concurrency       int           `json:"concurrency"`               // 同时发送的请求数
+	BatchSize         int           `json:"batchSize,omitempty"`       // 每个请求包含的字幕条数
+	RequestsPerSecond int           `json:"requestsPerSecond"`         // 每秒最多发送的请求数，-1表示不限制
+	MaxCharacters     int           `json:"maxCharacters"`             // 单条文本超过该字符数时分段翻译
+	ChunkCharacters   int           `json:"chunkCharacters"`           // 超长文本每段的字符数
+	MergeCharacters   int           `json:"mergeCharacters,omitempty"` // 短字幕合并后每个请求的字符数上限
+	Region            string        `json:"region,omitempty"`          // 服务区域
+	Retry             ProviderRetry `json:"retry"`                     // 临时错误的重试和熔断设置
+}
+
+// ProviderRetry 表示提供商的重试和熔断设置
+type ProviderRetry struct {
+	MaxAttempts      int `json:"maxAttempts"`      // 最多尝试次数（包括第一次）
+	InitialBackoffMs int `json:"initialBackoffMs"` // 第一次重试前的等待毫秒数
+	MaxBackoffMs     int `json:"maxBackoffMs"`     // 单次等待的最大毫秒数
+	FailureThreshold int `json:"failureThreshold"` // 连续多少次失败后熔断，-1表示不熔断
+	CooldownSeconds  int `json:"cooldownSeconds"`  // 熔断多少秒后允许试探请求
+}
+
+// ExportRequest 表示导出翻译交换文件（XLIFF/PO/CSV）的请求
+type ExportRequest struct {
+	Filename         string  `json:"filename" binding:"required"` // 字幕文件名
+	Content          string  `json:"content" binding:"required"`  // 字幕内容
+	ContentEncoding  string  `json:"contentEncoding,omitempty"`   // 内容编码: 留空表示文本，"base64"表示二进制内容
+	Format           string  `json:"format" binding:"required"`   // 交换格式: "xliff12", "xliff20", "po" 或 "csv"
+	SourceLanguage   string  `json:"sourceLanguage,omitempty"`    // 源语言，写入交换文件
+	TargetLanguage   string  `json:"targetLanguage,omitempty"`    // 目标语言，预填译文时必填
+	Prefill          bool    `json:"prefill,omitempty"`           // 使用机器翻译预填译文（标记为需要审校）
+	Provider         string  `json:"provider,omitempty"`          // 预填使用的翻译提供商
+	ApiKey           string  `json:"apiKey,omitempty"`            // API密钥
+	ApiSecret        string  `json:"apiSecret,omitempty"`         // API密钥对应的Secret
+	ApiUrl           string  `json:"apiUrl,omitempty"`            // API地址
+	LostCueThreshold float64 `json:"lostCueThreshold,omitempty"`  // 允许丢弃的字幕比例（0~1），默认0.1
+	FPS              float64 `json:"fps,omitempty"`               // MicroDVD等基于帧的格式的帧率，默认读取文件头
+}
+
+// ImportRequest 表示将译员返回的交换文件合并回原字幕的请求
+type ImportRequest struct {
+	Filename            string  `json:"filename" binding:"required"`            // 原字幕文件名，决定输出格式
+	Content             string  `json:"content" binding:"required"`             // 原字幕内容
+	ContentEncoding     string  `json:"contentEncoding,omitempty"`              // 内容编码: 留空表示文本，"base64"表示二进制内容
+	TranslationFilename string  `json:"translationFilename" binding:"required"` // 交换文件名，按扩展名（.xlf/.xliff/.po/.csv）识别格式
+	Translation         string  `json:"translation" binding:"required"`         // 交换文件内容
+	TargetLanguage      string  `json:"targetLanguage,omitempty"`               // 目标语言，用于生成文件名
+	OutputFormat        string  `json:"outputFormat,omitempty"`                 // 输出格式: "translation_only"（默认）或 "original_and_translation"
+	TranslationPosition string  `json:"translationPosition,omitempty"`          // 翻译位置: "below" 或 "above"
+	LostCueThreshold    float64 `json:"lostCueThreshold,omitempty"`             // 允许丢弃的字幕比例（0~1），默认0.1
+	FPS                 float64 `json:"fps,omitempty"`                          // MicroDVD等基于帧的格式的帧率，默认读取文件头
 }
@@ -0,0 +1,40 @@
+package models
+
+// UsageRecord 表示某个客户端某天在某个提供商上的用量
+type UsageRecord struct {
+	Day        string `json:"day"`        // 日期（UTC）
+	Client     string `json:"client"`     // 客户端：API密钥ID或 ip:地址
+	Provider   string `json:"provider"`   // 翻译提供商
+	Requests   int64  `json:"requests"`   // 请求次数
+	Characters int64  `json:"characters"` // 计入配额的源文字符数
+	Sent       int64  `json:"sent"`       // 实际发送给提供商的字符数
+}
+
+// UsageSummary 表示客户端的配额和用量
+type UsageSummary struct {
+	Client                string        `json:"client"`                // 客户端标识
+	RequestsPerMinute     int           `json:"requestsPerMinute"`     // 每分钟请求数限制，0表示不限制
+	DailyCharacters       int64         `json:"dailyCharacters"`       // 今天已使用的字符数
+	DailyCharacterLimit   int64         `json:"dailyCharacterLimit"`   // 每日字符配额，0表示不限制
+	MonthlyCharacters     int64         `json:"monthlyCharacters"`     // 本月已使用的字符数
+	MonthlyCharacterLimit int64         `json:"monthlyCharacterLimit"` // 每月字符配额，0表示不限制
+	Records               []UsageRecord `json:"records"`               // 本月（或指定日期范围内）的每日用量
+}
+
+// CostEstimate 表示翻译一个文件的字符数和费用估算
+type CostEstimate struct {
+	Cues                int                `json:"cues"`                // 字幕条数
+	SourceCharacters    int64              `json:"sourceCharacters"`    // 全部源文字符数（计入配额）
+	DuplicateCharacters int64              `json:"duplicateCharacters"` // 同一文件内重复字幕去重节省的字符数（不涉及翻译缓存或术语表）
+	BlankCues           int                `json:"blankCues"`           // 无需翻译的空白字幕数
+	Providers           []ProviderEstimate `json:"providers"`           // 各提供商的计费字符数和费用
+}
+
+// ProviderEstimate 表示某个提供商的计费估算
+type ProviderEstimate struct {
+	Provider             string   `json:"provider"`                       // 提供商标识
+	BillableCharacters   int64    `json:"billableCharacters"`             // 计费字符数（去重后，含合并发送的分隔符）
+	PerMillionCharacters float64  `json:"perMillionCharacters,omitempty"` // 每百万字符价格
+	Currency             string   `json:"currency,omitempty"`             // 币种
+	Cost                 *float64 `json:"cost,omitempty"`                 // 估算费用，未配置价格时为空
+}
@@ -0,0 +1,191 @@
+package qa
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/frank0/subtitleTranslate/internal/langdetect"
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/reflow"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+)
+
+// 问题严重程度
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// 问题代码
+const (
+	CodeInvalidTiming    = "invalid_timing"    // 时间范围无法解析
+	CodeInvalidDuration  = "invalid_duration"  // 持续时间为零或负数
+	CodeShortDuration    = "short_duration"    // 持续时间过短
+	CodeOverlap          = "overlap"           // 与下一条字幕重叠
+	CodeReadingSpeed     = "reading_speed"     // 每秒字符数过高
+	CodeLineLength       = "line_length"       // 行长超限
+	CodeTooManyLines     = "too_many_lines"    // 行数超限
+	CodeEmptyTranslation = "empty_translation" // 译文为空
+	CodeUntranslated     = "untranslated"      // 译文与原文相同
+	CodeSourceScript     = "source_script"     // 译文仍是原文的文字
+	CodeTagMismatch      = "tag_mismatch"      // 格式标签数量不一致
+)
+
+// Options QA检查参数
+type Options struct {
+	MinDuration     time.Duration // 最短持续时间
+	MaxCPS          float64       // 每秒最大字符数
+	MaxCharsPerLine int           // 每行最大字符数
+	MaxLines        int           // 每条字幕最大行数
+	TargetLanguage  string        // 译文语言，用于判断文字系统
+}
+
+// DefaultOptions 返回指定目标语言的默认参数
+func DefaultOptions(targetLanguage string) Options {
+	limits := reflow.DefaultOptions(targetLanguage)
+	return Options{
+		MinDuration:     833 * time.Millisecond, // 约5/6秒
+		MaxCPS:          limits.MaxCPS,
+		MaxCharsPerLine: limits.MaxCharsPerLine,
+		MaxLines:        limits.MaxLines,
+		TargetLanguage:  targetLanguage,
+	}
+}
+
+// FromRequest 以目标语言默认值为基础，合并请求中的自定义参数
+func FromRequest(req models.QAOptions, targetLanguage string) Options {
+	opts := DefaultOptions(targetLanguage)
+	if req.MinDurationMs > 0 {
+		opts.MinDuration = time.Duration(req.MinDurationMs) * time.Millisecond
+	}
+	if req.MaxCPS > 0 {
+		opts.MaxCPS = req.MaxCPS
+	}
+	if req.MaxCharsPerLine > 0 {
+		opts.MaxCharsPerLine = req.MaxCharsPerLine
+	}
+	if req.MaxLines > 0 {
+		opts.MaxLines = req.MaxLines
+	}
+	return opts
+}
+
+// tagPattern 匹配HTML样式标签和ASS覆盖标签
+var tagPattern = regexp.MustCompile(`<[^>]+>|\{[^}]*\}`)
+
+// Check 检查字幕条目，source为对应的原文条目（可为nil，此时跳过译文相关检查）
+func Check(entries []models.SubtitleEntry, source []models.SubtitleEntry, opts Options) *models.QAReport {
+	report := &models.QAReport{Findings: []models.QAFinding{}}
+	add := func(index int, severity, code, message string) {
+		report.Findings = append(report.Findings, models.QAFinding{
+			Index:    index,
+			Severity: severity,
+			Code:     code,
+			Message:  message,
+		})
+	}
+
+	// 时间相关检查
+	ranges := make([]*utils.TimeRange, len(entries))
+	for i, entry := range entries {
+		tr, err := utils.ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			add(entry.Index, SeverityError, CodeInvalidTiming, fmt.Sprintf("时间范围无法解析: %s", entry.TimeRange))
+			continue
+		}
+		ranges[i] = &tr
+
+		switch d := tr.Duration(); {
+		case d <= 0:
+			add(entry.Index, SeverityError, CodeInvalidDuration, fmt.Sprintf("持续时间为%dms", d.Milliseconds()))
+		case opts.MinDuration > 0 && d < opts.MinDuration:
+			add(entry.Index, SeverityWarning, CodeShortDuration, fmt.Sprintf("持续时间%dms，短于%dms", d.Milliseconds(), opts.MinDuration.Milliseconds()))
+		}
+	}
+	for i := 0; i+1 < len(entries); i++ {
+		if ranges[i] == nil || ranges[i+1] == nil {
+			continue
+		}
+		if ranges[i].End > ranges[i+1].Start {
+			add(entries[i].Index, SeverityError, CodeOverlap, fmt.Sprintf("与第%d条字幕重叠%dms", entries[i+1].Index, (ranges[i].End-ranges[i+1].Start).Milliseconds()))
+		}
+	}
+
+	// 文本相关检查
+	for i, entry := range entries {
+		plain := tagPattern.ReplaceAllString(entry.Content, "")
+		lines := strings.Split(plain, "\n")
+		for n, line := range lines {
+			if width := len([]rune(line)); opts.MaxCharsPerLine > 0 && width > opts.MaxCharsPerLine {
+				add(entry.Index, SeverityWarning, CodeLineLength, fmt.Sprintf("第%d行有%d个字符，超过%d", n+1, width, opts.MaxCharsPerLine))
+			}
+		}
+		if opts.MaxLines > 0 && len(lines) > opts.MaxLines {
+			add(entry.Index, SeverityWarning, CodeTooManyLines, fmt.Sprintf("共%d行，超过%d行", len(lines), opts.MaxLines))
+		}
+		if ranges[i] != nil && ranges[i].Duration() > 0 && opts.MaxCPS > 0 {
+			cps, _ := reflow.CPS(entry.Content, entry.TimeRange)
+			if cps > opts.MaxCPS {
+				add(entry.Index, SeverityWarning, CodeReadingSpeed, fmt.Sprintf("阅读速度%.1f字符/秒，超过%.1f", math.Round(cps*10)/10, opts.MaxCPS))
+			}
+		}
+
+		if source == nil || i >= len(source) {
+			continue
+		}
+		checkTranslation(entry, source[i], opts, add)
+	}
+
+	for _, f := range report.Findings {
+		switch f.Severity {
+		case SeverityError:
+			report.Errors++
+		case SeverityWarning:
+			report.Warnings++
+		default:
+			report.Infos++
+		}
+	}
+	return report
+}
+
+// checkTranslation 对比原文与译文
+func checkTranslation(entry, source models.SubtitleEntry, opts Options, add func(int, string, string, string)) {
+	translated := strings.TrimSpace(tagPattern.ReplaceAllString(entry.Content, ""))
+	original := strings.TrimSpace(tagPattern.ReplaceAllString(source.Content, ""))
+
+	if translated == "" {
+		if original != "" {
+			add(entry.Index, SeverityError, CodeEmptyTranslation, "译文为空")
+		}
+		return
+	}
+
+	if translated == original && hasLetters(original) {
+		add(entry.Index, SeverityWarning, CodeUntranslated, "译文与原文完全相同")
+	} else if expected := langdetect.ExpectedScript(opts.TargetLanguage); expected != "" {
+		sourceScript := langdetect.Script(original)
+		if got := langdetect.Script(translated); got != "" && got == sourceScript && got != expected {
+			add(entry.Index, SeverityWarning, CodeSourceScript, fmt.Sprintf("译文仍为原文的%s文字", got))
+		}
+	}
+
+	if a, b := len(tagPattern.FindAllString(source.Content, -1)), len(tagPattern.FindAllString(entry.Content, -1)); a != b {
+		add(entry.Index, SeverityWarning, CodeTagMismatch, fmt.Sprintf("原文有%d个格式标签，译文有%d个", a, b))
+	}
+}
+
+// hasLetters 判断文本是否包含字母（纯数字或标点不算未翻译）
+func hasLetters(text string) bool {
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
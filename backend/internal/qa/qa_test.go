@@ -0,0 +1,146 @@
+package qa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func entry(index int, timeRange, content string) models.SubtitleEntry {
+	return models.SubtitleEntry{Index: index, TimeRange: timeRange, Content: content}
+}
+
+// codes 返回每条字幕发现的问题代码
+func codes(report *models.QAReport) map[int][]string {
+	got := map[int][]string{}
+	for _, f := range report.Findings {
+		got[f.Index] = append(got[f.Index], f.Code)
+	}
+	return got
+}
+
+func TestCheck(t *testing.T) {
+	opts := Options{MinDuration: 833 * time.Millisecond, MaxCPS: 17, MaxCharsPerLine: 42, MaxLines: 2, TargetLanguage: "zh"}
+
+	tests := []struct {
+		name    string
+		entries []models.SubtitleEntry
+		source  []models.SubtitleEntry
+		want    map[int][]string
+	}{
+		{
+			name:    "没有问题",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "你好。")},
+			want:    map[int][]string{},
+		},
+		{
+			name:    "时间范围无法解析",
+			entries: []models.SubtitleEntry{entry(1, "bad", "你好。")},
+			want:    map[int][]string{1: {CodeInvalidTiming}},
+		},
+		{
+			name:    "持续时间为零",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:01,000", "你好。")},
+			want:    map[int][]string{1: {CodeInvalidDuration}},
+		},
+		{
+			name:    "持续时间过短",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:01,500", "好")},
+			want:    map[int][]string{1: {CodeShortDuration}},
+		},
+		{
+			name: "与下一条重叠",
+			entries: []models.SubtitleEntry{
+				entry(1, "00:00:01,000 --> 00:00:03,000", "你好。"),
+				entry(2, "00:00:02,500 --> 00:00:04,000", "再见。"),
+			},
+			want: map[int][]string{1: {CodeOverlap}},
+		},
+		{
+			name:    "行长、行数和阅读速度超限",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:02,000", "one\ntwo\nthis line is definitely longer than forty-two characters")},
+			want:    map[int][]string{1: {CodeLineLength, CodeTooManyLines, CodeReadingSpeed}},
+		},
+		{
+			name:    "计算行长时忽略格式标签",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:04,000", "<i>"+"a short line"+"</i>{\\an8}")},
+			want:    map[int][]string{},
+		},
+		{
+			name:    "译文为空",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", " ")},
+			source:  []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "Hello.")},
+			want:    map[int][]string{1: {CodeEmptyTranslation}},
+		},
+		{
+			name:    "译文与原文相同",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "Hello.")},
+			source:  []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "Hello.")},
+			want:    map[int][]string{1: {CodeUntranslated}},
+		},
+		{
+			name:    "纯数字不算未翻译",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "1984")},
+			source:  []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "1984")},
+			want:    map[int][]string{},
+		},
+		{
+			name:    "译文仍是原文的文字",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "Good morning.")},
+			source:  []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "Hello there.")},
+			want:    map[int][]string{1: {CodeSourceScript}},
+		},
+		{
+			name:    "格式标签数量不一致",
+			entries: []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "你好。")},
+			source:  []models.SubtitleEntry{entry(1, "00:00:01,000 --> 00:00:03,000", "<i>Hello.</i>")},
+			want:    map[int][]string{1: {CodeTagMismatch}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Check(tt.entries, tt.source, opts)
+			got := codes(report)
+			if len(got) != len(tt.want) {
+				t.Fatalf("问题 = %v，期望 %v", got, tt.want)
+			}
+			for index, want := range tt.want {
+				if len(got[index]) != len(want) {
+					t.Fatalf("第%d条的问题 = %v，期望 %v", index, got[index], want)
+				}
+				for i := range want {
+					if got[index][i] != want[i] {
+						t.Errorf("第%d条的问题 = %v，期望 %v", index, got[index], want)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCountsSeverities(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		entry(1, "00:00:01,000 --> 00:00:03,000", "你好。"),
+		entry(2, "00:00:02,000 --> 00:00:02,500", "好"),
+	}
+	report := Check(entries, nil, DefaultOptions("zh"))
+	if report.Errors != 1 || report.Warnings != 1 || report.Infos != 0 {
+		t.Errorf("错误/警告/提示 = %d/%d/%d，期望 1/1/0", report.Errors, report.Warnings, report.Infos)
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	defaults := FromRequest(models.QAOptions{}, "zh")
+	if defaults != DefaultOptions("zh") {
+		t.Errorf("未设置参数时 = %+v，期望默认值 %+v", defaults, DefaultOptions("zh"))
+	}
+
+	got := FromRequest(models.QAOptions{MinDurationMs: 1000, MaxCPS: 20, MaxCharsPerLine: 30, MaxLines: 3}, "en")
+	want := Options{MinDuration: time.Second, MaxCPS: 20, MaxCharsPerLine: 30, MaxLines: 3, TargetLanguage: "en"}
+	if got != want {
+		t.Errorf("FromRequest = %+v，期望 %+v", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+)
+
+// Limiter 令牌桶速率限制器，桶容量为每秒请求数
+type Limiter struct {
+	perSecond int
+	tokens    chan struct{}
+	ticker    *time.Ticker
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	mu      sync.Mutex
+	waiters int  // 正在 Wait 的调用数
+	retired bool // 已被新的令牌桶取代，最后一个等待者离开后停止
+}
+
+// errStopped 速率限制器已停止
+var errStopped = errors.New("rate limiter stopped")
+
+// New 创建每秒最多perSecond个请求的速率限制器
+func New(perSecond int) *Limiter {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Limiter{
+		perSecond: perSecond,
+		tokens:    make(chan struct{}, perSecond),
+		ticker:    time.NewTicker(time.Second / time.Duration(perSecond)),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	// 初始化令牌
+	for i := 0; i < perSecond; i++ {
+		l.tokens <- struct{}{}
+	}
+
+	// 启动令牌补充协程
+	go l.refill()
+
+	return l
+}
+
+// refill 定期补充令牌
+func (l *Limiter) refill() {
+	for {
+		select {
+		case <-l.ctx.Done():
+			l.ticker.Stop()
+			return
+		case <-l.ticker.C:
+			select {
+			case l.tokens <- struct{}{}:
+				// 成功添加令牌
+			default:
+				// 令牌桶已满，跳过
+			}
+		}
+	}
+}
+
+// Wait 等待获取令牌，ctx取消时立即返回ctx的错误
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.waiters++
+	l.mu.Unlock()
+	defer l.leave()
+
+	select {
+	case <-l.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ctx.Done():
+		return errStopped
+	}
+}
+
+// leave 结束一次等待，已被取代的令牌桶在没有等待者后停止
+func (l *Limiter) leave() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiters--
+	if l.retired && l.waiters == 0 {
+		l.cancel()
+	}
+}
+
+// retire 不再接受新的调用：已在等待的调用继续按原速率获取令牌，全部返回后停止
+func (l *Limiter) retire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.retired = true
+	if l.waiters == 0 {
+		l.cancel()
+	}
+}
+
+// Stop 停止速率限制器，等待中的请求返回错误
+func (l *Limiter) Stop() {
+	l.cancel()
+}
+
+var (
+	mu       sync.RWMutex
+	limiters = map[string]*Limiter{}
+)
+
+// Set 设置提供商每秒最多发送的请求数，0或负数表示不限制
+// 速率不变时保留现有的令牌桶；速率变化时新的调用使用新的令牌桶，
+// 旧令牌桶上正在等待的调用不受影响，不会因为重新加载配置而失败
+func Set(provider string, perSecond int) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	old := limiters[provider]
+	if old != nil && old.perSecond == perSecond {
+		return
+	}
+	if perSecond > 0 {
+		limiters[provider] = New(perSecond)
+	} else {
+		delete(limiters, provider)
+	}
+	if old != nil {
+		old.retire()
+	}
+}
+
+// Wait 等待提供商的速率限制，未设置限制时只检查ctx
+func Wait(ctx context.Context, provider string) error {
+	start := time.Now()
+	for {
+		mu.RLock()
+		l := limiters[provider]
+		mu.RUnlock()
+
+		if l == nil {
+			return ctx.Err()
+		}
+		err := l.Wait(ctx)
+		if errors.Is(err, errStopped) {
+			// 取到令牌桶后它恰好被取代并停止，改用新的令牌桶
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		metrics.RateLimiterWait.Observe(time.Since(start).Seconds(), provider)
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterWaitCanceled(t *testing.T) {
+	l := New(5)
+	defer l.Stop()
+
+	// 取完桶中的令牌，下一次等待要到下一次补充
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("超时后等待了%v", elapsed)
+	}
+}
+
+func TestSet(t *testing.T) {
+	defer Set("test", 0)
+
+	if err := Wait(context.Background(), "test"); err != nil {
+		t.Fatalf("未设置限制时 err = %v", err)
+	}
+
+	Set("test", 2)
+	first := limiters["test"]
+	Set("test", 2)
+	if limiters["test"] != first {
+		t.Error("速率不变时不应替换令牌桶")
+	}
+	for i := 0; i < 2; i++ {
+		if err := Wait(context.Background(), "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := Wait(ctx, "test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("令牌用完后 err = %v, want context.DeadlineExceeded", err)
+	}
+
+	Set("test", 0)
+	if _, ok := limiters["test"]; ok {
+		t.Error("设置为0后应取消限制")
+	}
+}
+
+func TestSetKeepsWaiters(t *testing.T) {
+	defer Set("reload", 0)
+
+	Set("reload", 2)
+	old := limiters["reload"]
+	for i := 0; i < 2; i++ {
+		if err := Wait(context.Background(), "reload"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 令牌用完后开始等待，等待期间重新加载配置改变速率
+	result := make(chan error, 1)
+	go func() { result <- Wait(context.Background(), "reload") }()
+	for waiting := false; !waiting; {
+		old.mu.Lock()
+		waiting = old.waiters == 1
+		old.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	Set("reload", 5)
+
+	// 新的调用使用新的令牌桶，不用等待
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := Wait(ctx, "reload"); err != nil {
+		t.Fatalf("新的令牌桶 err = %v", err)
+	}
+
+	// 等待中的调用按原速率拿到令牌，而不是因为令牌桶停止而失败
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("重新加载时等待中的调用失败: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待中的调用没有返回")
+	}
+	select {
+	case <-old.ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("旧令牌桶的等待者全部返回后应停止")
+	}
+
+	// 没有等待者的令牌桶被取代时立即停止
+	current := limiters["reload"]
+	Set("reload", 3)
+	if current.ctx.Err() == nil {
+		t.Error("没有等待者的旧令牌桶应立即停止")
+	}
+}
@@ -0,0 +1,394 @@
+package reflow
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+)
+
+// Options 换行与阅读速度参数
+type Options struct {
+	MaxCharsPerLine int     // 每行最大字符数
+	MaxLines        int     // 每条字幕最大行数
+	MaxCPS          float64 // 每秒最大字符数，0表示不检查
+	Language        string  // 文本语言，用于选择断行规则
+}
+
+// DefaultOptions 返回指定语言的默认参数
+func DefaultOptions(language string) Options {
+	switch baseLanguage(language) {
+	case "zh":
+		return Options{MaxCharsPerLine: 16, MaxLines: 2, MaxCPS: 9, Language: language}
+	case "ja":
+		return Options{MaxCharsPerLine: 13, MaxLines: 2, MaxCPS: 4, Language: language}
+	case "ko":
+		return Options{MaxCharsPerLine: 16, MaxLines: 2, MaxCPS: 12, Language: language}
+	default:
+		return Options{MaxCharsPerLine: 42, MaxLines: 2, MaxCPS: 17, Language: language}
+	}
+}
+
+// FromRequest 以语言默认值为基础，合并请求中的自定义参数
+func FromRequest(req models.ReflowOptions, language string) Options {
+	opts := DefaultOptions(language)
+	if req.MaxCharsPerLine > 0 {
+		opts.MaxCharsPerLine = req.MaxCharsPerLine
+	}
+	if req.MaxLines > 0 {
+		opts.MaxLines = req.MaxLines
+	}
+	if req.MaxCPS > 0 {
+		opts.MaxCPS = req.MaxCPS
+	}
+	return opts
+}
+
+// CJK 行首禁则字符：不能出现在行首的标点
+const noBreakBefore = "，。、；：？！…‥）」』】〕〉》’”・ー～ぁぃぅぇぉっゃゅょゎァィゥェォッャュョヮ々,.;:?!)]}%"
+
+// CJK 行尾禁则字符：不能出现在行尾的标点
+const noBreakAfter = "（「『【〔〈《‘“([{"
+
+// tagPattern 匹配HTML样式标签和ASS覆盖标签，计算长度时忽略
+var tagPattern = regexp.MustCompile(`<[^>]*>|\{\\[^}]*\}`)
+
+// token 不可分割的文本单元
+type token struct {
+	text  string
+	space bool // 与前一个单元之间是否有空格
+	width int
+}
+
+// Wrap 将文本按参数重新断行
+func Wrap(text string, opts Options) string {
+	if opts.MaxCharsPerLine <= 0 {
+		return text
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return text
+	}
+
+	// 对话破折号开头的多行字幕保持分行，只对每一行单独处理
+	lines := strings.Split(text, "\n")
+	if len(lines) > 1 && isDialogue(lines) {
+		for i, line := range lines {
+			lines[i] = strings.Join(wrapParagraph(strings.TrimSpace(line), opts, 0), "\n")
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	return strings.Join(wrapParagraph(joinLines(lines), opts, opts.MaxLines), "\n")
+}
+
+// isDialogue 判断是否为每行以破折号开头的对话字幕
+func isDialogue(lines []string) bool {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "–") && !strings.HasPrefix(line, "—") {
+			return false
+		}
+	}
+	return true
+}
+
+// joinLines 将原有的多行合并为一段，CJK文本不插入空格
+func joinLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if i > 0 && b.Len() > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(b.String())
+			next, _ := utf8.DecodeRuneInString(line)
+			if !(isCJK(prev) && isCJK(next)) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// wrapParagraph 将一段文本分成若干行，maxLines为0表示不限制行数
+func wrapParagraph(text string, opts Options, maxLines int) []string {
+	if textWidth(text) <= opts.MaxCharsPerLine {
+		return []string{text}
+	}
+
+	tokens := tokenize(text)
+	if len(tokens) <= 1 {
+		return []string{text}
+	}
+
+	// 计算在行宽限制内所需的最少行数
+	minLines := len(breakLines(tokens, opts.MaxCharsPerLine, 0))
+	lines := minLines
+	width := opts.MaxCharsPerLine
+	if maxLines > 0 && lines > maxLines {
+		// 无法满足行数限制时，放宽行宽，保证不超过最大行数
+		lines = maxLines
+		width = math.MaxInt32
+	}
+
+	result := breakLines(tokens, width, lines)
+	if result == nil {
+		result = breakLines(tokens, opts.MaxCharsPerLine, 0)
+	}
+	return result
+}
+
+// tokenize 将文本切分为不可分割的单元
+// 空格分隔的语言以单词为单位；CJK文字以单字为单位，并遵守禁则规则
+func tokenize(text string) []token {
+	var tokens []token
+	pendingSpace := false
+
+	for _, word := range splitKeepingSpace(text) {
+		if word == " " {
+			pendingSpace = true
+			continue
+		}
+
+		// 单词内部按CJK字符进一步切分
+		var parts []string
+		var current strings.Builder
+		for _, r := range word {
+			if isCJK(r) {
+				if current.Len() > 0 {
+					parts = append(parts, current.String())
+					current.Reset()
+				}
+				parts = append(parts, string(r))
+				continue
+			}
+			current.WriteRune(r)
+		}
+		if current.Len() > 0 {
+			parts = append(parts, current.String())
+		}
+
+		for i, part := range parts {
+			space := pendingSpace && i == 0
+			pendingSpace = false
+
+			// 行首禁则：标点附着到前一个单元
+			if len(tokens) > 0 && !space && (startsWithAny(part, noBreakBefore) || endsWithAny(tokens[len(tokens)-1].text, noBreakAfter)) {
+				last := &tokens[len(tokens)-1]
+				last.text += part
+				last.width += textWidth(part)
+				continue
+			}
+			tokens = append(tokens, token{text: part, space: space, width: textWidth(part)})
+		}
+	}
+
+	return tokens
+}
+
+// splitKeepingSpace 按空白切分文本，连续空白折叠为一个 " " 元素
+func splitKeepingSpace(text string) []string {
+	var result []string
+	var current strings.Builder
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if current.Len() > 0 {
+				result = append(result, current.String())
+				current.Reset()
+			}
+			if len(result) > 0 && result[len(result)-1] != " " {
+				result = append(result, " ")
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		result = append(result, current.String())
+	}
+	return result
+}
+
+// breakLines 在单元边界断行
+// lines为0时贪心填充，行宽不超过width；否则在恰好lines行内寻找最均衡的断行方式，
+// 同等情况下优先上短下长（金字塔形）
+func breakLines(tokens []token, width int, lines int) []string {
+	if lines == 0 {
+		var result []string
+		start := 0
+		lineWidth := 0
+		for i, t := range tokens {
+			add := t.width
+			if i > start && t.space {
+				add++
+			}
+			if i > start && lineWidth+add > width {
+				result = append(result, joinTokens(tokens[start:i]))
+				start = i
+				lineWidth = t.width
+				continue
+			}
+			lineWidth += add
+		}
+		return append(result, joinTokens(tokens[start:]))
+	}
+
+	n := len(tokens)
+	if lines > n {
+		lines = n
+	}
+
+	// lineWidthOf 计算tokens[i:j]组成一行的宽度
+	lineWidthOf := func(i, j int) int {
+		w := 0
+		for k := i; k < j; k++ {
+			w += tokens[k].width
+			if k > i && tokens[k].space {
+				w++
+			}
+		}
+		return w
+	}
+
+	// cost[l][j]：前j个单元分成l行的最小代价
+	const inf = math.MaxFloat64
+	cost := make([][]float64, lines+1)
+	prev := make([][]int, lines+1)
+	for l := range cost {
+		cost[l] = make([]float64, n+1)
+		prev[l] = make([]int, n+1)
+		for j := range cost[l] {
+			cost[l][j] = inf
+		}
+	}
+	cost[0][0] = 0
+
+	for l := 1; l <= lines; l++ {
+		for j := l; j <= n; j++ {
+			for i := l - 1; i < j; i++ {
+				if cost[l-1][i] == inf {
+					continue
+				}
+				w := lineWidthOf(i, j)
+				if w > width {
+					continue
+				}
+				c := cost[l-1][i] + float64(w*w)
+				// 上一行比本行长时略加惩罚，使结果呈上短下长
+				if l > 1 {
+					if pw := lineWidthOf(prev[l-1][i], i); pw > w {
+						c += float64(pw-w) * 0.5
+					}
+				}
+				if c < cost[l][j] {
+					cost[l][j] = c
+					prev[l][j] = i
+				}
+			}
+		}
+	}
+
+	if cost[lines][n] == inf {
+		return nil
+	}
+
+	result := make([]string, lines)
+	j := n
+	for l := lines; l >= 1; l-- {
+		i := prev[l][j]
+		result[l-1] = joinTokens(tokens[i:j])
+		j = i
+	}
+	return result
+}
+
+// joinTokens 拼接单元，恢复原有空格
+func joinTokens(tokens []token) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && t.space {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// textWidth 计算显示字符数，忽略格式标签
+func textWidth(text string) int {
+	return utf8.RuneCountInString(tagPattern.ReplaceAllString(text, ""))
+}
+
+// isCJK 判断是否为中日文字符（可在任意字符间断行）
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		strings.ContainsRune(noBreakBefore, r) && r > unicode.MaxASCII ||
+		strings.ContainsRune(noBreakAfter, r) && r > unicode.MaxASCII
+}
+
+func startsWithAny(s, chars string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return strings.ContainsRune(chars, r)
+}
+
+func endsWithAny(s, chars string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return strings.ContainsRune(chars, r)
+}
+
+// baseLanguage 返回语言代码的主语言部分
+func baseLanguage(language string) string {
+	language = strings.ToLower(language)
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	return language
+}
+
+// CPS 计算每秒字符数，不计换行和格式标签
+func CPS(text string, timeRange string) (float64, bool) {
+	tr, err := utils.ParseTimeRange(timeRange)
+	if err != nil || tr.Duration() <= 0 {
+		return 0, false
+	}
+	chars := textWidth(strings.ReplaceAll(text, "\n", ""))
+	return float64(chars) / tr.Duration().Seconds(), true
+}
+
+// Check 检查单条字幕的行长、行数和阅读速度，返回发现的问题
+func Check(entry models.SubtitleEntry, opts Options) *models.ReadingSpeedWarning {
+	warning := models.ReadingSpeedWarning{Index: entry.Index}
+	problem := false
+
+	lines := strings.Split(entry.Content, "\n")
+	for _, line := range lines {
+		if w := textWidth(line); opts.MaxCharsPerLine > 0 && w > opts.MaxCharsPerLine {
+			if w > warning.MaxLineLength {
+				warning.MaxLineLength = w
+			}
+			problem = true
+		}
+	}
+	if opts.MaxLines > 0 && len(lines) > opts.MaxLines {
+		warning.Lines = len(lines)
+		problem = true
+	}
+	if cps, ok := CPS(entry.Content, entry.TimeRange); ok && opts.MaxCPS > 0 && cps > opts.MaxCPS {
+		warning.CPS = math.Round(cps*10) / 10
+		problem = true
+	}
+
+	if !problem {
+		return nil
+	}
+	return &warning
+}
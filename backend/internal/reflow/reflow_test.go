@@ -0,0 +1,124 @@
+package reflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts Options
+		want string
+	}{
+		{
+			name: "短文本不变",
+			text: "Hello there.",
+			opts: DefaultOptions("en"),
+			want: "Hello there.",
+		},
+		{
+			name: "英文按单词均衡断行",
+			text: "I never thought I would see you again after all these years in the city",
+			opts: Options{MaxCharsPerLine: 42, MaxLines: 2},
+			want: "I never thought I would see you again\nafter all these years in the city",
+		},
+		{
+			name: "原有换行合并后重新断行",
+			text: "I never thought\nI would see you again after all these years in the city",
+			opts: Options{MaxCharsPerLine: 42, MaxLines: 2},
+			want: "I never thought I would see you again\nafter all these years in the city",
+		},
+		{
+			name: "中文合并时不插入空格，两行均衡",
+			text: "我从来没有想过，\n在这么多年以后还会在这座城市里再见到你。",
+			opts: Options{MaxCharsPerLine: 16, MaxLines: 2},
+			want: "我从来没有想过，在这么多年以\n后还会在这座城市里再见到你。",
+		},
+		{
+			name: "对话字幕每行单独处理",
+			text: "- Are you coming?\n- Yes.",
+			opts: Options{MaxCharsPerLine: 42, MaxLines: 2},
+			want: "- Are you coming?\n- Yes.",
+		},
+		{
+			name: "超出行数时放宽行宽",
+			text: "one two three four five six seven eight nine ten",
+			opts: Options{MaxCharsPerLine: 10, MaxLines: 2},
+			want: "one two three four five\nsix seven eight nine ten",
+		},
+		{
+			name: "计算长度时忽略标签",
+			text: "<i>I never thought I would see you again</i>",
+			opts: Options{MaxCharsPerLine: 37, MaxLines: 2},
+			want: "<i>I never thought I would see you again</i>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Wrap(tt.text, tt.opts); got != tt.want {
+				t.Errorf("Wrap(%q) =\n%q\nwant\n%q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapKeepsCJKPunctuationAttached(t *testing.T) {
+	got := Wrap("「你好」，我是新来的同事，请多关照。", Options{MaxCharsPerLine: 8, MaxLines: 3})
+	for _, line := range strings.Split(got, "\n") {
+		r := []rune(line)
+		if strings.ContainsRune(noBreakBefore, r[0]) {
+			t.Errorf("行首出现禁则标点: %q", got)
+		}
+		if strings.ContainsRune(noBreakAfter, r[len(r)-1]) {
+			t.Errorf("行尾出现禁则标点: %q", got)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	opts := FromRequest(models.ReflowOptions{MaxCPS: 20}, "zh-Hans")
+	if opts.MaxCharsPerLine != 16 || opts.MaxLines != 2 || opts.MaxCPS != 20 {
+		t.Errorf("FromRequest = %+v", opts)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	opts := Options{MaxCharsPerLine: 10, MaxLines: 2, MaxCPS: 10}
+	tests := []struct {
+		name  string
+		entry models.SubtitleEntry
+		want  *models.ReadingSpeedWarning
+	}{
+		{
+			name:  "没有问题",
+			entry: models.SubtitleEntry{Index: 1, TimeRange: "00:00:01,000 --> 00:00:03,000", Content: "short\nlines"},
+		},
+		{
+			name:  "行太长",
+			entry: models.SubtitleEntry{Index: 2, TimeRange: "00:00:01,000 --> 00:00:10,000", Content: "this line is long"},
+			want:  &models.ReadingSpeedWarning{Index: 2, MaxLineLength: 17},
+		},
+		{
+			name:  "行数太多",
+			entry: models.SubtitleEntry{Index: 3, TimeRange: "00:00:01,000 --> 00:00:10,000", Content: "a\nb\nc"},
+			want:  &models.ReadingSpeedWarning{Index: 3, Lines: 3},
+		},
+		{
+			name:  "阅读速度太快",
+			entry: models.SubtitleEntry{Index: 4, TimeRange: "00:00:01,000 --> 00:00:01,500", Content: "abcdefghij"},
+			want:  &models.ReadingSpeedWarning{Index: 4, CPS: 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(tt.entry, opts)
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("Check = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+)
+
+// ErrCircuitOpen 提供商连续失败后熔断，冷却期内不再发送请求
+var ErrCircuitOpen = errors.New("提供商暂时不可用，已熔断，请稍后重试")
+
+// breaker 单个提供商的熔断器
+// 连续失败达到阈值后打开；冷却期结束后放行一个试探请求，成功则关闭，失败则重新打开
+type breaker struct {
+	provider  string
+	mu        sync.Mutex
+	failures  int       // 连续可重试失败次数
+	openUntil time.Time // 熔断结束时间，零值表示未熔断
+	probing   bool      // 冷却期结束后是否已有试探请求在进行
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*breaker{}
+)
+
+// breakerFor 返回提供商的熔断器
+func breakerFor(provider string) *breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	b, ok := breakers[provider]
+	if !ok {
+		b = &breaker{provider: provider}
+		breakers[provider] = b
+	}
+	return b
+}
+
+// allow 判断现在是否可以发送请求
+func (b *breaker) allow(policy Policy, now time.Time) bool {
+	if policy.FailureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success 记录一次成功（或与提供商状态无关的失败），关闭熔断器
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probing = false
+}
+
+// failure 记录一次可重试的失败，连续失败达到阈值或试探失败时打开熔断器
+func (b *breaker) failure(policy Policy, now time.Time) {
+	if policy.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.probing || b.failures >= policy.FailureThreshold {
+		if !now.Before(b.openUntil) {
+			metrics.ProviderCircuitOpens.Inc(b.provider)
+		}
+		b.openUntil = now.Add(policy.Cooldown)
+		b.probing = false
+	}
+}
+
+// abort 请求被取消，既不算成功也不算失败，释放试探名额
+func (b *breaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State 返回提供商熔断器的状态：closed、open 或 half-open
+func State(provider string) string {
+	b := breakerFor(provider)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.openUntil.IsZero():
+		return "closed"
+	case time.Now().Before(b.openUntil):
+		return "open"
+	default:
+		return "half-open"
+	}
+}
@@ -0,0 +1,163 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+)
+
+// Policy 提供商调用的重试和熔断策略
+type Policy struct {
+	MaxAttempts      int           // 最多尝试次数（包括第一次）
+	InitialBackoff   time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	MaxBackoff       time.Duration // 单次等待时间上限
+	FailureThreshold int           // 连续多少次可重试的失败后熔断，0表示不熔断
+	Cooldown         time.Duration // 熔断后多久允许试探请求
+}
+
+// Error 带有分类的提供商错误
+type Error struct {
+	Err        error
+	Retryable  bool          // 是否可以重试，例如超时、5xx、限流
+	RetryAfter time.Duration // 提供商要求的最短等待时间，0表示未指定
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Retryable 把err标记为可重试的错误
+func Retryable(err error) error {
+	return &Error{Err: err, Retryable: true}
+}
+
+// RetryableAfter 把err标记为可重试的错误，并要求至少等待d后再重试
+func RetryableAfter(err error, d time.Duration) error {
+	return &Error{Err: err, Retryable: true, RetryAfter: d}
+}
+
+// Permanent 把err标记为不可重试的错误，例如认证失败、不支持的语言
+func Permanent(err error) error {
+	return &Error{Err: err}
+}
+
+// Classify 判断错误是否可以重试
+// 已标记的错误按标记处理；ctx取消或超时不重试；其余网络错误可以重试，未知错误不重试
+func Classify(err error) (retryable bool, retryAfter time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Retryable, e.RetryAfter
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true, 0
+	}
+	return false, 0
+}
+
+// RetryableStatus 判断HTTP状态码是否表示临时错误：408、429和5xx（501除外）
+func RetryableStatus(status int) bool {
+	switch {
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	case status == http.StatusNotImplemented:
+		return false
+	default:
+		return status >= 500
+	}
+}
+
+// ParseRetryAfter 解析 Retry-After 响应头（秒数或HTTP日期），无法解析时返回0
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// HTTPError 根据HTTP状态码和 Retry-After 头对错误分类
+func HTTPError(err error, status int, header http.Header) error {
+	if !RetryableStatus(status) {
+		return Permanent(err)
+	}
+	return RetryableAfter(err, ParseRetryAfter(header.Get("Retry-After"), time.Now()))
+}
+
+// Backoff 返回第attempt次重试（从1开始）前的等待时间
+// 使用指数退避，并在后一半区间内随机抖动，避免多个请求同时重试
+func (p Policy) Backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// Do 按策略调用fn，可重试的错误在退避后重试，熔断期间直接返回 ErrCircuitOpen
+// ctx取消时立即停止等待并返回ctx的错误
+func Do(ctx context.Context, provider string, policy Policy, fn func(ctx context.Context) error) error {
+	b := breakerFor(provider)
+	attempts := max(policy.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if !b.allow(policy, time.Now()) {
+			return fmt.Errorf("%s: %w", provider, ErrCircuitOpen)
+		}
+
+		err = fn(ctx)
+		if err == nil {
+			b.success()
+			return nil
+		}
+		if ctx.Err() != nil {
+			b.abort()
+			return err
+		}
+		retryable, retryAfter := Classify(err)
+		if !retryable {
+			// 认证失败、参数错误等说明提供商是正常的
+			b.success()
+			return err
+		}
+		b.failure(policy, time.Now())
+		if attempt == attempts {
+			break
+		}
+
+		wait := max(policy.Backoff(attempt), retryAfter)
+		metrics.ProviderRetries.Inc(provider)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if attempts > 1 {
+		return fmt.Errorf("重试%d次后仍然失败: %w", attempts-1, err)
+	}
+	return err
+}
@@ -0,0 +1,183 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testPolicy 等待时间很短的策略，便于测试
+var testPolicy = Policy{
+	MaxAttempts:      3,
+	InitialBackoff:   time.Millisecond,
+	MaxBackoff:       4 * time.Millisecond,
+	FailureThreshold: 5,
+	Cooldown:         50 * time.Millisecond,
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second, 10: time.Second} {
+		for i := 0; i < 20; i++ {
+			if got := p.Backoff(attempt); got < want/2 || got > want {
+				t.Fatalf("Backoff(%d) = %v, want [%v, %v]", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Mon, 01 Jan 2024 00:00:10 GMT": 10 * time.Second,
+		"Sun, 31 Dec 2023 23:59:00 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := ParseRetryAfter(value, now); got != want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"2"}}
+	tests := []struct {
+		status     int
+		retryable  bool
+		retryAfter time.Duration
+	}{
+		{http.StatusTooManyRequests, true, 2 * time.Second},
+		{http.StatusServiceUnavailable, true, 2 * time.Second},
+		{http.StatusRequestTimeout, true, 2 * time.Second},
+		{http.StatusNotImplemented, false, 0},
+		{http.StatusBadRequest, false, 0},
+		{http.StatusForbidden, false, 0},
+	}
+	for _, tt := range tests {
+		retryable, retryAfter := Classify(HTTPError(errors.New("failed"), tt.status, header))
+		if retryable != tt.retryable || retryAfter != tt.retryAfter {
+			t.Errorf("status %d: got (%v, %v), want (%v, %v)", tt.status, retryable, retryAfter, tt.retryable, tt.retryAfter)
+		}
+	}
+}
+
+func TestDoRetriesRetryableErrors(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), "test-retry", testPolicy, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return Retryable(errors.New("timeout"))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDoStopsOnPermanentErrors(t *testing.T) {
+	calls := 0
+	permanent := errors.New("AuthFailure")
+	err := Do(context.Background(), "test-permanent", testPolicy, func(ctx context.Context) error {
+		calls++
+		return Permanent(permanent)
+	})
+	if !errors.Is(err, permanent) {
+		t.Fatalf("err = %v, want %v", err, permanent)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if state := State("test-permanent"); state != "closed" {
+		t.Errorf("不可重试的错误不应熔断，state = %s", state)
+	}
+}
+
+func TestDoHonoursRetryAfter(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	err := Do(context.Background(), "test-retry-after", testPolicy, func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			return RetryableAfter(errors.New("throttled"), 30*time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("重试前只等待了%v", elapsed)
+	}
+}
+
+func TestDoStopsWaitingWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := testPolicy
+	policy.InitialBackoff = time.Minute
+	policy.MaxBackoff = time.Minute
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	err := Do(ctx, "test-cancel", policy, func(ctx context.Context) error {
+		return Retryable(errors.New("timeout"))
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("取消后%v才返回", elapsed)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const provider = "test-breaker"
+	policy := testPolicy
+	policy.MaxAttempts = 1
+	policy.FailureThreshold = 2
+	down := func(ctx context.Context) error { return Retryable(errors.New("503")) }
+
+	// 连续失败达到阈值后熔断，冷却期内不调用提供商
+	for i := 0; i < policy.FailureThreshold; i++ {
+		Do(context.Background(), provider, policy, down)
+	}
+	if state := State(provider); state != "open" {
+		t.Fatalf("state = %s, want open", state)
+	}
+	called := false
+	err := Do(context.Background(), provider, policy, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrCircuitOpen) || called {
+		t.Fatalf("熔断期间 err = %v, called = %v", err, called)
+	}
+
+	// 冷却期后放行一个试探请求，失败则重新熔断
+	time.Sleep(policy.Cooldown)
+	if state := State(provider); state != "half-open" {
+		t.Fatalf("state = %s, want half-open", state)
+	}
+	Do(context.Background(), provider, policy, down)
+	if state := State(provider); state != "open" {
+		t.Fatalf("试探失败后 state = %s, want open", state)
+	}
+
+	// 试探成功后关闭
+	time.Sleep(policy.Cooldown)
+	if err := Do(context.Background(), provider, policy, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if state := State(provider); state != "closed" {
+		t.Fatalf("试探成功后 state = %s, want closed", state)
+	}
+}
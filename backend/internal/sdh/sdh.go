@@ -0,0 +1,341 @@
+package sdh
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// 听障字幕注释的处理方式
+const (
+	ModeNone      = ""          // 不做特殊处理，注释随正文一起翻译
+	ModeStrip     = "strip"     // 删除注释和说话人标签，删除后为空的字幕整条移除
+	ModeKeep      = "keep"      // 保留注释原文，不送去翻译
+	ModeTranslate = "translate" // 注释与正文分开单独翻译
+)
+
+// Options 听障字幕处理选项
+type Options struct {
+	Mode            string
+	ProtectSpeakers bool // 说话人标签（如 "JOHN:"）保持原样不翻译
+}
+
+// FromRequest 从请求参数创建处理选项
+func FromRequest(req models.SDHOptions) (Options, error) {
+	mode := strings.ToLower(req.Mode)
+	switch mode {
+	case ModeNone, ModeStrip, ModeKeep, ModeTranslate:
+	default:
+		return Options{}, fmt.Errorf("不支持的听障注释处理方式: %s", req.Mode)
+	}
+	return Options{Mode: mode, ProtectSpeakers: req.ProtectSpeakers}, nil
+}
+
+var (
+	// annotationPattern 匹配方括号、圆括号注释和音乐符号包围的歌词
+	annotationPattern = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)|［[^］]*］|（[^）]*）|[♪♫][^♪♫]*(?:[♪♫]|$)`)
+	// speakerPattern 匹配行首的大写说话人标签，例如 "JOHN:" 或 "DR. SMITH:"
+	speakerPattern = regexp.MustCompile(`^([A-Z][A-Z0-9 .'&-]*[A-Z0-9.]|[A-Z]):\s*`)
+	// dashPattern 匹配行首的对话破折号
+	dashPattern = regexp.MustCompile(`^[-–—]\s*`)
+)
+
+// piece 行内的一段注释
+type piece struct {
+	text string
+}
+
+// line 拆分后的一行字幕
+type line struct {
+	dash     string  // 行首破折号
+	speaker  string  // 说话人标签（含冒号）
+	prefix   []piece // 正文之前的注释
+	dialogue string  // 正文
+	suffix   []piece // 正文之后的注释（行中间的注释也归入此处）
+}
+
+// cue 拆分后的一条字幕
+type cue struct {
+	lines []line
+}
+
+// Plan 记录字幕拆分结果，用于翻译后重新组装
+type Plan struct {
+	opts  Options
+	cues  []cue
+	units []string // 需要翻译的文本
+}
+
+// Prepare 拆分字幕中的注释和说话人标签
+// 返回的条目在 strip 模式下已删除注释和空字幕，其余模式下保持原样
+func Prepare(entries []models.SubtitleEntry, opts Options) ([]models.SubtitleEntry, *Plan) {
+	plan := &Plan{opts: opts}
+	var kept []models.SubtitleEntry
+
+	for _, entry := range entries {
+		c := parseCue(entry.Content, opts)
+		if opts.Mode == ModeStrip {
+			c = stripCue(c)
+			if len(c.lines) == 0 {
+				continue
+			}
+			entry.Content = c.render(nil)
+		}
+		kept = append(kept, entry)
+		plan.cues = append(plan.cues, c)
+	}
+
+	// strip 模式删除了字幕时重新编号
+	if len(kept) != len(entries) {
+		for i := range kept {
+			kept[i].Index = i + 1
+		}
+	}
+
+	for _, c := range plan.cues {
+		if d := c.dialogue(); d != "" {
+			plan.units = append(plan.units, d)
+		}
+		if opts.Mode == ModeTranslate {
+			for _, l := range c.lines {
+				for _, p := range l.annotations() {
+					if inner := innerText(p.text); inner != "" {
+						plan.units = append(plan.units, inner)
+					}
+				}
+			}
+		}
+	}
+
+	return kept, plan
+}
+
+// Texts 返回需要送去翻译的文本
+func (p *Plan) Texts() []string {
+	return p.units
+}
+
+// Apply 将翻译结果组装回每条字幕，translated与Texts()一一对应
+func (p *Plan) Apply(translated []string) []string {
+	next := 0
+	take := func() string {
+		if next >= len(translated) {
+			return ""
+		}
+		s := translated[next]
+		next++
+		return s
+	}
+
+	result := make([]string, len(p.cues))
+	for i, c := range p.cues {
+		dialogue := ""
+		if c.dialogue() != "" {
+			dialogue = take()
+		}
+		c = c.withDialogue(dialogue)
+
+		var annotations map[*piece]string
+		if p.opts.Mode == ModeTranslate {
+			annotations = make(map[*piece]string)
+			for li := range c.lines {
+				for _, ap := range c.lines[li].annotationRefs() {
+					if innerText(ap.text) != "" {
+						annotations[ap] = wrapLike(ap.text, take())
+					}
+				}
+			}
+		}
+		result[i] = c.render(annotations)
+	}
+	return result
+}
+
+// parseCue 将字幕内容拆分为行、注释和正文
+func parseCue(content string, opts Options) cue {
+	var c cue
+	for _, raw := range strings.Split(content, "\n") {
+		var l line
+		text := strings.TrimSpace(raw)
+
+		if m := dashPattern.FindString(text); m != "" {
+			l.dash = m
+			text = text[len(m):]
+		}
+
+		// 行首注释
+		for opts.Mode != ModeNone {
+			loc := annotationPattern.FindStringIndex(text)
+			if loc == nil || loc[0] != 0 {
+				break
+			}
+			l.prefix = append(l.prefix, piece{text: text[:loc[1]]})
+			text = strings.TrimSpace(text[loc[1]:])
+		}
+
+		if opts.ProtectSpeakers || opts.Mode == ModeStrip {
+			if m := speakerPattern.FindString(text); m != "" {
+				l.speaker = strings.TrimSpace(m)
+				text = text[len(m):]
+			}
+		}
+
+		// 其余注释移到正文之后
+		if opts.Mode != ModeNone {
+			for _, m := range annotationPattern.FindAllString(text, -1) {
+				l.suffix = append(l.suffix, piece{text: m})
+			}
+			text = annotationPattern.ReplaceAllString(text, " ")
+		}
+		l.dialogue = strings.Join(strings.Fields(text), " ")
+
+		c.lines = append(c.lines, l)
+	}
+	return c
+}
+
+// stripCue 删除注释和说话人标签，去掉因此变空的行
+func stripCue(c cue) cue {
+	var lines []line
+	for _, l := range c.lines {
+		if l.dialogue == "" {
+			continue
+		}
+		lines = append(lines, line{dash: l.dash, dialogue: l.dialogue})
+	}
+	return cue{lines: lines}
+}
+
+// dialogue 返回需要翻译的正文，各行以换行分隔
+func (c cue) dialogue() string {
+	var parts []string
+	for _, l := range c.lines {
+		if l.dialogue != "" {
+			parts = append(parts, l.dialogue)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// withDialogue 用译文替换正文
+// 译文行数与原文正文行数一致时逐行替换，否则整段译文放在第一行正文处
+func (c cue) withDialogue(translated string) cue {
+	var indices []int
+	for i, l := range c.lines {
+		if l.dialogue != "" {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return c
+	}
+
+	lines := make([]line, len(c.lines))
+	copy(lines, c.lines)
+	translatedLines := strings.Split(translated, "\n")
+	if len(translatedLines) == len(indices) {
+		for n, i := range indices {
+			lines[i].dialogue = strings.TrimSpace(translatedLines[n])
+		}
+	} else {
+		for n, i := range indices {
+			if n == 0 {
+				lines[i].dialogue = strings.TrimSpace(translated)
+			} else {
+				lines[i].dialogue = ""
+			}
+		}
+	}
+	return cue{lines: lines}
+}
+
+// render 重新组装字幕文本，annotations 为注释的替换文本（可为nil）
+func (c cue) render(annotations map[*piece]string) string {
+	var out []string
+	for i := range c.lines {
+		l := &c.lines[i]
+		var parts []string
+		for j := range l.prefix {
+			parts = append(parts, replacement(&l.prefix[j], annotations))
+		}
+		if l.speaker != "" {
+			parts = append(parts, l.speaker)
+		}
+		if l.dialogue != "" {
+			parts = append(parts, l.dialogue)
+		}
+		for j := range l.suffix {
+			parts = append(parts, replacement(&l.suffix[j], annotations))
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		out = append(out, l.dash+strings.Join(parts, " "))
+	}
+	return strings.Join(out, "\n")
+}
+
+// annotations 返回行内所有注释
+func (l line) annotations() []piece {
+	return append(append([]piece{}, l.prefix...), l.suffix...)
+}
+
+// annotationRefs 返回行内所有注释的指针，顺序与annotations一致
+func (l *line) annotationRefs() []*piece {
+	var refs []*piece
+	for i := range l.prefix {
+		refs = append(refs, &l.prefix[i])
+	}
+	for i := range l.suffix {
+		refs = append(refs, &l.suffix[i])
+	}
+	return refs
+}
+
+// replacement 返回注释的替换文本
+func replacement(p *piece, annotations map[*piece]string) string {
+	if s, ok := annotations[p]; ok {
+		return s
+	}
+	return p.text
+}
+
+// innerText 去掉注释两侧的括号或音乐符号
+func innerText(annotation string) string {
+	runes := []rune(strings.TrimSpace(annotation))
+	if len(runes) == 0 {
+		return ""
+	}
+	if strings.ContainsRune("[(［（♪♫", runes[0]) {
+		runes = runes[1:]
+	}
+	if len(runes) > 0 && strings.ContainsRune("])］）♪♫", runes[len(runes)-1]) {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes))
+}
+
+// wrapLike 用原注释的括号包裹译文
+func wrapLike(original, translated string) string {
+	translated = strings.TrimSpace(translated)
+	if translated == "" {
+		return original
+	}
+	runes := []rune(strings.TrimSpace(original))
+	open, close := "", ""
+	if strings.ContainsRune("[(［（♪♫", runes[0]) {
+		open = string(runes[0])
+	}
+	if len(runes) > 1 && strings.ContainsRune("])］）♪♫", runes[len(runes)-1]) {
+		close = string(runes[len(runes)-1])
+	}
+	if open == "♪" || open == "♫" {
+		open += " "
+		if close != "" {
+			close = " " + close
+		}
+	}
+	return open + translated + close
+}
@@ -0,0 +1,110 @@
+package sdh
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestFromRequest(t *testing.T) {
+	opts, err := FromRequest(models.SDHOptions{Mode: "Strip", ProtectSpeakers: true})
+	if err != nil {
+		t.Fatalf("FromRequest 返回错误: %v", err)
+	}
+	if opts.Mode != ModeStrip || !opts.ProtectSpeakers {
+		t.Errorf("FromRequest = %+v", opts)
+	}
+	if _, err := FromRequest(models.SDHOptions{Mode: "remove"}); err == nil {
+		t.Error("不支持的处理方式应返回错误")
+	}
+}
+
+func TestPrepareStrip(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, Content: "[door slams]"},
+		{Index: 2, Content: "JOHN: Where are you? (whispering)"},
+		{Index: 3, Content: "- [laughs] Hi.\n- MARY: Bye."},
+	}
+	kept, plan := Prepare(entries, Options{Mode: ModeStrip})
+
+	want := []models.SubtitleEntry{
+		{Index: 1, Content: "Where are you?"},
+		{Index: 2, Content: "- Hi.\n- Bye."},
+	}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("删除注释后 = %+v，期望 %+v", kept, want)
+	}
+	if texts := plan.Texts(); !reflect.DeepEqual(texts, []string{"Where are you?", "Hi.\nBye."}) {
+		t.Errorf("Texts = %q", texts)
+	}
+	got := plan.Apply([]string{"你在哪？", "嗨。\n再见。"})
+	if !reflect.DeepEqual(got, []string{"你在哪？", "- 嗨。\n- 再见。"}) {
+		t.Errorf("Apply = %q", got)
+	}
+}
+
+func TestPrepareAndApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		opts       Options
+		texts      []string
+		translated []string
+		want       string
+	}{
+		{
+			name:       "不处理时注释随正文翻译",
+			content:    "[music] Hello there.",
+			opts:       Options{},
+			texts:      []string{"[music] Hello there."},
+			translated: []string{"[音乐] 你好。"},
+			want:       "[音乐] 你好。",
+		},
+		{
+			name:       "保留注释原文",
+			content:    "[music] Hello there.",
+			opts:       Options{Mode: ModeKeep},
+			texts:      []string{"Hello there."},
+			translated: []string{"你好。"},
+			want:       "[music] 你好。",
+		},
+		{
+			name:       "说话人标签不翻译",
+			content:    "JOHN: Hello.",
+			opts:       Options{ProtectSpeakers: true},
+			texts:      []string{"Hello."},
+			translated: []string{"你好。"},
+			want:       "JOHN: 你好。",
+		},
+		{
+			name:       "注释单独翻译并保留括号",
+			content:    "[door slams] Who's there? ♪ la la ♪",
+			opts:       Options{Mode: ModeTranslate},
+			texts:      []string{"Who's there?", "door slams", "la la"},
+			translated: []string{"谁？", "摔门声", "啦啦"},
+			want:       "[摔门声] 谁？ ♪ 啦啦 ♪",
+		},
+		{
+			name:       "译文行数不一致时放在第一行",
+			content:    "Line one\nline two",
+			opts:       Options{Mode: ModeKeep},
+			texts:      []string{"Line one\nline two"},
+			translated: []string{"一行"},
+			want:       "一行",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, plan := Prepare([]models.SubtitleEntry{{Index: 1, Content: tt.content}}, tt.opts)
+			if texts := plan.Texts(); !reflect.DeepEqual(texts, tt.texts) {
+				t.Fatalf("Texts = %q，期望 %q", texts, tt.texts)
+			}
+			got := plan.Apply(tt.translated)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("Apply = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted 替换凭据的文本
+const Redacted = "[REDACTED]"
+
+// minLength 短于该长度的值不登记，避免把常见的短字符串也替换掉
+const minLength = 6
+
+var (
+	mu     sync.RWMutex
+	values = map[string]bool{}
+	sorted []string // 按长度从长到短排列，较长的凭据先替换
+)
+
+// Register 登记需要隐藏的凭据值，日志和返回给客户端的错误信息中出现时会被替换
+// 配置重新加载后旧的值仍然保留，进行中的请求可能还在使用
+func Register(secrets ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	changed := false
+	for _, s := range secrets {
+		if len(s) < minLength || values[s] {
+			continue
+		}
+		values[s] = true
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	sorted = sorted[:0]
+	for s := range values {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+}
+
+// Redact 把s中已登记的凭据和extra中的值替换为 [REDACTED]
+// extra用于请求中携带的、不需要长期登记的凭据
+func Redact(s string, extra ...string) string {
+	for _, e := range extra {
+		if len(e) >= minLength {
+			s = strings.ReplaceAll(s, e, Redacted)
+		}
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, secret := range sorted {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// redactedError 隐藏了凭据的错误，保留原错误供 errors.Is/As 判断
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// RedactError 返回错误信息中隐藏了凭据的错误，err为nil时返回nil
+func RedactError(err error, extra ...string) error {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	if redacted := Redact(msg, extra...); redacted != msg {
+		return &redactedError{err: err, msg: redacted}
+	}
+	return err
+}
+
+// FromEnv 读取名为name的环境变量；设置了 name_FILE 时从该文件读取（Docker/Kubernetes secrets）
+// 两者都设置时报错，文件末尾的换行会被去掉
+func FromEnv(name string) (string, bool, error) {
+	value, set := os.LookupEnv(name)
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return value, set && value != "", nil
+	}
+	if set && value != "" {
+		return "", false, fmt.Errorf("%s和%s_FILE不能同时设置", name, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: 无法读取凭据文件: %w", name, err)
+	}
+	value = strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", false, fmt.Errorf("%s_FILE: 凭据文件%s为空", name, path)
+	}
+	return value, true, nil
+}
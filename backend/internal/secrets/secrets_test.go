@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+
+	s, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("GOOGLE_API_KEY", "AIza-test-key")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "AIza-test-key") || strings.Contains(string(data), "GOOGLE_API_KEY") {
+		t.Fatal("凭据库文件中出现了明文")
+	}
+
+	s, err = Open(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := s.Get("GOOGLE_API_KEY"); !ok || value != "AIza-test-key" {
+		t.Errorf("Get = %q, %v", value, ok)
+	}
+
+	if _, err := Open(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("口令错误时 err = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestStoreChangePassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	s, err := Open(path, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("TENCENT_SECRET_KEY", "tencent-secret")
+	if err := s.ChangePassphrase("new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, "old"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("更换口令后旧口令 err = %v", err)
+	}
+	s, err = Open(path, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := s.Get("TENCENT_SECRET_KEY"); value != "tencent-secret" {
+		t.Errorf("Get = %q", value)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	Register("registered-secret", "short")
+
+	got := Redact("registered-secret and request-secret and short", "request-secret")
+	want := "[REDACTED] and [REDACTED] and short"
+	if got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+
+	base := errors.New("upstream")
+	err := RedactError(errors.Join(base, errors.New("key registered-secret rejected")))
+	if strings.Contains(err.Error(), "registered-secret") {
+		t.Errorf("错误信息中仍有凭据: %v", err)
+	}
+	if !errors.Is(err, base) {
+		t.Error("隐藏凭据后应保留原错误")
+	}
+	if RedactError(nil) != nil {
+		t.Error("RedactError(nil) 应返回nil")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_SECRET", "")
+	t.Setenv("TEST_SECRET_FILE", path)
+	if value, ok, err := FromEnv("TEST_SECRET"); err != nil || !ok || value != "from-file" {
+		t.Errorf("FromEnv = %q, %v, %v", value, ok, err)
+	}
+
+	t.Setenv("TEST_SECRET", "from-env")
+	if _, _, err := FromEnv("TEST_SECRET"); err == nil {
+		t.Error("同时设置 TEST_SECRET 和 TEST_SECRET_FILE 时应报错")
+	}
+
+	t.Setenv("TEST_SECRET_FILE", "")
+	if value, ok, err := FromEnv("TEST_SECRET"); err != nil || !ok || value != "from-env" {
+		t.Errorf("FromEnv = %q, %v, %v", value, ok, err)
+	}
+}
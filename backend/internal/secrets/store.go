@@ -0,0 +1,217 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnv 加密凭据库口令的环境变量，也可以用 SECRETS_PASSPHRASE_FILE 指定口令文件
+const PassphraseEnv = "SECRETS_PASSPHRASE"
+
+// ErrWrongPassphrase 口令错误或文件已损坏
+var ErrWrongPassphrase = errors.New("无法解密凭据库: 口令错误或文件已损坏")
+
+// scrypt参数，解密一次约需100毫秒，只在启动、重新加载和命令行管理时进行
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	keySize = 32 // AES-256
+)
+
+// Store 用口令加密保存在本地文件中的凭据，键为环境变量名，例如 GOOGLE_API_KEY
+// 文件中只有加密后的内容；密钥由口令经scrypt派生，使用AES-256-GCM加密
+type Store struct {
+	path   string
+	key    []byte
+	salt   []byte
+	values map[string]string
+}
+
+// storeFile 加密凭据库文件结构
+type storeFile struct {
+	Version    int    `json:"version"`
+	KDF        kdf    `json:"kdf"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// kdf 密钥派生参数，保存在文件中以便以后调整参数
+type kdf struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// Passphrase 从 SECRETS_PASSPHRASE 或 SECRETS_PASSPHRASE_FILE 读取口令
+func Passphrase() (string, error) {
+	passphrase, ok, err := FromEnv(PassphraseEnv)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("未设置凭据库口令，请设置%s或%s_FILE", PassphraseEnv, PassphraseEnv)
+	}
+	return passphrase, nil
+}
+
+// Open 用口令打开加密凭据库，文件不存在时返回空的凭据库，保存时创建
+func Open(path, passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("凭据库口令不能为空")
+	}
+	s := &Store{path: path, values: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.salt = make([]byte, 16)
+		if _, err := rand.Read(s.salt); err != nil {
+			return nil, fmt.Errorf("生成随机数失败: %w", err)
+		}
+		if s.key, err = deriveKey(passphrase, kdf{Salt: s.salt, N: scryptN, R: scryptR, P: scryptP}); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取凭据库失败: %w", err)
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析凭据库失败: %w", err)
+	}
+	if file.Version != 1 || file.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("不支持的凭据库版本%d（%s）", file.Version, file.KDF.Name)
+	}
+	if s.key, err = deriveKey(passphrase, file.KDF); err != nil {
+		return nil, err
+	}
+	s.salt = file.KDF.Salt
+
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	if err := json.Unmarshal(plaintext, &s.values); err != nil {
+		return nil, fmt.Errorf("解析凭据库失败: %w", err)
+	}
+	return s, nil
+}
+
+// Get 返回名为name的凭据
+func (s *Store) Get(name string) (string, bool) {
+	value, ok := s.values[name]
+	return value, ok
+}
+
+// Set 设置凭据，调用 Save 后写入文件
+func (s *Store) Set(name, value string) {
+	s.values[name] = value
+}
+
+// Delete 删除凭据，返回是否存在
+func (s *Store) Delete(name string) bool {
+	_, ok := s.values[name]
+	delete(s.values, name)
+	return ok
+}
+
+// Names 返回所有凭据名，按字母排序
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChangePassphrase 更换口令，使用新的盐重新派生密钥，调用 Save 后生效
+func (s *Store) ChangePassphrase(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("凭据库口令不能为空")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("生成随机数失败: %w", err)
+	}
+	key, err := deriveKey(passphrase, kdf{Salt: salt, N: scryptN, R: scryptR, P: scryptP})
+	if err != nil {
+		return err
+	}
+	s.key, s.salt = key, salt
+	return nil
+}
+
+// Save 加密并原子地写入凭据库文件，每次保存使用新的随机nonce
+func (s *Store) Save() error {
+	plaintext, err := json.Marshal(s.values)
+	if err != nil {
+		return fmt.Errorf("序列化凭据库失败: %w", err)
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("生成随机数失败: %w", err)
+	}
+	data, err := json.MarshalIndent(storeFile{
+		Version:    1,
+		KDF:        kdf{Name: "scrypt", Salt: s.salt, N: scryptN, R: scryptR, P: scryptP},
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化凭据库失败: %w", err)
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("创建凭据库目录失败: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入凭据库失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("写入凭据库失败: %w", err)
+	}
+	return nil
+}
+
+// deriveKey 用scrypt从口令派生AES密钥
+func deriveKey(passphrase string, params kdf) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), params.Salt, params.N, params.R, params.P, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("派生凭据库密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+// newGCM 创建AES-GCM加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
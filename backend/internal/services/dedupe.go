@@ -0,0 +1,74 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/frank0/subtitleTranslate/config"
+)
+
+// Unique 去掉重复和空白的文本，只把不同的文本送去翻译
+// positions[i] 为 texts[i] 在 unique 中的位置，空白文本为 -1
+func Unique(texts []string) (unique []string, positions []int) {
+	positions = make([]int, len(texts))
+	seen := make(map[string]int, len(texts))
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			positions[i] = -1
+			continue
+		}
+		pos, ok := seen[text]
+		if !ok {
+			pos = len(unique)
+			seen[text] = pos
+			unique = append(unique, text)
+		}
+		positions[i] = pos
+	}
+	return unique, positions
+}
+
+// Expand 将去重后的译文还原到每条文本，空白文本保持原样
+func Expand(texts, translated []string, positions []int) []string {
+	result := make([]string, len(texts))
+	for i, pos := range positions {
+		if pos < 0 || pos >= len(translated) {
+			result[i] = texts[i]
+			continue
+		}
+		result[i] = translated[pos]
+	}
+	return result
+}
+
+// BillableCharacters 估算把文本发送给提供商时计费的字符数
+// 阿里云和腾讯云会把短文本用换行符合并后发送，换行符同样计费
+func BillableCharacters(provider string, texts []string) int64 {
+	var total int64
+	for _, text := range texts {
+		total += int64(utf8.RuneCountInString(text))
+	}
+	limits := config.Current().Provider(provider)
+	if limits.MergeCharacters == 0 {
+		return total
+	}
+
+	// 与 translateMerged 的合并规则一致
+	current := 0
+	for _, text := range texts {
+		n := utf8.RuneCountInString(text)
+		if n > limits.MaxCharacters {
+			continue // 超长文本单独分段发送
+		}
+		if current > 0 && current+n < limits.MergeCharacters {
+			total++
+			current++
+		}
+		if current+n < limits.MergeCharacters {
+			current += n
+		} else {
+			current = n
+		}
+	}
+	return total
+}
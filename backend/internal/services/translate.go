@@ -3,10 +3,14 @@ package services
 import (
 	"context"
 	"fmt"
-	"os"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/frank0/subtitleTranslate/config"
 	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/ratelimit"
+	"github.com/frank0/subtitleTranslate/internal/retry"
 	"github.com/frank0/subtitleTranslate/internal/translator/aliyun"
 	"github.com/frank0/subtitleTranslate/internal/translator/google"
 	"github.com/frank0/subtitleTranslate/internal/translator/tencent"
@@ -15,11 +19,52 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-// 最大并发翻译数
-const maxConcurrentTranslations = 5
+// Configure 按配置设置各提供商的速率限制，并发、批量和分段设置在每次翻译时读取
+func Configure(cfg *config.Config) {
+	for _, name := range config.ProviderNames {
+		ratelimit.Set(name, cfg.Provider(name).RequestsPerSecond)
+	}
+}
+
+// 各提供商的翻译调用，测试中可以替换为假的实现
+var (
+	volcengineTranslate    = volcengine.TranslateTextsWithSettings
+	aliyunTranslate        = aliyun.TranslateTexts
+	aliyunTranslateMerged  = aliyun.TranslateMergedText
+	googleTranslate        = google.TranslateTextsWithSettings
+	tencentTranslate       = tencent.TranslateTexts
+	tencentTranslateMerged = tencent.TranslateMergedText
+)
+
+// withRetry 按提供商配置的重试策略调用翻译函数，临时错误退避后重试，提供商熔断时直接失败
+func withRetry(ctx context.Context, provider string, limits config.ProviderConfig, fn func(context.Context) ([]string, error)) ([]string, error) {
+	policy := retry.Policy{
+		MaxAttempts:      limits.Retry.MaxAttempts,
+		InitialBackoff:   time.Duration(limits.Retry.InitialBackoffMs) * time.Millisecond,
+		MaxBackoff:       time.Duration(limits.Retry.MaxBackoffMs) * time.Millisecond,
+		FailureThreshold: limits.Retry.FailureThreshold,
+		Cooldown:         time.Duration(limits.Retry.CooldownSeconds) * time.Second,
+	}
+	var translated []string
+	err := retry.Do(ctx, provider, policy, func(ctx context.Context) error {
+		var err error
+		translated, err = fn(ctx)
+		return err
+	})
+	return translated, err
+}
+
+// credentials 返回请求携带的密钥对，请求未携带时返回配置中的密钥对
+// 密钥对整体替换，不会把请求中的ID和配置中的密钥混用
+func credentials(settings models.ApiSettings, id, secret string) (string, string) {
+	if settings.ApiKey != "" || settings.ApiSecret != "" {
+		return settings.ApiKey, settings.ApiSecret
+	}
+	return id, secret
+}
 
 // TranslateWithVolcengine 使用火山引擎翻译字幕文本
-func TranslateWithVolcengine(texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
+func TranslateWithVolcengine(ctx context.Context, texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
 	// 如果文本列表为空，直接返回
 	if len(texts) == 0 {
 		return nil, nil
@@ -31,42 +76,37 @@ func TranslateWithVolcengine(texts []string, targetLanguage string, sourceLangua
 		srcLang = sourceLanguage
 	}
 
-	// 设置API密钥和Secret
-	if settings.ApiKey != "" {
-		// 设置环境变量，供翻译器使用
-		os.Setenv("VOLCENGINE_ACCESS_KEY", settings.ApiKey)
-	}
-	if settings.ApiSecret != "" {
-		os.Setenv("VOLCENGINE_SECRET_KEY", settings.ApiSecret)
-	}
+	// 请求未携带密钥时使用配置中的密钥，配置重新加载后下一个请求即生效
+	cfg := config.Current()
+	accessKey, secretKey := credentials(settings, cfg.Volcengine.AccessKey, cfg.Volcengine.SecretKey)
+	limits := cfg.Provider("volce")
 
 	// 创建结果切片
 	result := make([]string, len(texts))
 
-	// 用于存储需要处理的文本索引和内容的映射
-	type textItem struct {
-		index int
-		text  string
-	}
-
 	var itemsToProcess []textItem
 
 	// 预处理：检查每个文本是否需要分割
 	for i, text := range texts {
 		textLength := len([]rune(text))
 
-		if textLength > 5000 {
+		if textLength > limits.MaxCharacters {
 			// 超长文本需要分割处理
 			runes := []rune(text)
 			var combinedResult strings.Builder
 
-			for j := 0; j < len(runes); j += 4000 {
-				end := j + 4000
+			for j := 0; j < len(runes); j += limits.ChunkCharacters {
+				end := j + limits.ChunkCharacters
 				if end > len(runes) {
 					end = len(runes)
 				}
+				if err := ctx.Err(); err != nil {
+					return nil, fmt.Errorf("翻译被取消：%w", err)
+				}
 				subText := string(runes[j:end])
-				translated, err := volcengine.TranslateTextsWithSettings([]string{subText}, targetLanguage, settings.ApiKey, settings.ApiSecret, srcLang)
+				translated, err := withRetry(ctx, "volce", limits, func(ctx context.Context) ([]string, error) {
+					return volcengineTranslate(ctx, []string{subText}, targetLanguage, accessKey, secretKey, srcLang)
+				})
 				if err != nil {
 					return nil, fmt.Errorf("翻译超长文本片段失败：%w", err)
 				}
@@ -85,43 +125,65 @@ func TranslateWithVolcengine(texts []string, targetLanguage string, sourceLangua
 		return result, nil
 	}
 
-	// 按16个一组进行批量处理
-	batchSize := 16
-	var eg errgroup.Group
-	sem := semaphore.NewWeighted(maxConcurrentTranslations)
-	ctx := context.Background()
+	err := translateBatches(ctx, itemsToProcess, limits, result, func(ctx context.Context, batch []string) ([]string, error) {
+		return withRetry(ctx, "volce", limits, func(ctx context.Context) ([]string, error) {
+			return volcengineTranslate(ctx, batch, targetLanguage, accessKey, secretKey, srcLang)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// textItem 需要批量翻译的文本及其在结果中的位置
+type textItem struct {
+	index int
+	text  string
+}
+
+// translateBatches 按配置的批量大小分批翻译，同时最多发送limits.Concurrency个批次
+// 译文按原位置写入result；任一批次失败或ctx取消时，尚未开始的批次不再发送
+func translateBatches(ctx context.Context, items []textItem, limits config.ProviderConfig, result []string, translate func(context.Context, []string) ([]string, error)) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	sem := semaphore.NewWeighted(int64(limits.Concurrency))
 
-	for i := 0; i < len(itemsToProcess); i += batchSize {
-		end := i + batchSize
-		if end > len(itemsToProcess) {
-			end = len(itemsToProcess)
+	for i := 0; i < len(items); i += limits.BatchSize {
+		end := i + limits.BatchSize
+		if end > len(items) {
+			end = len(items)
 		}
 
-		batch := itemsToProcess[i:end]
+		batch := items[i:end]
 
 		eg.Go(func() error {
 			if err := sem.Acquire(ctx, 1); err != nil {
 				return fmt.Errorf("获取信号量失败：%w", err)
 			}
 			defer sem.Release(1)
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("翻译被取消：%w", err)
+			}
 
 			// 提取当前批次的文本
-			var batchTexts []string
-			indices := make([]int, len(batch))
+			batchTexts := make([]string, len(batch))
 			for j, item := range batch {
-				batchTexts = append(batchTexts, item.text)
-				indices[j] = item.index
+				batchTexts[j] = item.text
 			}
 
 			// 批量翻译
-			translated, err := volcengine.TranslateTextsWithSettings(batchTexts, targetLanguage, settings.ApiKey, settings.ApiSecret, srcLang)
+			translated, err := translate(ctx, batchTexts)
 			if err != nil {
 				return fmt.Errorf("批量翻译失败：%w", err)
 			}
+			if len(translated) != len(batch) {
+				return fmt.Errorf("翻译结果数量不匹配: 请求%d条，返回%d条", len(batch), len(translated))
+			}
 
 			// 将结果放回到对应位置
-			for j, translatedText := range translated {
-				result[indices[j]] = translatedText
+			for j, item := range batch {
+				result[item.index] = translated[j]
 			}
 
 			return nil
@@ -129,15 +191,11 @@ func TranslateWithVolcengine(texts []string, targetLanguage string, sourceLangua
 	}
 
 	// 等待所有批次完成
-	if err := eg.Wait(); err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return eg.Wait()
 }
 
 // TranslateWithAliyun 使用阿里云翻译字幕文本
-func TranslateWithAliyun(texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
+func TranslateWithAliyun(ctx context.Context, texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
 	// 如果文本列表为空，直接返回
 	if len(texts) == 0 {
 		return nil, nil
@@ -149,156 +207,68 @@ func TranslateWithAliyun(texts []string, targetLanguage string, sourceLanguage s
 		srcLang = "auto"
 	}
 
-	// 设置API密钥
-	accessKeyId := settings.ApiKey
-	accessKeySecret := settings.ApiSecret
-	regionId := "cn-hangzhou" // 默认区域
-
-	// 创建结果切片
-	result := make([]string, len(texts))
+	// 请求未携带密钥时使用配置中的密钥
+	cfg := config.Current()
+	accessKeyId, accessKeySecret := credentials(settings, cfg.Aliyun.AccessKeyId, cfg.Aliyun.AccessKeySecret)
+	limits := cfg.Provider("aliyun")
+
+	return translateMerged(ctx, texts, limits, mergedProvider{
+		name: "aliyun",
+		translate: func(ctx context.Context, texts []string) ([]string, error) {
+			return withRetry(ctx, "aliyun", limits, func(ctx context.Context) ([]string, error) {
+				return aliyunTranslate(ctx, texts, targetLanguage, srcLang, accessKeyId, accessKeySecret, limits.Region)
+			})
+		},
+		merged: func(ctx context.Context, text string) ([]string, error) {
+			return withRetry(ctx, "aliyun", limits, func(ctx context.Context) ([]string, error) {
+				return aliyunTranslateMerged(ctx, text, targetLanguage, srcLang, accessKeyId, accessKeySecret, limits.Region)
+			})
+		},
+	})
+}
 
-	// 用于存储需要处理的文本索引和内容的映射
-	type textItem struct {
-		index int
-		text  string
+// TranslateWithGoogle 使用Google翻译文本
+func TranslateWithGoogle(ctx context.Context, texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
+	// 如果文本列表为空，直接返回
+	if len(texts) == 0 {
+		return nil, nil
 	}
 
-	var itemsToProcess []textItem
-
-	// 预处理：检查每个文本是否需要分割
-	for i, text := range texts {
-		textLength := len([]rune(text))
-
-		if textLength > 5000 {
-			// 超长文本需要分割处理（阿里云单次请求限制5000字符）
-			runes := []rune(text)
-			var combinedResult strings.Builder
-
-			for j := 0; j < len(runes); j += 4500 {
-				end := j + 4500
-				if end > len(runes) {
-					end = len(runes)
-				}
-				subText := string(runes[j:end])
-				translated, err := aliyun.TranslateTexts([]string{subText}, targetLanguage, srcLang, accessKeyId, accessKeySecret, regionId)
-				if err != nil {
-					return nil, fmt.Errorf("翻译超长文本片段失败：%w", err)
-				}
-				combinedResult.WriteString(translated[0])
-			}
-
-			result[i] = combinedResult.String()
-		} else {
-			// 正常长度的文本加入批量处理队列
-			itemsToProcess = append(itemsToProcess, textItem{index: i, text: text})
-		}
+	srcLang := "auto"
+	if len(sourceLanguage) > 0 {
+		srcLang = sourceLanguage
 	}
-
-	// 如果没有需要批量处理的文本，直接返回结果
-	if len(itemsToProcess) == 0 {
-		return result, nil
+	// 请求未携带密钥时使用配置中的密钥和地址
+	// 服务器的密钥只发送到配置的地址，忽略请求中的apiUrl，避免密钥被发送到调用方指定的主机
+	cfg := config.Current()
+	apiKey, apiURL := settings.ApiKey, settings.ApiUrl
+	if apiKey == "" {
+		apiKey, apiURL = cfg.Google.APIKey, cfg.Google.TranslateURL
 	}
-
-	// 尝试将多个短文本合并为长文本进行批量翻译，以减少API调用次数
-	// 阿里云更适合处理完整句子，所以我们可以将多个短字幕行合并
-	type mergedItem struct {
-		indices    []int
-		mergedText string
+	if apiURL == "" {
+		apiURL = cfg.Google.TranslateURL
 	}
 
-	var mergedItems []mergedItem
-	var currentIndices []int
-	var currentText strings.Builder
-	currentLength := 0
-
-	for _, item := range itemsToProcess {
-		textLength := len([]rune(item.text))
-
-		// 如果合并后的文本不超过4500字符，就合并（留出500字符的安全余量）
-		if currentLength+textLength < 4500 && currentText.Len() > 0 {
-			currentText.WriteString("\n") // 用换行符分隔不同的字幕行
-			currentLength++
-		}
-
-		if currentLength+textLength < 4500 {
-			currentText.WriteString(item.text)
-			currentLength += textLength
-			currentIndices = append(currentIndices, item.index)
-		} else {
-			// 当前合并项已满，保存并开始新的合并
-			if currentText.Len() > 0 {
-				mergedItems = append(mergedItems, mergedItem{
-					indices:    currentIndices,
-					mergedText: currentText.String(),
-				})
-			}
-			// 开始新的合并
-			currentIndices = []int{item.index}
-			currentText.Reset()
-			currentText.WriteString(item.text)
-			currentLength = textLength
-		}
+	items := make([]textItem, len(texts))
+	for i, text := range texts {
+		items[i] = textItem{index: i, text: text}
 	}
-
-	// 处理最后一组
-	if currentText.Len() > 0 {
-		mergedItems = append(mergedItems, mergedItem{
-			indices:    currentIndices,
-			mergedText: currentText.String(),
+	result := make([]string, len(texts))
+	limits := cfg.Provider("google")
+	err := translateBatches(ctx, items, limits, result, func(ctx context.Context, batch []string) ([]string, error) {
+		return withRetry(ctx, "google", limits, func(ctx context.Context) ([]string, error) {
+			return googleTranslate(ctx, batch, targetLanguage, apiKey, apiURL, srcLang)
 		})
-	}
-
-	// 批量翻译合并后的文本
-	for _, mergedItem := range mergedItems {
-		translatedLines, err := aliyun.TranslateMergedText(mergedItem.mergedText, targetLanguage, srcLang, accessKeyId, accessKeySecret, regionId)
-		if err != nil {
-			return nil, fmt.Errorf("批量翻译失败：%w", err)
-		}
-
-		// 确保翻译结果的行数与原始索引数量匹配
-		if len(translatedLines) != len(mergedItem.indices) {
-			// 如果不匹配，尝试重新翻译单个文本
-			for _, index := range mergedItem.indices {
-				originalText := itemsToProcess[index].text
-				singleTranslated, err := aliyun.TranslateTexts([]string{originalText}, targetLanguage, srcLang, accessKeyId, accessKeySecret, regionId)
-				if err != nil {
-					return nil, fmt.Errorf("回退单文本翻译失败：%w", err)
-				}
-				result[index] = singleTranslated[0]
-			}
-		} else {
-			// 正确映射翻译结果
-			for i, index := range mergedItem.indices {
-				result[index] = translatedLines[i]
-			}
-		}
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
-// TranslateWithGoogle 使用Google翻译文本
-func TranslateWithGoogle(texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
-	srcLang := "auto"
-	if len(sourceLanguage) > 0 {
-		srcLang = sourceLanguage
-	}
-	// 设置API密钥
-	if settings.ApiKey != "" {
-		// 设置环境变量，供翻译器使用
-		os.Setenv("GOOGLE_API_KEY", settings.ApiKey)
-	}
-
-	// 设置API URL（如果有）
-	if settings.ApiUrl != "" {
-		os.Setenv("GOOGLE_TRANSLATE_URL", settings.ApiUrl)
-	}
-
-	return google.TranslateTexts(texts, targetLanguage, srcLang)
-}
-
 // TranslateWithTencent 使用腾讯云翻译字幕文本
-func TranslateWithTencent(texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
+func TranslateWithTencent(ctx context.Context, texts []string, targetLanguage string, sourceLanguage string, settings models.ApiSettings) ([]string, error) {
 	// 如果文本列表为空，直接返回
 	if len(texts) == 0 {
 		return nil, nil
@@ -310,38 +280,61 @@ func TranslateWithTencent(texts []string, targetLanguage string, sourceLanguage
 		srcLang = "auto"
 	}
 
-	// 设置API密钥
-	secretId := settings.ApiKey
-	secretKey := settings.ApiSecret
-	region := "ap-beijing" // 默认区域
+	// 请求未携带密钥时使用配置中的密钥
+	cfg := config.Current()
+	secretId, secretKey := credentials(settings, cfg.Tencent.SecretId, cfg.Tencent.SecretKey)
+	limits := cfg.Provider("tencent")
+
+	return translateMerged(ctx, texts, limits, mergedProvider{
+		name: "tencent",
+		translate: func(ctx context.Context, texts []string) ([]string, error) {
+			return withRetry(ctx, "tencent", limits, func(ctx context.Context) ([]string, error) {
+				return tencentTranslate(ctx, texts, targetLanguage, srcLang, secretId, secretKey, limits.Region)
+			})
+		},
+		merged: func(ctx context.Context, text string) ([]string, error) {
+			return withRetry(ctx, "tencent", limits, func(ctx context.Context) ([]string, error) {
+				return tencentTranslateMerged(ctx, text, targetLanguage, srcLang, secretId, secretKey, limits.Region)
+			})
+		},
+	})
+}
+
+// mergedProvider 把短字幕合并后批量翻译的提供商（阿里云、腾讯云）
+// 这些提供商更适合处理完整句子，合并多个短字幕行可以减少API调用次数
+type mergedProvider struct {
+	name      string
+	translate func(ctx context.Context, texts []string) ([]string, error) // 逐条翻译
+	merged    func(ctx context.Context, text string) ([]string, error)    // 翻译用换行符合并的文本，按行返回译文
+}
 
+// translateMerged 使用合并短文本的提供商翻译字幕文本
+// 超过limits.MaxCharacters的文本按limits.ChunkCharacters分段，其余文本合并为不超过limits.MergeCharacters的请求
+func translateMerged(ctx context.Context, texts []string, limits config.ProviderConfig, provider mergedProvider) ([]string, error) {
 	// 创建结果切片
 	result := make([]string, len(texts))
 
-	// 用于存储需要处理的文本索引和内容的映射
-	type textItem struct {
-		index int
-		text  string
-	}
-
 	var itemsToProcess []textItem
 
 	// 预处理：检查每个文本是否需要分割
 	for i, text := range texts {
 		textLength := len([]rune(text))
 
-		if textLength > 6000 {
-			// 超长文本需要分割处理（腾讯云单次请求限制约6000字符）
+		if textLength > limits.MaxCharacters {
+			// 超长文本需要分割处理
 			runes := []rune(text)
 			var combinedResult strings.Builder
 
-			for j := 0; j < len(runes); j += 5000 {
-				end := j + 5000
+			for j := 0; j < len(runes); j += limits.ChunkCharacters {
+				end := j + limits.ChunkCharacters
 				if end > len(runes) {
 					end = len(runes)
 				}
+				if err := ctx.Err(); err != nil {
+					return nil, fmt.Errorf("翻译被取消：%w", err)
+				}
 				subText := string(runes[j:end])
-				translated, err := tencent.TranslateTexts([]string{subText}, targetLanguage, srcLang, secretId, secretKey, region)
+				translated, err := provider.translate(ctx, []string{subText})
 				if err != nil {
 					return nil, fmt.Errorf("翻译超长文本片段失败：%w", err)
 				}
@@ -360,8 +353,7 @@ func TranslateWithTencent(texts []string, targetLanguage string, sourceLanguage
 		return result, nil
 	}
 
-	// 尝试将多个短文本合并为长文本进行批量翻译，以减少API调用次数
-	// 腾讯云更适合处理完整句子，所以我们可以将多个短字幕行合并
+	// 将多个短文本合并为长文本进行批量翻译
 	type mergedItem struct {
 		indices    []int
 		mergedText string
@@ -375,13 +367,13 @@ func TranslateWithTencent(texts []string, targetLanguage string, sourceLanguage
 	for _, item := range itemsToProcess {
 		textLength := len([]rune(item.text))
 
-		// 如果合并后的文本不超过5000字符，就合并
-		if currentLength+textLength < 5500 && currentText.Len() > 0 {
+		// 如果合并后的文本不超过上限，就合并
+		if currentLength+textLength < limits.MergeCharacters && currentText.Len() > 0 {
 			currentText.WriteString("\n") // 用换行符分隔不同的字幕行
 			currentLength++
 		}
 
-		if currentLength+textLength < 5500 {
+		if currentLength+textLength < limits.MergeCharacters {
 			currentText.WriteString(item.text)
 			currentLength += textLength
 			currentIndices = append(currentIndices, item.index)
@@ -409,30 +401,50 @@ func TranslateWithTencent(texts []string, targetLanguage string, sourceLanguage
 		})
 	}
 
-	// 批量翻译合并后的文本
+	// 批量翻译合并后的文本，同时最多发送limits.Concurrency个请求
+	eg, ctx := errgroup.WithContext(ctx)
+	sem := semaphore.NewWeighted(int64(limits.Concurrency))
+
 	for _, mergedItem := range mergedItems {
-		translatedLines, err := tencent.TranslateMergedText(mergedItem.mergedText, targetLanguage, srcLang, secretId, secretKey, region)
-		if err != nil {
-			return nil, fmt.Errorf("批量翻译失败：%w", err)
-		}
+		eg.Go(func() error {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return fmt.Errorf("获取信号量失败：%w", err)
+			}
+			defer sem.Release(1)
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("翻译被取消：%w", err)
+			}
 
-		// 确保翻译结果的行数与原始索引数量匹配
-		if len(translatedLines) != len(mergedItem.indices) {
-			// 如果不匹配，尝试重新翻译单个文本
-			for _, index := range mergedItem.indices {
-				originalText := itemsToProcess[index].text
-				singleTranslated, err := tencent.TranslateTexts([]string{originalText}, targetLanguage, srcLang, secretId, secretKey, region)
-				if err != nil {
-					return nil, fmt.Errorf("回退单文本翻译失败：%w", err)
+			translatedLines, err := provider.merged(ctx, mergedItem.mergedText)
+			if err != nil {
+				return fmt.Errorf("批量翻译失败：%w", err)
+			}
+
+			// 确保翻译结果的行数与原始索引数量匹配
+			if len(translatedLines) != len(mergedItem.indices) {
+				// 如果不匹配，尝试重新翻译单个文本
+				slog.WarnContext(ctx, "merged translation line count mismatch, translating texts one by one",
+					"provider", provider.name, "expected", len(mergedItem.indices), "got", len(translatedLines))
+				for _, index := range mergedItem.indices {
+					singleTranslated, err := provider.translate(ctx, []string{texts[index]})
+					if err != nil {
+						return fmt.Errorf("回退单文本翻译失败：%w", err)
+					}
+					result[index] = singleTranslated[0]
 				}
-				result[index] = singleTranslated[0]
+				return nil
 			}
-		} else {
+
 			// 正确映射翻译结果
 			for i, index := range mergedItem.indices {
 				result[index] = translatedLines[i]
 			}
-		}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/retry"
+)
+
+// slowProvider 模拟响应缓慢的提供商：每次调用都阻塞到delay结束或ctx取消
+type slowProvider struct {
+	delay    time.Duration
+	calls    atomic.Int32
+	inFlight atomic.Int32
+	peak     atomic.Int32 // 同时进行的最大调用数
+}
+
+func (p *slowProvider) wait(ctx context.Context) error {
+	p.calls.Add(1)
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(p.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *slowProvider) translate(ctx context.Context, texts []string) ([]string, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return append([]string(nil), texts...), nil
+}
+
+func (p *slowProvider) translateMerged(ctx context.Context, merged string) ([]string, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return strings.Split(merged, "\n"), nil
+}
+
+// cueTexts 生成n条长度为size的字幕文本
+func cueTexts(n, size int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = strings.Repeat("a", size)
+	}
+	return texts
+}
+
+// assertCanceled 检查翻译在取消后很快返回，并且返回的是ctx的错误
+func assertCanceled(t *testing.T, err error, elapsed time.Duration, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("err = %v, want %v", err, want)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("取消后%v才返回", elapsed)
+	}
+}
+
+func TestVolcengineCancelStopsPendingBatches(t *testing.T) {
+	fake := &slowProvider{delay: 5 * time.Second}
+	orig := volcengineTranslate
+	volcengineTranslate = func(ctx context.Context, texts []string, _, _, _ string, _ ...string) ([]string, error) {
+		return fake.translate(ctx, texts)
+	}
+	defer func() { volcengineTranslate = orig }()
+
+	// 20个批次，同时最多发送maxConcurrentTranslations个
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := TranslateWithVolcengine(ctx, cueTexts(320, 10), "en", "zh", models.ApiSettings{})
+	assertCanceled(t, err, time.Since(start), context.Canceled)
+	if calls := fake.calls.Load(); calls > int32(config.Current().Provider("volce").Concurrency) {
+		t.Errorf("取消后仍发送了批次：共%d次调用", calls)
+	}
+}
+
+func TestAliyunCancelStopsMergedChunks(t *testing.T) {
+	fake := &slowProvider{delay: 5 * time.Second}
+	orig := aliyunTranslateMerged
+	aliyunTranslateMerged = func(ctx context.Context, merged, _, _, _, _, _ string) ([]string, error) {
+		return fake.translateMerged(ctx, merged)
+	}
+	defer func() { aliyunTranslateMerged = orig }()
+
+	// 每条1000字符，合并后约为5段
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := TranslateWithAliyun(ctx, cueTexts(20, 1000), "en", "zh", models.ApiSettings{})
+	assertCanceled(t, err, time.Since(start), context.DeadlineExceeded)
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestTencentCancelStopsLongTextChunks(t *testing.T) {
+	fake := &slowProvider{delay: 5 * time.Second}
+	orig := tencentTranslate
+	tencentTranslate = func(ctx context.Context, texts []string, _, _, _, _, _ string) ([]string, error) {
+		return fake.translate(ctx, texts)
+	}
+	defer func() { tencentTranslate = orig }()
+
+	// 超长文本按5000字符分段逐段发送
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := TranslateWithTencent(ctx, cueTexts(1, 30000), "en", "zh", models.ApiSettings{})
+	assertCanceled(t, err, time.Since(start), context.Canceled)
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestTranslateCompletesWithoutCancel(t *testing.T) {
+	fake := &slowProvider{delay: time.Millisecond}
+	orig := volcengineTranslate
+	volcengineTranslate = func(ctx context.Context, texts []string, _, _, _ string, _ ...string) ([]string, error) {
+		return fake.translate(ctx, texts)
+	}
+	defer func() { volcengineTranslate = orig }()
+
+	texts := cueTexts(40, 10)
+	got, err := TranslateWithVolcengine(context.Background(), texts, "en", "zh", models.ApiSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(texts) {
+		t.Fatalf("len = %d, want %d", len(got), len(texts))
+	}
+	if calls := fake.calls.Load(); calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+// loadConfig 从临时配置文件加载配置，测试结束后恢复默认配置
+func loadConfig(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_PATH", path)
+	if _, err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Unsetenv("CONFIG_PATH")
+		config.Load()
+	})
+}
+
+func TestMergedChunksUseConfiguredConcurrency(t *testing.T) {
+	loadConfig(t, `{"providers": {"aliyun": {"concurrency": 3, "mergeCharacters": 2500}}}`)
+
+	fake := &slowProvider{delay: 20 * time.Millisecond}
+	orig := aliyunTranslateMerged
+	aliyunTranslateMerged = func(ctx context.Context, merged, _, _, _, _, _ string) ([]string, error) {
+		return fake.translateMerged(ctx, merged)
+	}
+	defer func() { aliyunTranslateMerged = orig }()
+
+	// 每段最多2条1000字符的文本，共10段
+	texts := cueTexts(20, 1000)
+	got, err := TranslateWithAliyun(context.Background(), texts, "en", "zh", models.ApiSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(texts) {
+		t.Fatalf("len = %d, want %d", len(got), len(texts))
+	}
+	if calls := fake.calls.Load(); calls != 10 {
+		t.Errorf("calls = %d, want 10", calls)
+	}
+	if peak := fake.peak.Load(); peak != 3 {
+		t.Errorf("最大并发 = %d, want 3", peak)
+	}
+}
+
+func TestTransientErrorsAreRetried(t *testing.T) {
+	loadConfig(t, `{"providers": {"volce": {"retry": {"initialBackoffMs": 1, "maxBackoffMs": 2}}}}`)
+
+	var calls atomic.Int32
+	orig := volcengineTranslate
+	volcengineTranslate = func(ctx context.Context, texts []string, _, _, _ string, _ ...string) ([]string, error) {
+		if calls.Add(1) == 1 {
+			return nil, retry.Retryable(errors.New("503 Service Unavailable"))
+		}
+		return texts, nil
+	}
+	defer func() { volcengineTranslate = orig }()
+
+	got, err := TranslateWithVolcengine(context.Background(), []string{"你好"}, "en", "zh", models.ApiSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || calls.Load() != 2 {
+		t.Errorf("got %v after %d calls, want 1 result after 2 calls", got, calls.Load())
+	}
+}
+
+func TestServerCredentialsFromConfig(t *testing.T) {
+	loadConfig(t, `{"google": {"apiKey": "server-key", "translateURL": "https://translate.example.com"}}`)
+
+	var gotKey, gotURL string
+	orig := googleTranslate
+	googleTranslate = func(ctx context.Context, texts []string, target, apiKey, apiURL string, source ...string) ([]string, error) {
+		gotKey, gotURL = apiKey, apiURL
+		return texts, nil
+	}
+	defer func() { googleTranslate = orig }()
+
+	if _, err := TranslateWithGoogle(context.Background(), []string{"你好"}, "en", "zh", models.ApiSettings{ApiKey: "client-key"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotKey != "client-key" || gotURL != "https://translate.example.com" {
+		t.Errorf("请求携带密钥时 key=%q url=%q", gotKey, gotURL)
+	}
+
+	// 上一个请求的密钥不能被之后未携带密钥的请求使用
+	if _, err := TranslateWithGoogle(context.Background(), []string{"你好"}, "en", "zh", models.ApiSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if gotKey != "server-key" {
+		t.Errorf("未携带密钥时 key=%q, want server-key", gotKey)
+	}
+
+	// 使用服务器的密钥时忽略请求中的地址
+	if _, err := TranslateWithGoogle(context.Background(), []string{"你好"}, "en", "zh", models.ApiSettings{ApiUrl: "https://attacker.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotKey != "server-key" || gotURL != "https://translate.example.com" {
+		t.Errorf("使用服务器密钥时 key=%q url=%q, 密钥只能发送到配置的地址", gotKey, gotURL)
+	}
+
+	// 重新加载配置后立即使用新的密钥
+	loadConfig(t, `{"google": {"apiKey": "rotated-key"}}`)
+	if _, err := TranslateWithGoogle(context.Background(), []string{"你好"}, "en", "zh", models.ApiSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if gotKey != "rotated-key" {
+		t.Errorf("重新加载后 key=%q, want rotated-key", gotKey)
+	}
+}
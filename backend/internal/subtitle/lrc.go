@@ -0,0 +1,278 @@
+package subtitle
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+)
+
+// AttrWordTiming LRC条目附加属性的键：增强格式的逐字时间，
+// 时间戳相对于行开始时间，构建时在文本未改变的情况下写回
+const AttrWordTiming = "wordTiming"
+
+// lrcOpenEnd 最后一行没有结束标记且没有[length:]时使用的显示时长
+const lrcOpenEnd = 2 * time.Second
+
+// LRCParser LRC歌词格式解析器
+// 支持[offset:]、元数据标签、一行多个时间戳和增强格式的<mm:ss.xx>逐字时间，
+// 结束时间根据下一行推断；双语输出时译文写为相同时间戳的另一行
+type LRCParser struct {
+	doc *lrcDocument
+}
+
+func (p *LRCParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	entries, diagnostics, doc, err := parseLRC(string(data))
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	p.doc = doc
+	return entries, diagnostics, nil
+}
+
+func (p *LRCParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(buildLRC(p.doc, entries))
+}
+
+func (p *LRCParser) SupportedExtensions() []string {
+	return []string{".lrc"}
+}
+
+// lrcDocument 构建时需要保留的元数据
+type lrcDocument struct {
+	tags []string // 元数据标签原文（不含[offset:]，偏移在解析时已应用到时间上）
+}
+
+var (
+	lrcTimePattern = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	lrcTagPattern  = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	lrcWordPattern = regexp.MustCompile(`<(\d+):(\d{1,2})(?:[.:](\d{1,3}))?>`)
+)
+
+// lrcLine 解析出的一个时间戳及其文本
+type lrcLine struct {
+	start time.Duration
+	text  string // 空文本表示上一行的结束
+	words string // 增强格式原文，时间戳为绝对时间
+}
+
+// parseLRC 解析LRC内容
+func parseLRC(content string) ([]models.SubtitleEntry, []models.Diagnostic, *lrcDocument, error) {
+	var diagnostics []models.Diagnostic
+	doc := &lrcDocument{}
+	var lines []lrcLine
+	var offset, length time.Duration
+
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\uFEFF")
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		// 行首的一个或多个时间戳
+		var starts []time.Duration
+		rest := line
+		for {
+			m := lrcTimePattern.FindStringSubmatch(rest)
+			if m == nil {
+				break
+			}
+			start, err := lrcTimestamp(m[1], m[2], m[3])
+			if err != nil {
+				diagnostics = append(diagnostics, models.Diagnostic{
+					Line:     i + 1,
+					Severity: models.SeverityError,
+					Message:  err.Error() + "，已跳过",
+					Raw:      raw,
+				})
+				starts = nil
+				rest = ""
+				break
+			}
+			starts = append(starts, start)
+			rest = rest[len(m[0]):]
+		}
+		if len(starts) == 0 {
+			if rest == "" {
+				continue
+			}
+			if m := lrcTagPattern.FindStringSubmatch(line); m != nil {
+				value := strings.TrimSpace(m[2])
+				switch strings.ToLower(m[1]) {
+				case "offset":
+					ms, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+					if err != nil {
+						diagnostics = append(diagnostics, models.Diagnostic{
+							Line:     i + 1,
+							Severity: models.SeverityWarning,
+							Message:  "无效的offset，已忽略",
+							Raw:      raw,
+						})
+						continue
+					}
+					// 正偏移表示歌词提前显示
+					offset = time.Duration(ms) * time.Millisecond
+					continue
+				case "length":
+					if d, err := utils.ParseTimestamp(value); err == nil {
+						length = d
+					}
+				}
+				doc.tags = append(doc.tags, line)
+				continue
+			}
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityError,
+				Message:  "没有时间戳的歌词行，已跳过",
+				Raw:      raw,
+			})
+			continue
+		}
+
+		text, words := lrcText(rest)
+		for _, start := range starts {
+			lines = append(lines, lrcLine{start: start, text: text, words: words})
+		}
+	}
+
+	if offset != 0 {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Severity: models.SeverityInfo,
+			Message:  fmt.Sprintf("已应用offset %dms", offset.Milliseconds()),
+		})
+		for i := range lines {
+			lines[i].start = max(lines[i].start-offset, 0)
+			lines[i].words = shiftLRCWords(lines[i].words, -offset)
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].start < lines[j].start })
+
+	var entries []models.SubtitleEntry
+	for i, l := range lines {
+		if l.text == "" {
+			continue
+		}
+		// 下一个时间不同的行（包括空行结束标记）结束当前行
+		end := l.start + lrcOpenEnd
+		if length > l.start {
+			end = length
+		}
+		for _, next := range lines[i+1:] {
+			if next.start > l.start {
+				end = next.start
+				break
+			}
+		}
+		entry := models.SubtitleEntry{
+			Index:     len(entries) + 1,
+			TimeRange: utils.FormatSRTTimestamp(l.start) + " --> " + utils.FormatSRTTimestamp(end),
+			Content:   l.text,
+		}
+		if l.words != "" {
+			entry.Attributes = map[string]string{AttrWordTiming: shiftLRCWords(l.words, -l.start)}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, diagnostics, doc, nil
+}
+
+// lrcTimestamp 解析 mm:ss.xx 时间戳，小数部分可以为1~3位
+func lrcTimestamp(minutes, seconds, frac string) (time.Duration, error) {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	if s > 59 {
+		return 0, fmt.Errorf("无效的时间戳: %s:%s", minutes, seconds)
+	}
+	ms := 0
+	if frac != "" {
+		for len(frac) < 3 {
+			frac += "0"
+		}
+		ms, _ = strconv.Atoi(frac)
+	}
+	return time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
+
+// formatLRCTimestamp 将时长格式化为 mm:ss.xx
+func formatLRCTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := (d + 5*time.Millisecond) / (10 * time.Millisecond)
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+// lrcText 返回去掉逐字时间戳的文本，以及包含逐字时间戳的原文（没有时为空）
+func lrcText(rest string) (string, string) {
+	rest = strings.TrimSpace(rest)
+	if !lrcWordPattern.MatchString(rest) {
+		return rest, ""
+	}
+	text := strings.Join(strings.Fields(lrcWordPattern.ReplaceAllString(rest, "")), " ")
+	return text, rest
+}
+
+// shiftLRCWords 将逐字时间戳整体平移delta
+func shiftLRCWords(words string, delta time.Duration) string {
+	if words == "" || delta == 0 {
+		return words
+	}
+	return lrcWordPattern.ReplaceAllStringFunc(words, func(ts string) string {
+		m := lrcWordPattern.FindStringSubmatch(ts)
+		d, err := lrcTimestamp(m[1], m[2], m[3])
+		if err != nil {
+			return ts
+		}
+		return "<" + formatLRCTimestamp(max(d+delta, 0)) + ">"
+	})
+}
+
+// buildLRC 构建LRC内容
+// 多行内容（双语模式下的原文和译文）写为相同时间戳的多行，
+// 与下一行不相接时写入空的时间戳行标记结束
+func buildLRC(doc *lrcDocument, entries []models.SubtitleEntry) string {
+	var b strings.Builder
+	if doc != nil {
+		for _, tag := range doc.tags {
+			b.WriteString(tag + "\n")
+		}
+	}
+
+	var ranges []utils.TimeRange
+	var valid []models.SubtitleEntry
+	for _, entry := range entries {
+		if tr, err := utils.ParseTimeRange(entry.TimeRange); err == nil {
+			ranges = append(ranges, tr)
+			valid = append(valid, entry)
+		}
+	}
+	for i, entry := range valid {
+		tr := ranges[i]
+		stamp := "[" + formatLRCTimestamp(tr.Start) + "]"
+		words := entry.Attributes[AttrWordTiming]
+		for _, line := range strings.Split(entry.Content, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			// 文本未改变时写回逐字时间
+			if words != "" {
+				if text, _ := lrcText(words); text == line {
+					line = shiftLRCWords(words, tr.Start)
+				}
+			}
+			b.WriteString(stamp + line + "\n")
+		}
+		if i+1 == len(valid) || ranges[i+1].Start > tr.End {
+			b.WriteString("[" + formatLRCTimestamp(tr.End) + "]\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package subtitle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+const lrcInput = `[ti:Song]
+[ar:Artist]
+[offset:+500]
+[00:01.50]<00:01.50>Hello <00:02.00>world
+[00:03.50][00:10.50]Chorus line
+[00:05.50]
+[00:07.50]Verse two
+no timestamp
+[00:61.00]Bad
+`
+
+func TestLRCParse(t *testing.T) {
+	got, diagnostics, err := (&LRCParser{}).Parse([]byte(lrcInput))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	// offset为正表示提前500ms显示，逐字时间相对于行开始时间
+	want := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:03,000", Content: "Hello world", Attributes: map[string]string{AttrWordTiming: "<00:00.00>Hello <00:00.50>world"}},
+		{Index: 2, TimeRange: "00:00:03,000 --> 00:00:05,000", Content: "Chorus line"},
+		{Index: 3, TimeRange: "00:00:07,000 --> 00:00:10,000", Content: "Verse two"},
+		{Index: 4, TimeRange: "00:00:10,000 --> 00:00:12,000", Content: "Chorus line"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("解析结果 = %+v，期望 %+v", got, want)
+	}
+
+	wantDiagnostics := []diagnosticKey{{8, models.SeverityError}, {9, models.SeverityError}, {0, models.SeverityInfo}}
+	if keys := diagnosticKeys(diagnostics); !reflect.DeepEqual(keys, wantDiagnostics) {
+		t.Errorf("诊断信息 = %+v，期望 %+v", diagnostics, wantDiagnostics)
+	}
+}
+
+func TestLRCRoundTrip(t *testing.T) {
+	p := &LRCParser{}
+	first, _, err := p.Parse([]byte(lrcInput))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	built := string(p.Build(first, "translation_only"))
+
+	// offset已应用到时间上，不再写出
+	want := `[ti:Song]
+[ar:Artist]
+[00:01.00]<00:01.00>Hello <00:01.50>world
+[00:03.00]Chorus line
+[00:05.00]
+[00:07.00]Verse two
+[00:10.00]Chorus line
+[00:12.00]
+`
+	if built != want {
+		t.Errorf("构建结果 =\n%s\n期望\n%s", built, want)
+	}
+
+	second, diagnostics, err := (&LRCParser{}).Parse([]byte(built))
+	if err != nil {
+		t.Fatalf("解析构建结果失败: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("诊断信息 = %+v，期望为空", diagnostics)
+	}
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("往返后 = %+v，期望 %+v", second, first)
+	}
+}
+
+func TestLRCBuildTranslatedDropsWordTiming(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:03,000", Content: "Hello world\n你好世界", Attributes: map[string]string{AttrWordTiming: "<00:00.00>Hello <00:00.50>world"}},
+	}
+	got := string((&LRCParser{}).Build(entries, "original_and_translation"))
+	want := "[00:01.00]<00:01.00>Hello <00:01.50>world\n[00:01.00]你好世界\n[00:03.00]\n"
+	if got != want {
+		t.Errorf("构建结果 = %q，期望 %q", got, want)
+	}
+}
+
+// diagnosticKey 诊断信息的行号和严重程度
+type diagnosticKey struct {
+	line     int
+	severity string
+}
+
+func diagnosticKeys(diagnostics []models.Diagnostic) []diagnosticKey {
+	var keys []diagnosticKey
+	for _, d := range diagnostics {
+		keys = append(keys, diagnosticKey{d.Line, d.Severity})
+	}
+	return keys
+}
@@ -3,6 +3,8 @@ package subtitle
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/frank0/subtitleTranslate/internal/models"
@@ -10,33 +12,95 @@ import (
 )
 
 // Parser 字幕解析器接口
+// Parse 接收原始字节（文本格式为UTF-8，EBU STL等为二进制），
+// 返回解析出的字幕以及被修复或丢弃内容的诊断信息，丢弃的字幕以error级别报告
 type Parser interface {
-	Parse(content string) ([]models.SubtitleEntry, error)
+	Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error)
 	SupportedExtensions() []string
 }
 
+// Builder 字幕构建器接口，由同时支持输出的解析器实现
+type Builder interface {
+	Build(entries []models.SubtitleEntry, outputFormat string) []byte
+}
+
+// Validator 可选接口，由字符集或版面受限的输出格式实现，
+// 检查字幕能否被完整表示，返回的警告附加到响应的诊断信息中
+type Validator interface {
+	Validate(entries []models.SubtitleEntry) []models.Diagnostic
+}
+
 // ParserFactory 解析器工厂
 type ParserFactory struct {
-	parsers map[string]Parser
+	parsers          map[string]Parser
+	builders         map[string]Builder
+	lostCueThreshold float64
+}
+
+// DefaultLostCueThreshold 默认允许丢弃的字幕比例
+const DefaultLostCueThreshold = 0.1
+
+// Options 解析选项
+type Options struct {
+	Strict           bool    // 严格模式：格式有误时返回错误而不是尝试修复
+	LostCueThreshold float64 // 允许丢弃的字幕比例（0~1），超过时解析失败；0使用默认值，1表示不限制
+	FPS              float64 // 基于帧的格式（MicroDVD）使用的帧率，0使用文件头或默认帧率
+	SAMISourceClass  string  // SAMI源语言类，为空时使用文件中声明的第一个语言类
+	SAMITargetClass  string  // SAMI译文语言类，为空时根据TargetLanguage推断
+	TargetLanguage   string  // 译文语言代码
 }
 
 // NewParserFactory 创建新的解析器工厂
 func NewParserFactory() *ParserFactory {
+	return NewParserFactoryWithOptions(Options{})
+}
+
+// NewParserFactoryWithOptions 使用指定的解析选项创建解析器工厂
+func NewParserFactoryWithOptions(opts Options) *ParserFactory {
 	factory := &ParserFactory{
-		parsers: make(map[string]Parser),
+		parsers:          make(map[string]Parser),
+		builders:         make(map[string]Builder),
+		lostCueThreshold: opts.LostCueThreshold,
+	}
+	if factory.lostCueThreshold <= 0 {
+		factory.lostCueThreshold = DefaultLostCueThreshold
 	}
-	
+
 	// 注册支持的解析器
-	factory.Register(".srt", &SRTParser{})
+	factory.Register(".srt", &SRTParser{Strict: opts.Strict})
 	factory.Register(".vtt", &VTTParser{})
-	factory.Register(".ass", &ASSParser{})
-	
+	ass := &ASSParser{}
+	factory.Register(".ass", ass)
+	factory.Register(".ssa", ass)
+	factory.Register(".sbv", &SBVParser{})
+	factory.Register(".sub", &MicroDVDParser{FPS: opts.FPS})
+	factory.Register(".mpl", &MPL2Parser{})
+	factory.Register(".mpl2", &MPL2Parser{})
+	factory.Register(".stl", &STLParser{})
+	factory.Register(".scc", &SCCParser{})
+	factory.Register(".lrc", &LRCParser{})
+	samiTarget := opts.SAMITargetClass
+	if samiTarget == "" && opts.TargetLanguage != "" {
+		samiTarget = SAMIClassForLanguage(opts.TargetLanguage)
+	}
+	sami := &SAMIParser{SourceClass: opts.SAMISourceClass, TargetClass: samiTarget, TargetLang: opts.TargetLanguage}
+	for _, ext := range sami.SupportedExtensions() {
+		factory.Register(ext, sami)
+	}
+	ttml := &TTMLParser{}
+	for _, ext := range ttml.SupportedExtensions() {
+		factory.Register(ext, ttml)
+	}
+
 	return factory
 }
 
-// Register 注册新的解析器
+// Register 注册新的解析器，解析器实现了Builder时同时注册为构建器
 func (f *ParserFactory) Register(extension string, parser Parser) {
 	f.parsers[strings.ToLower(extension)] = parser
+	if builder, ok := parser.(Builder); ok {
+		f.builders[strings.ToLower(extension)] = builder
+	}
 }
 
 // GetParser 根据文件扩展名获取解析器
@@ -49,20 +113,79 @@ func (f *ParserFactory) GetParser(filename string) (Parser, error) {
 	return parser, nil
 }
 
+// Parse 根据文件扩展名解析字幕内容，丢弃的字幕比例超过阈值时返回错误
+// 出错时仍返回已收集的诊断信息
+func (f *ParserFactory) Parse(filename string, data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	parser, err := f.GetParser(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, diagnostics, err := parser.Parse(data)
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	if err := CheckLostCues(entries, diagnostics, f.lostCueThreshold); err != nil {
+		return nil, diagnostics, err
+	}
+	return entries, diagnostics, nil
+}
+
+// CheckLostCues 统计error级别的诊断（即被丢弃的字幕），比例超过threshold时返回错误
+func CheckLostCues(entries []models.SubtitleEntry, diagnostics []models.Diagnostic, threshold float64) error {
+	lost := 0
+	for _, d := range diagnostics {
+		if d.Severity == models.SeverityError {
+			lost++
+		}
+	}
+	if lost == 0 || threshold >= 1 {
+		return nil
+	}
+	total := lost + len(entries)
+	if ratio := float64(lost) / float64(total); ratio > threshold {
+		return fmt.Errorf("%d条字幕中有%d条无法解析（%.0f%%），超过允许的%.0f%%", total, lost, ratio*100, threshold*100)
+	}
+	return nil
+}
+
 // GetSupportedExtensions 获取所有支持的扩展名
 func (f *ParserFactory) GetSupportedExtensions() []string {
 	var extensions []string
-	for _, parser := range f.parsers {
-		extensions = append(extensions, parser.SupportedExtensions()...)
+	for ext := range f.parsers {
+		extensions = append(extensions, ext)
 	}
+	sort.Strings(extensions)
 	return extensions
 }
 
+// 编译期检查各格式同时实现了解析和构建
+var (
+	_ Builder   = (*SRTParser)(nil)
+	_ Builder   = (*VTTParser)(nil)
+	_ Builder   = (*ASSParser)(nil)
+	_ Builder   = (*SBVParser)(nil)
+	_ Builder   = (*MicroDVDParser)(nil)
+	_ Builder   = (*MPL2Parser)(nil)
+	_ Builder   = (*TTMLParser)(nil)
+	_ Builder   = (*STLParser)(nil)
+	_ Builder   = (*SCCParser)(nil)
+	_ Builder   = (*SAMIParser)(nil)
+	_ Builder   = (*LRCParser)(nil)
+	_ Validator = (*SCCParser)(nil)
+	_ Validator = (*STLParser)(nil)
+)
+
 // SRTParser SRT格式解析器
-type SRTParser struct{}
+type SRTParser struct {
+	Strict bool // 严格模式
+}
+
+func (p *SRTParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	return utils.ParseSRTWithOptions(string(data), utils.SRTOptions{Strict: p.Strict})
+}
 
-func (p *SRTParser) Parse(content string) ([]models.SubtitleEntry, error) {
-	return utils.ParseSRT(content)
+func (p *SRTParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(utils.BuildSRT(entries, outputFormat))
 }
 
 func (p *SRTParser) SupportedExtensions() []string {
@@ -72,8 +195,12 @@ func (p *SRTParser) SupportedExtensions() []string {
 // VTTParser VTT格式解析器
 type VTTParser struct{}
 
-func (p *VTTParser) Parse(content string) ([]models.SubtitleEntry, error) {
-	return utils.ParseVTT(content)
+func (p *VTTParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	return utils.ParseVTT(string(data))
+}
+
+func (p *VTTParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(utils.BuildVTT(entries, outputFormat))
 }
 
 func (p *VTTParser) SupportedExtensions() []string {
@@ -83,10 +210,118 @@ func (p *VTTParser) SupportedExtensions() []string {
 // ASSParser ASS格式解析器
 type ASSParser struct{}
 
-func (p *ASSParser) Parse(content string) ([]models.SubtitleEntry, error) {
-	return utils.ParseASS(content)
+func (p *ASSParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	return utils.ParseASS(string(data))
+}
+
+func (p *ASSParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(utils.BuildASS(entries, outputFormat))
 }
 
 func (p *ASSParser) SupportedExtensions() []string {
 	return []string{".ass", ".ssa"}
-}
\ No newline at end of file
+}
+
+// SBVParser YouTube SBV格式解析器
+type SBVParser struct{}
+
+func (p *SBVParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	return utils.ParseSBV(string(data))
+}
+
+func (p *SBVParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(utils.BuildSBV(entries, outputFormat))
+}
+
+func (p *SBVParser) SupportedExtensions() []string {
+	return []string{".sbv"}
+}
+
+// AttrFrameRate MicroDVD条目附加属性的键：解析时实际使用的帧率，
+// 构建时沿用，使没有指定帧率的往返保持一致
+const AttrFrameRate = "frameRate"
+
+// MicroDVDParser MicroDVD格式解析器，时间以帧为单位
+type MicroDVDParser struct {
+	FPS float64 // 帧率，0表示使用文件头或默认帧率
+}
+
+func (p *MicroDVDParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	entries, diagnostics, fps, err := utils.ParseMicroDVD(string(data), p.FPS)
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	rate := strconv.FormatFloat(fps, 'f', -1, 64)
+	for i := range entries {
+		entries[i].Attributes = map[string]string{AttrFrameRate: rate}
+	}
+	return entries, diagnostics, nil
+}
+
+// Build 使用条目记录的解析帧率，没有时使用配置的帧率
+func (p *MicroDVDParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	fps := p.FPS
+	if len(entries) > 0 {
+		if rate, err := strconv.ParseFloat(entries[0].Attributes[AttrFrameRate], 64); err == nil && rate > 0 {
+			fps = rate
+		}
+	}
+	return []byte(utils.BuildMicroDVD(entries, outputFormat, fps))
+}
+
+func (p *MicroDVDParser) SupportedExtensions() []string {
+	return []string{".sub"}
+}
+
+// MPL2Parser MPL2格式解析器，时间以0.1秒为单位
+type MPL2Parser struct{}
+
+func (p *MPL2Parser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	return utils.ParseMPL2(string(data))
+}
+
+func (p *MPL2Parser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(utils.BuildMPL2(entries, outputFormat))
+}
+
+func (p *MPL2Parser) SupportedExtensions() []string {
+	return []string{".mpl", ".mpl2"}
+}
+
+// Build 根据文件扩展名选择构建器生成字幕内容，未注册构建器的格式输出SRT
+func (f *ParserFactory) Build(filename string, entries []models.SubtitleEntry, outputFormat string) []byte {
+	if builder, ok := f.builders[strings.ToLower(filepath.Ext(filename))]; ok {
+		return builder.Build(entries, outputFormat)
+	}
+	return []byte(utils.BuildSRT(entries, outputFormat))
+}
+
+// Validate 检查字幕能否用目标格式完整表示，目标格式没有限制时返回nil
+func (f *ParserFactory) Validate(filename string, entries []models.SubtitleEntry) []models.Diagnostic {
+	if validator, ok := f.builders[strings.ToLower(filepath.Ext(filename))].(Validator); ok {
+		return validator.Validate(entries)
+	}
+	return nil
+}
+
+// ApplyTranslations 按输出格式将译文写入字幕条目
+// outputFormat为"original_and_translation"时原文和译文合并，position为"above"时译文在上
+func ApplyTranslations(entries []models.SubtitleEntry, translated []string, outputFormat, position string) {
+	for i := range entries {
+		switch outputFormat {
+		case "original_and_translation":
+			if position == "above" {
+				entries[i].Content = translated[i] + "\n" + entries[i].Content
+			} else {
+				entries[i].Content = entries[i].Content + "\n" + translated[i]
+			}
+		default: // "translation_only"
+			entries[i].Content = translated[i]
+		}
+	}
+}
+
+// IsBinary 判断文件格式是否为二进制格式，二进制内容在JSON中以base64传输
+func IsBinary(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".stl"
+}
@@ -0,0 +1,104 @@
+package subtitle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestCheckLostCues(t *testing.T) {
+	// 10条字幕中丢弃lost条
+	cues := func(lost int) ([]models.SubtitleEntry, []models.Diagnostic) {
+		entries := make([]models.SubtitleEntry, 10-lost)
+		diagnostics := []models.Diagnostic{{Line: 1, Severity: models.SeverityWarning}}
+		for i := 0; i < lost; i++ {
+			diagnostics = append(diagnostics, models.Diagnostic{Line: i + 2, Severity: models.SeverityError})
+		}
+		return entries, diagnostics
+	}
+
+	tests := []struct {
+		name      string
+		lost      int
+		threshold float64
+		wantErr   bool
+	}{
+		{"没有丢弃", 0, 0.2, false},
+		{"低于阈值", 1, 0.2, false},
+		{"等于阈值", 2, 0.2, false},
+		{"超过阈值", 3, 0.2, true},
+		{"阈值为0时不允许丢弃", 1, 0, true},
+		{"阈值为1时不限制", 10, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, diagnostics := cues(tt.lost)
+			err := CheckLostCues(entries, diagnostics, tt.threshold)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckLostCues(丢弃%d条，阈值%g) = %v，期望出错 %v", tt.lost, tt.threshold, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFactoryParseLostCueThreshold(t *testing.T) {
+	// 4条字幕中1条时间行无效
+	data := []byte("1\n00:00:01,000 --> 00:00:02,000\nA\n\n2\n00:00:xx --> 00:00:04,000\nB\n\n3\n00:00:05,000 --> 00:00:06,000\nC\n\n4\n00:00:07,000 --> 00:00:08,000\nD\n")
+
+	tests := []struct {
+		threshold float64
+		wantErr   bool
+	}{
+		{0.25, false},
+		{0.2, true},
+		{0, true}, // 0使用默认阈值0.1
+	}
+	for _, tt := range tests {
+		entries, diagnostics, err := NewParserFactoryWithOptions(Options{LostCueThreshold: tt.threshold}).Parse("a.srt", data)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("阈值%g: Parse = %v，期望出错 %v", tt.threshold, err, tt.wantErr)
+		}
+		// 失败时仍返回诊断信息，供响应中展示
+		if len(diagnostics) != 1 || diagnostics[0].Line != 6 || !strings.Contains(diagnostics[0].Raw, "00:00:xx") {
+			t.Errorf("阈值%g: 诊断信息 = %+v", tt.threshold, diagnostics)
+		}
+		if tt.wantErr && entries != nil {
+			t.Errorf("阈值%g: 失败时不应返回字幕: %+v", tt.threshold, entries)
+		}
+	}
+}
+
+func TestMicroDVDParserKeepsFrameRate(t *testing.T) {
+	content := []byte("{1}{1}25\n{25}{50}Hello\n")
+	entries, _, err := (&MicroDVDParser{}).Parse(content)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if got := entries[0].Attributes[AttrFrameRate]; got != "25" {
+		t.Fatalf("帧率属性 = %q，期望 25", got)
+	}
+
+	tests := []struct {
+		name   string
+		parser *MicroDVDParser
+		want   string
+	}{
+		// 帧率随字幕条目传递，与解析时使用的解析器实例无关
+		{"另一个解析器实例", &MicroDVDParser{}, "{1}{1}25\n{25}{50}Hello\n"},
+		{"条目帧率优先于配置", &MicroDVDParser{FPS: 30}, "{1}{1}25\n{25}{50}Hello\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.parser.Build(entries, "")); got != tt.want {
+				t.Errorf("Build =\n%q\n期望\n%q", got, tt.want)
+			}
+		})
+	}
+
+	// 条目没有帧率属性时使用配置的帧率
+	entries[0].Attributes = nil
+	if got, want := string((&MicroDVDParser{FPS: 30}).Build(entries, "")), "{1}{1}30\n{30}{60}Hello\n"; got != want {
+		t.Errorf("没有帧率属性时 Build =\n%q\n期望\n%q", got, want)
+	}
+}
@@ -0,0 +1,429 @@
+package subtitle
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+	"golang.org/x/text/encoding/korean"
+)
+
+// AttrOriginal SAMI条目附加属性的键：源语言类的原始文本，
+// 双语输出时据此从合并后的内容中分离出译文，写入单独的语言类
+const AttrOriginal = "original"
+
+// samiOpenEnd 最后一条字幕没有清屏SYNC时使用的显示时长
+const samiOpenEnd = 2 * time.Second
+
+// SAMIParser SAMI（.smi）格式解析器，支持一个文件中按Class区分的多语言字幕
+// 解析时选择一个语言类作为源，构建双语字幕时保留原文件的所有语言类并把译文写为新的语言类
+type SAMIParser struct {
+	SourceClass string // 源语言类，例如KRCC，为空时使用文件中声明的第一个语言类
+	TargetClass string // 译文语言类，为空时沿用源语言类
+	TargetLang  string // 译文语言代码，写入新语言类的样式声明
+
+	doc *samiDocument
+}
+
+func (p *SAMIParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	entries, diagnostics, doc, err := parseSAMI(data, p.SourceClass)
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	p.doc = doc
+	return entries, diagnostics, nil
+}
+
+func (p *SAMIParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(buildSAMI(p.doc, entries, outputFormat, p.TargetClass, p.TargetLang))
+}
+
+func (p *SAMIParser) SupportedExtensions() []string {
+	return []string{".smi", ".sami"}
+}
+
+// SAMIClassForLanguage 返回语言代码对应的常用SAMI语言类名，例如ko -> KRCC
+func SAMIClassForLanguage(lang string) string {
+	primary := strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+	switch primary {
+	case "ko":
+		return "KRCC"
+	case "ja":
+		return "JPCC"
+	case "":
+		return "ENCC"
+	}
+	return strings.ToUpper(primary) + "CC"
+}
+
+// samiParagraph SYNC中的一个<P>，raw为原文，构建时原样写回
+type samiParagraph struct {
+	class string
+	raw   string
+}
+
+// samiSync 一个<SYNC>
+type samiSync struct {
+	start time.Duration
+	paras []samiParagraph
+}
+
+// samiDocument 构建时需要保留的文档结构
+type samiDocument struct {
+	head        string // <BODY>标签及之前的原文，包括<HEAD>和样式声明
+	syncs       []samiSync
+	sourceClass string
+}
+
+var (
+	samiBodyPattern  = regexp.MustCompile(`(?is)<body\b[^>]*>`)
+	samiEndPattern   = regexp.MustCompile(`(?is)</body\s*>`)
+	samiSyncPattern  = regexp.MustCompile(`(?is)<sync\b([^>]*)>`)
+	samiStartPattern = regexp.MustCompile(`(?i)\bstart\s*=\s*["']?(-?\d+)`)
+	samiPPattern     = regexp.MustCompile(`(?is)<p\b([^>]*)>`)
+	samiClassPattern = regexp.MustCompile(`(?i)\bclass\s*=\s*["']?([^"'\s>]+)`)
+	samiStylePattern = regexp.MustCompile(`(?s)\.([A-Za-z0-9_-]+)\s*\{([^}]*)\}`)
+	samiBreak        = regexp.MustCompile(`(?i)<br\s*/?>`)
+	samiTagPattern   = regexp.MustCompile(`(?s)<(/?)([a-zA-Z]+)[^>]*>`)
+	samiCloseTags    = regexp.MustCompile(`(?i)</(p|sync)\s*>`)
+)
+
+// parseSAMI 解析SAMI内容，非UTF-8内容按EUC-KR（CP949）解码
+func parseSAMI(data []byte, sourceClass string) ([]models.SubtitleEntry, []models.Diagnostic, *samiDocument, error) {
+	var diagnostics []models.Diagnostic
+	content := string(data)
+	if !utf8.Valid(data) {
+		decoded, err := korean.EUCKR.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("无法识别SAMI文件编码: %w", err)
+		}
+		content = string(decoded)
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Severity: models.SeverityInfo,
+			Message:  "文件不是UTF-8编码，已按EUC-KR（CP949）解码",
+		})
+	}
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\uFEFF")
+	lineAt := func(offset int) int { return strings.Count(content[:offset], "\n") + 1 }
+
+	doc := &samiDocument{}
+	body := 0
+	if loc := samiBodyPattern.FindStringIndex(content); loc != nil {
+		doc.head, body = content[:loc[1]], loc[1]
+	} else {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     1,
+			Severity: models.SeverityWarning,
+			Message:  "缺少<BODY>标签，已按整个文件解析",
+		})
+	}
+	end := len(content)
+	if loc := samiEndPattern.FindStringIndex(content[body:]); loc != nil {
+		end = body + loc[0]
+	}
+
+	// 样式中声明的语言类，按声明顺序
+	var classes []string
+	for _, m := range samiStylePattern.FindAllStringSubmatch(doc.head, -1) {
+		classes = appendClass(classes, m[1])
+	}
+
+	syncLocs := samiSyncPattern.FindAllStringSubmatchIndex(content[body:end], -1)
+	for i, loc := range syncLocs {
+		tagStart, tagEnd := body+loc[0], body+loc[1]
+		segmentEnd := end
+		if i+1 < len(syncLocs) {
+			segmentEnd = body + syncLocs[i+1][0]
+		}
+		m := samiStartPattern.FindStringSubmatch(content[body+loc[2] : body+loc[3]])
+		if m == nil {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     lineAt(tagStart),
+				Severity: models.SeverityError,
+				Message:  "SYNC缺少Start属性，已跳过",
+				Raw:      content[tagStart:tagEnd],
+			})
+			continue
+		}
+		ms, _ := strconv.Atoi(m[1])
+		sync := samiSync{start: time.Duration(max(ms, 0)) * time.Millisecond}
+
+		segment := content[tagEnd:segmentEnd]
+		pLocs := samiPPattern.FindAllStringSubmatchIndex(segment, -1)
+		if len(pLocs) == 0 || strings.TrimSpace(segment[:pLocs[0][0]]) != "" {
+			// 没有<P>的文本不属于任何语言类
+			text := segment
+			if len(pLocs) > 0 {
+				text = segment[:pLocs[0][0]]
+			}
+			sync.paras = append(sync.paras, samiParagraph{raw: strings.TrimSpace(text)})
+		}
+		for j, pl := range pLocs {
+			rawEnd := len(segment)
+			if j+1 < len(pLocs) {
+				rawEnd = pLocs[j+1][0]
+			}
+			class := ""
+			if cm := samiClassPattern.FindStringSubmatch(segment[pl[2]:pl[3]]); cm != nil {
+				class = cm[1]
+				classes = appendClass(classes, class)
+			}
+			raw := samiCloseTags.ReplaceAllString(segment[pl[1]:rawEnd], "")
+			sync.paras = append(sync.paras, samiParagraph{class: class, raw: strings.TrimSpace(raw)})
+		}
+		if n := len(doc.syncs); n > 0 && sync.start < doc.syncs[n-1].start {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     lineAt(tagStart),
+				Severity: models.SeverityWarning,
+				Message:  "SYNC时间早于上一个SYNC，已按时间排序",
+				Raw:      content[tagStart:tagEnd],
+			})
+		}
+		doc.syncs = append(doc.syncs, sync)
+	}
+	sort.SliceStable(doc.syncs, func(i, j int) bool { return doc.syncs[i].start < doc.syncs[j].start })
+
+	// 选择源语言类
+	switch {
+	case sourceClass != "":
+		found := false
+		for _, class := range classes {
+			if strings.EqualFold(class, sourceClass) {
+				sourceClass, found = class, true
+			}
+		}
+		if !found {
+			return nil, diagnostics, nil, fmt.Errorf("SAMI文件中没有语言类%s，可用的语言类: %s", sourceClass, strings.Join(classes, ", "))
+		}
+	case len(classes) > 0 && !onlyUnclassed(doc.syncs):
+		sourceClass = classes[0]
+	}
+	if len(classes) > 1 {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Severity: models.SeverityInfo,
+			Message:  fmt.Sprintf("文件包含多个语言类（%s），使用%s作为源", strings.Join(classes, ", "), sourceClass),
+		})
+	}
+	doc.sourceClass = sourceClass
+
+	// 同一语言类的下一个SYNC结束上一条字幕，空白文本（&nbsp;）用于清屏
+	type cue struct {
+		start time.Duration
+		text  string
+	}
+	var cues []cue
+	var ends []time.Duration
+	for _, sync := range doc.syncs {
+		for _, para := range sync.paras {
+			if !strings.EqualFold(para.class, sourceClass) {
+				continue
+			}
+			if len(cues) > len(ends) {
+				ends = append(ends, sync.start)
+			}
+			if text := samiText(para.raw); text != "" {
+				cues = append(cues, cue{start: sync.start, text: text})
+			}
+		}
+	}
+	if len(cues) > len(ends) {
+		ends = append(ends, cues[len(cues)-1].start+samiOpenEnd)
+	}
+
+	var entries []models.SubtitleEntry
+	for i, c := range cues {
+		end := ends[i]
+		if end <= c.start {
+			end = c.start + samiOpenEnd
+		}
+		entries = append(entries, models.SubtitleEntry{
+			Index:      len(entries) + 1,
+			TimeRange:  utils.FormatSRTTimestamp(c.start) + " --> " + utils.FormatSRTTimestamp(end),
+			Content:    c.text,
+			Attributes: map[string]string{AttrOriginal: c.text},
+		})
+	}
+	return entries, diagnostics, doc, nil
+}
+
+// appendClass 去重追加语言类名（不区分大小写）
+func appendClass(classes []string, class string) []string {
+	for _, c := range classes {
+		if strings.EqualFold(c, class) {
+			return classes
+		}
+	}
+	return append(classes, class)
+}
+
+// onlyUnclassed 判断是否所有段落都没有Class
+func onlyUnclassed(syncs []samiSync) bool {
+	for _, sync := range syncs {
+		for _, para := range sync.paras {
+			if para.class != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// samiText 将段落HTML转为字幕文本：<br>换行，保留<i><b><u>，去掉其他标签，解码实体
+func samiText(raw string) string {
+	text := samiBreak.ReplaceAllString(raw, "\n")
+	text = samiTagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		m := samiTagPattern.FindStringSubmatch(tag)
+		switch name := strings.ToLower(m[2]); name {
+		case "i", "b", "u":
+			return "<" + m[1] + name + ">"
+		}
+		return ""
+	})
+	// &nbsp;解码为不换行空格，strings.Fields会将其视为空白
+	text = html.UnescapeString(text)
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// samiHTML 将字幕文本转为段落HTML
+func samiHTML(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range samiTagPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		switch strings.ToLower(text[loc[4]:loc[5]]) {
+		case "i", "b", "u":
+			b.WriteString(strings.ToLower(text[loc[0]:loc[1]]))
+		}
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return strings.ReplaceAll(b.String(), "\n", "<br>")
+}
+
+// defaultSAMIHead 转换为SAMI时使用的文件头
+const defaultSAMIHead = `<SAMI>
+<HEAD>
+<TITLE></TITLE>
+<STYLE TYPE="text/css">
+<!--
+P { margin-left:8pt; margin-right:8pt; margin-bottom:2pt; margin-top:2pt; text-align:center; font-size:20pt; font-family:Arial, Sans-serif; font-weight:normal; color:white; }
+-->
+</STYLE>
+</HEAD>
+<BODY>`
+
+// ensureSAMIClass 在样式中声明缺少的语言类
+func ensureSAMIClass(head, class, lang string) string {
+	for _, m := range samiStylePattern.FindAllStringSubmatch(head, -1) {
+		if strings.EqualFold(m[1], class) {
+			return head
+		}
+	}
+	if lang == "" {
+		lang = strings.ToLower(strings.TrimSuffix(class, "CC"))
+	}
+	rule := fmt.Sprintf(".%s { Name:%s; lang:%s; SAMIType:CC; }\n", class, lang, lang)
+	lower := strings.ToLower(head)
+	for _, marker := range []string{"-->", "</style>"} {
+		if i := strings.LastIndex(lower, marker); i >= 0 {
+			return head[:i] + rule + head[i:]
+		}
+	}
+	if i := strings.LastIndex(lower, "</head>"); i >= 0 {
+		return head[:i] + "<STYLE TYPE=\"text/css\">\n<!--\n" + rule + "-->\n</STYLE>\n" + head[i:]
+	}
+	return head
+}
+
+// buildSAMI 构建SAMI内容
+// 双语模式下原文件的所有语言类原样保留，译文写为targetClass语言类；仅译文模式只输出targetClass
+func buildSAMI(doc *samiDocument, entries []models.SubtitleEntry, outputFormat, targetClass, targetLang string) string {
+	head := defaultSAMIHead
+	sourceClass := ""
+	if doc != nil {
+		sourceClass = doc.sourceClass
+		if doc.head != "" {
+			head = doc.head
+		}
+	}
+	bilingual := outputFormat == "original_and_translation" && doc != nil && len(doc.syncs) > 0
+	if targetClass == "" {
+		targetClass = sourceClass
+	}
+	if targetClass == "" {
+		targetClass = SAMIClassForLanguage(targetLang)
+	}
+	if bilingual && strings.EqualFold(targetClass, sourceClass) {
+		// 译文类不能覆盖原文类
+		targetClass += "TR"
+	}
+
+	syncs := make(map[time.Duration][]samiParagraph)
+	if bilingual {
+		for _, sync := range doc.syncs {
+			syncs[sync.start] = append(syncs[sync.start], sync.paras...)
+		}
+	}
+
+	var ranges []utils.TimeRange
+	var texts []string
+	for _, entry := range entries {
+		tr, err := utils.ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			continue
+		}
+		text := entry.Content
+		if original := entry.Attributes[AttrOriginal]; bilingual && original != "" && text != original {
+			// 从合并的双语内容中去掉原文，原文已在源语言类中
+			text = strings.Trim(strings.Replace(text, original, "", 1), "\n")
+		}
+		ranges = append(ranges, tr)
+		texts = append(texts, text)
+	}
+	starts := make(map[time.Duration]bool)
+	for _, tr := range ranges {
+		starts[tr.Start] = true
+	}
+	for i, tr := range ranges {
+		syncs[tr.Start] = append(syncs[tr.Start], samiParagraph{class: targetClass, raw: samiHTML(texts[i])})
+		if !starts[tr.End] {
+			syncs[tr.End] = append(syncs[tr.End], samiParagraph{class: targetClass, raw: "&nbsp;"})
+		}
+	}
+
+	times := make([]time.Duration, 0, len(syncs))
+	for t := range syncs {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	var b strings.Builder
+	b.WriteString(ensureSAMIClass(head, targetClass, targetLang))
+	b.WriteString("\n")
+	for _, t := range times {
+		for _, para := range syncs[t] {
+			b.WriteString(fmt.Sprintf("<SYNC Start=%d>", t.Milliseconds()))
+			if para.class != "" {
+				b.WriteString(fmt.Sprintf("<P Class=%s>", para.class))
+			} else {
+				b.WriteString("<P>")
+			}
+			b.WriteString(para.raw + "\n")
+		}
+	}
+	b.WriteString("</BODY>\n</SAMI>\n")
+	return b.String()
+}
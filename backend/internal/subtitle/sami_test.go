@@ -0,0 +1,136 @@
+package subtitle
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// samiMultiClass 含韩文和英文两个语言类的SAMI文件
+const samiMultiClass = `<SAMI>
+<HEAD>
+<STYLE TYPE="text/css">
+<!--
+P { font-size:20pt; }
+.KRCC { Name:Korean; lang:ko-KR; SAMIType:CC; }
+.ENCC { Name:English; lang:en-US; SAMIType:CC; }
+-->
+</STYLE>
+</HEAD>
+<BODY>
+<SYNC Start=1000><P Class=KRCC>안녕하세요<br>반가워요
+<SYNC Start=1000><P Class=ENCC>Hello<br><i>Nice to meet you</i>
+<SYNC Start=3000><P Class=KRCC>&nbsp;
+<SYNC Start=3000><P Class=ENCC>&nbsp;
+<SYNC Start=4000><P Class=KRCC>잘 가요
+<SYNC Start=4000><P Class=ENCC>Goodbye &amp; <font color="red">good luck</font>
+</BODY>
+</SAMI>
+`
+
+// samiEntry 构造带原文属性的SAMI条目
+func samiEntry(index int, timeRange, content string) models.SubtitleEntry {
+	return models.SubtitleEntry{Index: index, TimeRange: timeRange, Content: content, Attributes: map[string]string{AttrOriginal: content}}
+}
+
+func TestSAMISourceClass(t *testing.T) {
+	tests := []struct {
+		name        string
+		sourceClass string
+		want        []models.SubtitleEntry
+		wantErr     bool
+	}{
+		{
+			name: "默认使用第一个声明的语言类",
+			want: []models.SubtitleEntry{
+				samiEntry(1, "00:00:01,000 --> 00:00:03,000", "안녕하세요\n반가워요"),
+				samiEntry(2, "00:00:04,000 --> 00:00:06,000", "잘 가요"),
+			},
+		},
+		{
+			name:        "指定语言类不区分大小写",
+			sourceClass: "encc",
+			want: []models.SubtitleEntry{
+				samiEntry(1, "00:00:01,000 --> 00:00:03,000", "Hello\n<i>Nice to meet you</i>"),
+				samiEntry(2, "00:00:04,000 --> 00:00:06,000", "Goodbye & good luck"),
+			},
+		},
+		{
+			name:        "语言类不存在",
+			sourceClass: "JPCC",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diagnostics, err := (&SAMIParser{SourceClass: tt.sourceClass}).Parse([]byte(samiMultiClass))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "KRCC, ENCC") {
+					t.Errorf("错误 = %v，期望列出可用的语言类", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("解析结果 = %+v，期望 %+v", got, tt.want)
+			}
+			if len(diagnostics) != 1 || diagnostics[0].Severity != models.SeverityInfo {
+				t.Errorf("诊断信息 = %+v，期望一条多语言类提示", diagnostics)
+			}
+		})
+	}
+}
+
+func TestSAMIRoundTrip(t *testing.T) {
+	p := &SAMIParser{SourceClass: "ENCC"}
+	first, _, err := p.Parse([]byte(samiMultiClass))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	built := string(p.Build(first, "translation_only"))
+	if strings.Contains(built, "Class=KRCC>") {
+		t.Errorf("仅译文模式不应输出其他语言类:\n%s", built)
+	}
+	if !strings.Contains(built, ".KRCC { Name:Korean;") {
+		t.Errorf("应保留原文件的样式声明:\n%s", built)
+	}
+
+	second, _, err := (&SAMIParser{SourceClass: "ENCC"}).Parse([]byte(built))
+	if err != nil {
+		t.Fatalf("解析构建结果失败: %v\n%s", err, built)
+	}
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("往返后 = %+v，期望 %+v", second, first)
+	}
+}
+
+func TestSAMIBilingualKeepsSourceClasses(t *testing.T) {
+	p := &SAMIParser{SourceClass: "ENCC", TargetClass: "ZHCC", TargetLang: "zh"}
+	entries, _, err := p.Parse([]byte(samiMultiClass))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	ApplyTranslations(entries, []string{"你好\n<i>很高兴见到你</i>", "再见，祝你好运"}, "original_and_translation", "below")
+	built := string(p.Build(entries, "original_and_translation"))
+
+	if !strings.Contains(built, ".ZHCC { Name:zh; lang:zh; SAMIType:CC; }") {
+		t.Errorf("缺少译文语言类的样式声明:\n%s", built)
+	}
+	for _, class := range []string{"KRCC", "ENCC", "ZHCC"} {
+		got, _, err := (&SAMIParser{SourceClass: class}).Parse([]byte(built))
+		if err != nil {
+			t.Fatalf("解析%s失败: %v\n%s", class, err, built)
+		}
+		if len(got) != 2 || got[0].TimeRange != "00:00:01,000 --> 00:00:03,000" || got[1].TimeRange != "00:00:04,000 --> 00:00:06,000" {
+			t.Errorf("%s = %+v", class, got)
+		}
+	}
+	zh, _, _ := (&SAMIParser{SourceClass: "ZHCC"}).Parse([]byte(built))
+	if zh[0].Content != "你好\n<i>很高兴见到你</i>" || zh[1].Content != "再见，祝你好运" {
+		t.Errorf("译文语言类 = %+v，期望只包含译文", zh)
+	}
+}
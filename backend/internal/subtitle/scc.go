@@ -0,0 +1,786 @@
+package subtitle
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SCC / CEA-608 常量
+const (
+	sccHeader    = "Scenarist_SCC V1.0"
+	sccColumns   = 32 // 每行最多32列
+	sccMaxRows   = 4  // 推荐的最大行数
+	sccFrameRate = 30000.0 / 1001
+)
+
+// CEA-608 通道1的杂项控制码（第二字节，第一字节为0x14）
+const (
+	sccRCL = 0x20 // 恢复字幕加载（弹出式）
+	sccBS  = 0x21 // 退格
+	sccDER = 0x24 // 删除到行尾
+	sccRU2 = 0x25 // 两行滚动
+	sccRU3 = 0x26 // 三行滚动
+	sccRU4 = 0x27 // 四行滚动
+	sccRDC = 0x29 // 直接显示（逐字绘制）
+	sccEDM = 0x2C // 清除显示内存
+	sccCR  = 0x2D // 回车（滚动）
+	sccENM = 0x2E // 清除非显示内存
+	sccEOC = 0x2F // 字幕结束（交换显示内存）
+)
+
+// 显示模式
+const (
+	sccPopOn = iota
+	sccRollUp
+	sccPaintOn
+)
+
+// SCCParser Scenarist SCC（CEA-608）格式解析器
+type SCCParser struct{}
+
+func (p *SCCParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	return parseSCC(string(data))
+}
+
+func (p *SCCParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return []byte(buildSCC(entries))
+}
+
+// Validate 检查字幕能否用CEA-608完整表示
+func (p *SCCParser) Validate(entries []models.SubtitleEntry) []models.Diagnostic {
+	var diagnostics []models.Diagnostic
+	for _, entry := range entries {
+		text := plainSCCText(entry.Content)
+		var missing []string
+		seen := make(map[rune]bool)
+		for _, r := range text {
+			if r == '\n' || seen[r] {
+				continue
+			}
+			if _, exact := encode608Rune(r); !exact {
+				seen[r] = true
+				missing = append(missing, string(r))
+			}
+		}
+		if len(missing) > 0 {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("第%d条字幕包含CEA-608无法表示的字符，已替换或删除: %s", entry.Index, strings.Join(missing, " ")),
+				Raw:      entry.Content,
+			})
+		}
+		if rows := len(wrapSCCText(text)); rows > sccMaxRows {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("第%d条字幕按%d列换行后有%d行，超过建议的%d行", entry.Index, sccColumns, rows, sccMaxRows),
+				Raw:      entry.Content,
+			})
+		}
+	}
+	return diagnostics
+}
+
+func (p *SCCParser) SupportedExtensions() []string {
+	return []string{".scc"}
+}
+
+// sccLinePattern 匹配 时间码<TAB>字节对... 形式的行，分号表示丢帧时间码
+var sccLinePattern = regexp.MustCompile(`^(\d{2}):(\d{2}):(\d{2})([:;.,])(\d{2})\s+(.*)$`)
+
+// sccCell 屏幕上的一个字符
+type sccCell struct {
+	r      rune
+	italic bool
+}
+
+// sccScreen 一块字幕内存，15行×32列
+type sccScreen map[int]*[sccColumns]sccCell
+
+// text 返回屏幕上的文本，斜体以<i>标记
+func (s sccScreen) text() string {
+	rows := make([]int, 0, len(s))
+	for row := range s {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+	var lines []string
+	for _, row := range rows {
+		if line := s.rowText(row); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rowText 返回一行的文本
+func (s sccScreen) rowText(row int) string {
+	cells, ok := s[row]
+	if !ok {
+		return ""
+	}
+	var b strings.Builder
+	italic := false
+	for _, c := range cells {
+		if c.r == 0 {
+			c.r = ' '
+		}
+		if c.italic != italic && c.r != ' ' {
+			if c.italic {
+				b.WriteString("<i>")
+			} else {
+				b.WriteString("</i>")
+			}
+			italic = c.italic
+		}
+		b.WriteRune(c.r)
+	}
+	text := strings.TrimRight(b.String(), " ")
+	if italic {
+		text += "</i>"
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.TrimSpace(strings.ReplaceAll(text, " </i>", "</i> "))
+}
+
+// sccRolled 滚动模式下已经回车、等待结束时间的一行
+type sccRolled struct {
+	start time.Duration
+	text  string
+}
+
+// sccDecoder CEA-608 通道1解码状态
+type sccDecoder struct {
+	mode         int
+	rollRows     int
+	displayed    sccScreen
+	nonDisplayed sccScreen
+	row, col     int
+	italic       bool
+	channel      int
+
+	shownAt  time.Duration // 当前显示内容的开始时间
+	rowStart time.Duration // 滚动模式下当前行的开始时间
+	rolled   *sccRolled
+	entries  []models.SubtitleEntry
+}
+
+// emit 输出一条字幕
+func (d *sccDecoder) emit(start, end time.Duration, text string) {
+	if text == "" {
+		return
+	}
+	if end <= start {
+		end = start + time.Second
+	}
+	d.entries = append(d.entries, models.SubtitleEntry{
+		Index:     len(d.entries) + 1,
+		TimeRange: utils.FormatSRTTimestamp(start) + " --> " + utils.FormatSRTTimestamp(end),
+		Content:   text,
+	})
+}
+
+// closeRolled 结束上一行滚动字幕
+func (d *sccDecoder) closeRolled(now time.Duration) {
+	if d.rolled != nil {
+		d.emit(d.rolled.start, now, d.rolled.text)
+		d.rolled = nil
+	}
+}
+
+// flushDisplayed 清屏时输出当前显示的字幕
+func (d *sccDecoder) flushDisplayed(now time.Duration) {
+	switch d.mode {
+	case sccRollUp:
+		d.closeRolled(now)
+		d.emit(d.rowStart, now, d.displayed.rowText(d.row))
+	default:
+		d.emit(d.shownAt, now, d.displayed.text())
+	}
+	d.displayed = sccScreen{}
+}
+
+// target 返回当前写入的内存
+func (d *sccDecoder) target() sccScreen {
+	if d.mode == sccPopOn {
+		return d.nonDisplayed
+	}
+	return d.displayed
+}
+
+// put 在光标处写入一个字符
+func (d *sccDecoder) put(r rune, now time.Duration) {
+	screen := d.target()
+	if d.row == 0 {
+		d.row = 15
+	}
+	cells, ok := screen[d.row]
+	if !ok {
+		cells = &[sccColumns]sccCell{}
+		screen[d.row] = cells
+	}
+	switch d.mode {
+	case sccRollUp:
+		if d.displayed.rowText(d.row) == "" && r != ' ' {
+			d.closeRolled(now)
+			d.rowStart = now
+		}
+	case sccPaintOn:
+		if d.displayed.text() == "" && r != ' ' {
+			d.shownAt = now
+		}
+	}
+	col := min(d.col, sccColumns-1)
+	cells[col] = sccCell{r: r, italic: d.italic}
+	d.col = col + 1
+}
+
+// backspace 删除光标前的字符
+func (d *sccDecoder) backspace() {
+	if d.col > 0 {
+		d.col--
+		if cells, ok := d.target()[d.row]; ok {
+			cells[d.col] = sccCell{}
+		}
+	}
+}
+
+// control 处理杂项控制码
+func (d *sccDecoder) control(code byte, now time.Duration) {
+	switch code {
+	case sccRCL:
+		if d.mode == sccRollUp {
+			d.flushDisplayed(now)
+		}
+		d.mode = sccPopOn
+	case sccRU2, sccRU3, sccRU4:
+		if d.mode != sccRollUp {
+			d.flushDisplayed(now)
+			d.nonDisplayed = sccScreen{}
+			d.row, d.col = 15, 0
+		}
+		d.mode, d.rollRows = sccRollUp, int(code-sccRU2)+2
+	case sccRDC:
+		if d.mode == sccRollUp {
+			d.flushDisplayed(now)
+		}
+		d.mode = sccPaintOn
+	case sccBS:
+		d.backspace()
+	case sccDER:
+		if cells, ok := d.target()[d.row]; ok {
+			for i := d.col; i < sccColumns; i++ {
+				cells[i] = sccCell{}
+			}
+		}
+	case sccEDM:
+		d.flushDisplayed(now)
+	case sccENM:
+		d.nonDisplayed = sccScreen{}
+	case sccEOC:
+		d.flushDisplayed(now)
+		d.displayed, d.nonDisplayed = d.nonDisplayed, sccScreen{}
+		d.shownAt = now
+	case sccCR:
+		if d.mode == sccRollUp {
+			if text := d.displayed.rowText(d.row); text != "" {
+				d.closeRolled(now)
+				d.rolled = &sccRolled{start: d.rowStart, text: text}
+			}
+			delete(d.displayed, d.row)
+			d.col = 0
+		}
+	}
+}
+
+// pac 处理行首地址码，设置行号、缩进和斜体
+func (d *sccDecoder) pac(b1, b2 byte) {
+	rows := map[byte][2]int{
+		0x11: {1, 2}, 0x12: {3, 4}, 0x15: {5, 6}, 0x16: {7, 8}, 0x17: {9, 10},
+		0x10: {11, 11}, 0x13: {12, 13}, 0x14: {14, 15},
+	}
+	pair, ok := rows[b1]
+	if !ok {
+		return
+	}
+	row := pair[0]
+	if b2&0x20 != 0 {
+		row = pair[1]
+	}
+	if d.mode == sccRollUp && row != d.row {
+		// 滚动模式下地址码移动基准行，保留当前行内容
+		if cells, ok := d.displayed[d.row]; ok {
+			delete(d.displayed, d.row)
+			d.displayed[row] = cells
+		}
+	}
+	d.row = row
+	attr := b2 & 0x1F
+	if attr >= 0x10 {
+		d.col = int(attr&0x0E) * 2
+		d.italic = false
+	} else {
+		d.col = 0
+		d.italic = attr&0x0E == 0x0E
+	}
+}
+
+// parseSCC 解码SCC文件中通道1的字幕
+func parseSCC(content string) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	var diagnostics []models.Diagnostic
+	lines := strings.Split(strings.ReplaceAll(strings.TrimPrefix(content, "\uFEFF"), "\r\n", "\n"), "\n")
+
+	first := 0
+	for first < len(lines) && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	if first < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[first]), "Scenarist_SCC") {
+		first++
+	} else {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     first + 1,
+			Severity: models.SeverityWarning,
+			Message:  "缺少Scenarist_SCC文件头",
+		})
+	}
+
+	d := &sccDecoder{displayed: sccScreen{}, nonDisplayed: sccScreen{}, channel: 1}
+	var last time.Duration
+	var prevWord uint16
+	for n := first; n < len(lines); n++ {
+		line := strings.TrimSpace(lines[n])
+		if line == "" {
+			continue
+		}
+		m := sccLinePattern.FindStringSubmatch(line)
+		if m == nil {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     n + 1,
+				Severity: models.SeverityWarning,
+				Message:  "无法识别的SCC行，已跳过",
+				Raw:      lines[n],
+			})
+			continue
+		}
+		h, _ := strconv.Atoi(m[1])
+		mi, _ := strconv.Atoi(m[2])
+		s, _ := strconv.Atoi(m[3])
+		f, _ := strconv.Atoi(m[5])
+		start := sccTimecodeToDuration(h, mi, s, f, m[4] == ";" || m[4] == ",")
+		if start < last {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     n + 1,
+				Severity: models.SeverityWarning,
+				Message:  "时间码早于上一行",
+				Raw:      lines[n],
+			})
+		}
+
+		for i, hex := range strings.Fields(m[6]) {
+			now := start + time.Duration(float64(i)/sccFrameRate*float64(time.Second))
+			last = now
+			word, err := strconv.ParseUint(hex, 16, 16)
+			if err != nil || len(hex) != 4 {
+				diagnostics = append(diagnostics, models.Diagnostic{
+					Line:     n + 1,
+					Severity: models.SeverityWarning,
+					Message:  "无效的字节对，已忽略: " + hex,
+					Raw:      lines[n],
+				})
+				continue
+			}
+			b1, b2 := byte(word>>8)&0x7F, byte(word)&0x7F
+
+			if b1 >= 0x10 && b1 <= 0x1F {
+				// 控制码通常发送两次，忽略紧随其后的重复
+				if uint16(word) == prevWord {
+					prevWord = 0
+					continue
+				}
+				prevWord = uint16(word)
+				d.channel = 1
+				if b1&0x08 != 0 {
+					d.channel = 2
+				}
+				if d.channel != 1 {
+					continue
+				}
+				d.decodeControl(b1&^0x08, b2, now)
+				continue
+			}
+			prevWord = 0
+			if d.channel != 1 {
+				continue
+			}
+			for _, b := range []byte{b1, b2} {
+				if b >= 0x20 {
+					d.put(basic608[b-0x20], now)
+				}
+			}
+		}
+	}
+
+	// 文件结束时仍在显示的字幕
+	if d.mode == sccRollUp {
+		d.flushDisplayed(last + 2*time.Second)
+	} else if text := d.displayed.text(); text != "" {
+		d.emit(d.shownAt, max(last, d.shownAt+2*time.Second), text)
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     len(lines),
+			Severity: models.SeverityInfo,
+			Message:  "最后一条字幕没有清屏指令，按2秒显示时长处理",
+		})
+	}
+	sort.SliceStable(d.entries, func(i, j int) bool {
+		a, _ := utils.ParseTimeRange(d.entries[i].TimeRange)
+		b, _ := utils.ParseTimeRange(d.entries[j].TimeRange)
+		return a.Start < b.Start
+	})
+	for i := range d.entries {
+		d.entries[i].Index = i + 1
+	}
+	return d.entries, diagnostics, nil
+}
+
+// decodeControl 处理第一字节为0x10-0x17的控制码
+func (d *sccDecoder) decodeControl(b1, b2 byte, now time.Duration) {
+	switch {
+	case (b1 == 0x14 || b1 == 0x15) && b2 >= 0x20 && b2 <= 0x2F:
+		d.control(b2, now)
+	case b1 == 0x17 && b2 >= 0x21 && b2 <= 0x23:
+		d.col = min(d.col+int(b2-0x20), sccColumns-1)
+	case b1 == 0x11 && b2 >= 0x20 && b2 <= 0x2F:
+		// 行中样式码占一个空格
+		d.italic = b2 == 0x2E || b2 == 0x2F
+		d.put(' ', now)
+	case b1 == 0x11 && b2 >= 0x30 && b2 <= 0x3F:
+		d.put(special608[b2-0x30], now)
+	case (b1 == 0x12 || b1 == 0x13) && b2 >= 0x20 && b2 <= 0x3F:
+		// 扩展字符替换前面发送的替代字符
+		d.backspace()
+		if b1 == 0x12 {
+			d.put(extended608a[b2-0x20], now)
+		} else {
+			d.put(extended608b[b2-0x20], now)
+		}
+	case b2 >= 0x40 && b2 <= 0x7F:
+		d.pac(b1, b2)
+	}
+}
+
+// sccTimecodeToDuration 将29.97帧时间码换算为时长，dropFrame表示丢帧时间码
+func sccTimecodeToDuration(h, m, s, f int, dropFrame bool) time.Duration {
+	frames := (h*3600+m*60+s)*30 + f
+	if dropFrame {
+		minutes := h*60 + m
+		frames -= 2 * (minutes - minutes/10)
+	}
+	return time.Duration(math.Round(float64(frames)/sccFrameRate*1000)) * time.Millisecond
+}
+
+// formatSCCTimecode 将时长格式化为丢帧时间码 HH:MM:SS;FF
+func formatSCCTimecode(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	frames := int(math.Round(d.Seconds() * sccFrameRate))
+	tens, rest := frames/17982, frames%17982
+	frames += 18 * tens
+	if rest >= 2 {
+		frames += 2 * ((rest - 2) / 1798)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d;%02d", frames/108000%24, frames/1800%60, frames/30%60, frames%30)
+}
+
+// CEA-608 字符集
+var (
+	basic608 = []rune(" !\"#$%&'()á+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[é]íóúabcdefghijklmnopqrstuvwxyzç÷Ññ█")
+	// special608 第一字节0x11，第二字节0x30-0x3F
+	special608 = []rune("®°½¿™¢£♪à èâêîôû")
+	// extended608a 第一字节0x12，第二字节0x20-0x3F（西班牙语、法语及符号）
+	extended608a = []rune("ÁÉÓÚÜü‘¡*'—©℠•“”ÀÂÇÈÊËëÎÏïÔÙùÛ«»")
+	// extended608b 第一字节0x13，第二字节0x20-0x3F（葡萄牙语、德语、丹麦语）
+	extended608b = []rune("ÃãÍÌìÒòÕõ{}\\^_|~ÄäÖöß¥¤¦ÅåØø┌┐└┘")
+)
+
+// sccCode 一个字符编码后的形式：基本字符为单字节，特殊和扩展字符为控制码字节对
+type sccCode struct {
+	basic    byte
+	b1, b2   byte
+	fallback byte // 扩展字符前发送的替代字符，供不支持扩展字符的解码器显示
+}
+
+// encode608Rune 将字符编码为CEA-608，exact为false表示无法精确表示，已使用近似字符或删除
+func encode608Rune(r rune) (sccCode, bool) {
+	switch r {
+	case '’':
+		r = '\''
+	case ' ':
+		return sccCode{b1: 0x11, b2: 0x39}, true
+	}
+	for i, c := range basic608 {
+		if c == r {
+			return sccCode{basic: byte(i + 0x20)}, true
+		}
+	}
+	for i, c := range special608 {
+		if c == r {
+			return sccCode{b1: 0x11, b2: byte(i + 0x30)}, true
+		}
+	}
+	for table, chars := range [][]rune{extended608a, extended608b} {
+		for i, c := range chars {
+			if c == r {
+				code := sccCode{b1: byte(0x12 + table), b2: byte(i + 0x20), fallback: ' '}
+				if base := baseLetter(r); base != 0 {
+					code.fallback = base
+				}
+				return code, true
+			}
+		}
+	}
+	// 去掉变音符号后用基本字母近似
+	if base := baseLetter(r); base != 0 {
+		return sccCode{basic: base}, false
+	}
+	return sccCode{}, false
+}
+
+// baseLetter 返回带变音符号字母对应的基本字符，没有时返回0
+func baseLetter(r rune) byte {
+	d := []rune(norm.NFD.String(string(r)))
+	if len(d) > 1 && d[0] >= 0x20 && d[0] < 0x7F && d[0] != r {
+		for i, c := range basic608 {
+			if c == d[0] {
+				return byte(i + 0x20)
+			}
+		}
+	}
+	return 0
+}
+
+// sccTagPattern 字幕内容中除斜体外的格式标记
+var sccTagPattern = regexp.MustCompile(`(?i)<(/?)([a-z]+)[^>]*>`)
+
+// sccSegmentPattern 将一行拆分为斜体标记和文本片段
+var sccSegmentPattern = regexp.MustCompile(`(?i)</?i>|[^<]+|<`)
+
+// plainSCCText 去掉斜体以外的标记
+func plainSCCText(content string) string {
+	return sccTagPattern.ReplaceAllStringFunc(content, func(tag string) string {
+		if m := sccTagPattern.FindStringSubmatch(tag); strings.ToLower(m[2]) == "i" {
+			return "<" + m[1] + "i>"
+		}
+		return ""
+	})
+}
+
+// wrapSCCText 按32列换行，斜体标记不占列宽
+func wrapSCCText(content string) []string {
+	var rows []string
+	for _, line := range strings.Split(content, "\n") {
+		var current string
+		width := func(s string) int {
+			return len([]rune(strings.NewReplacer("<i>", " ", "</i>", " ").Replace(s)))
+		}
+		for _, word := range strings.Fields(line) {
+			switch {
+			case current == "":
+				current = word
+			case width(current+" "+word) <= sccColumns:
+				current += " " + word
+			default:
+				rows = append(rows, current)
+				current = word
+			}
+			for width(current) > sccColumns {
+				r := []rune(current)
+				rows = append(rows, string(r[:sccColumns]))
+				current = string(r[sccColumns:])
+			}
+		}
+		if current != "" {
+			rows = append(rows, current)
+		}
+	}
+	return rows
+}
+
+// sccWords 编码过程中的字节对序列
+type sccWords struct {
+	words   []uint16
+	pending int // 尚未配对的基本字符，-1表示无
+}
+
+func parity(b byte) byte {
+	b &= 0x7F
+	if bits.OnesCount8(b)%2 == 0 {
+		b |= 0x80
+	}
+	return b
+}
+
+func (w *sccWords) char(b byte) {
+	if w.pending >= 0 {
+		w.words = append(w.words, uint16(parity(byte(w.pending)))<<8|uint16(parity(b)))
+		w.pending = -1
+		return
+	}
+	w.pending = int(b)
+}
+
+func (w *sccWords) flush() {
+	if w.pending >= 0 {
+		w.words = append(w.words, uint16(parity(byte(w.pending)))<<8|0x80)
+		w.pending = -1
+	}
+}
+
+// code 写入控制码，按惯例发送两次
+func (w *sccWords) code(b1, b2 byte) {
+	w.flush()
+	word := uint16(parity(b1))<<8 | uint16(parity(b2))
+	w.words = append(w.words, word, word)
+}
+
+// sccPACRows 行号对应的行首地址码第一字节
+var sccPACRows = map[int]byte{
+	1: 0x11, 2: 0x11, 3: 0x12, 4: 0x12, 5: 0x15, 6: 0x15, 7: 0x16, 8: 0x16,
+	9: 0x17, 10: 0x17, 11: 0x10, 12: 0x13, 13: 0x13, 14: 0x14, 15: 0x14,
+}
+
+// sccRowIsSecond 判断行号是否使用地址码的第二组（第二字节加0x20）
+func sccRowIsSecond(row int) bool {
+	switch row {
+	case 2, 4, 6, 8, 10, 13, 15:
+		return true
+	}
+	return false
+}
+
+// encodeSCCCaption 编码一条弹出式字幕：清除非显示内存、加载文本、交换显示
+func encodeSCCCaption(content string) []uint16 {
+	w := &sccWords{pending: -1}
+	w.code(0x14, sccENM)
+	w.code(0x14, sccRCL)
+
+	rows := wrapSCCText(plainSCCText(content))
+	if len(rows) > 15 {
+		rows = rows[len(rows)-15:]
+	}
+	for i, row := range rows {
+		rowNum := 15 - len(rows) + 1 + i
+		visible := []rune(strings.NewReplacer("<i>", " ", "</i>", " ").Replace(row))
+		col := (sccColumns - len(visible)) / 2
+		b1, b2 := sccPACRows[rowNum], byte(0x50+col/4*2)
+		if sccRowIsSecond(rowNum) {
+			b2 += 0x20
+		}
+		w.code(b1, b2)
+		if col%4 > 0 {
+			w.code(0x17, byte(0x20+col%4))
+		}
+		for _, part := range sccSegmentPattern.FindAllString(row, -1) {
+			switch strings.ToLower(part) {
+			case "<i>":
+				w.code(0x11, 0x2E)
+				continue
+			case "</i>":
+				w.code(0x11, 0x20)
+				continue
+			}
+			for _, r := range part {
+				code, _ := encode608Rune(r)
+				switch {
+				case code.basic != 0:
+					w.char(code.basic)
+				case code.fallback != 0:
+					w.char(code.fallback)
+					w.code(code.b1, code.b2)
+				case code.b1 != 0:
+					w.code(code.b1, code.b2)
+				}
+			}
+		}
+		w.flush()
+	}
+	w.code(0x14, sccEOC)
+	return w.words
+}
+
+// buildSCC 将字幕编码为弹出式SCC
+func buildSCC(entries []models.SubtitleEntry) string {
+	frame := time.Duration(math.Round(float64(time.Second) / sccFrameRate))
+	clear := []uint16{0x942C, 0x942C}
+
+	var b strings.Builder
+	b.WriteString(sccHeader + "\n\n")
+	var free time.Duration // 上一行数据发送完毕的时间
+	write := func(at time.Duration, words []uint16) {
+		b.WriteString(formatSCCTimecode(at) + "\t")
+		for i, word := range words {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(fmt.Sprintf("%04x", word))
+		}
+		b.WriteString("\n\n")
+		free = at + time.Duration(len(words))*frame
+	}
+
+	pendingClear := time.Duration(-1) // 上一条字幕的清屏时间，-1表示由下一条的交换代替
+	for i, entry := range entries {
+		tr, err := utils.ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			continue
+		}
+		// 加载在开始时间之前完成，字幕结束代码（两次中的第一次）正好落在开始时间
+		load := encodeSCCCaption(entry.Content)
+		at := max(tr.Start-time.Duration(len(load)-2)*frame, free)
+		if pendingClear >= 0 {
+			if clearAt := max(pendingClear, free); clearAt+2*frame <= at {
+				write(clearAt, clear)
+			} else {
+				// 清屏时间落在加载过程中，插入到加载数据里
+				at = max(at-2*frame, free)
+				k := sccSplicePoint(load, int((pendingClear-at)/frame))
+				load = append(load[:k], append(clear, load[k:]...)...)
+			}
+		}
+		write(at, load)
+
+		pendingClear = -1
+		if i+1 == len(entries) {
+			pendingClear = tr.End
+		} else if next, err := utils.ParseTimeRange(entries[i+1].TimeRange); err != nil || next.Start > tr.End {
+			pendingClear = tr.End
+		}
+	}
+	if pendingClear >= 0 {
+		write(max(pendingClear, free), clear)
+	}
+	return b.String()
+}
+
+// sccSplicePoint 返回不晚于want、且不会拆开成对控制码的插入位置
+func sccSplicePoint(words []uint16, want int) int {
+	point := 0
+	for i := 0; i < len(words) && i <= want; {
+		point = i
+		if b1 := byte(words[i]>>8) & 0x7F; b1 >= 0x10 && b1 <= 0x1F {
+			i += 2
+		} else {
+			i++
+		}
+	}
+	return point
+}
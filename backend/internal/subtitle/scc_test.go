@@ -0,0 +1,132 @@
+package subtitle
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+)
+
+func TestSCCTimecode(t *testing.T) {
+	tests := []struct {
+		timecode  string
+		h, m, s   int
+		f         int
+		dropFrame bool
+		want      time.Duration
+	}{
+		{timecode: "00:00:01;00", s: 1, dropFrame: true, want: 1001 * time.Millisecond},
+		// 丢帧时间码在每分钟开头跳过;00和;01，整十分钟除外
+		{timecode: "00:01:00;02", m: 1, f: 2, dropFrame: true, want: 60060 * time.Millisecond},
+		// 丢帧时间码每小时仍比实际时间少约3.6毫秒
+		{timecode: "00:10:00;00", m: 10, dropFrame: true, want: 599999 * time.Millisecond},
+		{timecode: "01:00:00;00", h: 1, dropFrame: true, want: 3599996 * time.Millisecond},
+		{timecode: "00:10:00:00", m: 10, want: 600600 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got := sccTimecodeToDuration(tt.h, tt.m, tt.s, tt.f, tt.dropFrame)
+		if got != tt.want {
+			t.Errorf("sccTimecodeToDuration(%s) = %v，期望 %v", tt.timecode, got, tt.want)
+		}
+		if tt.dropFrame {
+			if back := formatSCCTimecode(got); back != tt.timecode {
+				t.Errorf("formatSCCTimecode(%v) = %s，期望 %s", got, back, tt.timecode)
+			}
+		}
+	}
+
+	// 丢帧时间码的每一帧都能往返
+	for frames := 0; frames < 2*17982; frames += 7 {
+		d := time.Duration(float64(frames) / sccFrameRate * float64(time.Second))
+		tc := formatSCCTimecode(d)
+		var h, m, s, f int
+		if _, err := fmt.Sscanf(tc, "%d:%d:%d;%d", &h, &m, &s, &f); err != nil {
+			t.Fatalf("无法解析 %s: %v", tc, err)
+		}
+		if m%10 != 0 && s == 0 && f < 2 {
+			t.Fatalf("第%d帧格式化为不存在的丢帧时间码 %s", frames, tc)
+		}
+		if got := sccTimecodeToDuration(h, m, s, f, true); absDuration(got-d) > time.Millisecond {
+			t.Fatalf("第%d帧: %s 换算为 %v，期望 %v", frames, tc, got, d)
+		}
+	}
+}
+
+func TestSCCRoundTrip(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:59,900 --> 00:01:02,000", Content: "Hello, world!\n<i>Second line</i>"},
+		{Index: 2, TimeRange: "00:09:59,500 --> 00:10:01,000", Content: "Ça va? ½ ♪"},
+		{Index: 3, TimeRange: "01:00:05,000 --> 01:00:07,000", Content: "Größe"},
+	}
+	built := string((&SCCParser{}).Build(entries, "translated"))
+	if !strings.HasPrefix(built, sccHeader+"\n") {
+		t.Errorf("缺少文件头:\n%s", built)
+	}
+	for _, line := range strings.Split(built, "\n")[1:] {
+		if m := sccLinePattern.FindStringSubmatch(line); m != nil && m[4] != ";" {
+			t.Errorf("应输出丢帧时间码: %s", line)
+		}
+	}
+
+	got, diagnostics, err := (&SCCParser{}).Parse([]byte(built))
+	if err != nil {
+		t.Fatalf("解析构建结果失败: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("诊断信息 = %+v，期望为空", diagnostics)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("往返后 = %+v，期望 %d 条", got, len(entries))
+	}
+	frame := time.Duration(math.Ceil(float64(time.Second) / sccFrameRate))
+	for i, entry := range entries {
+		if got[i].Index != entry.Index || got[i].Content != entry.Content {
+			t.Errorf("第%d条 = %+v，期望 %+v", i+1, got[i], entry)
+		}
+		want, _ := utils.ParseTimeRange(entry.TimeRange)
+		tr, err := utils.ParseTimeRange(got[i].TimeRange)
+		if err != nil {
+			t.Fatalf("第%d条时间范围无效: %v", i+1, err)
+		}
+		// 时间按帧取整，误差不超过一帧
+		if absDuration(tr.Start-want.Start) > frame || absDuration(tr.End-want.End) > frame {
+			t.Errorf("第%d条时间 = %s，期望 %s", i+1, got[i].TimeRange, entry.TimeRange)
+		}
+	}
+}
+
+func TestSCCParseDropFrameFile(t *testing.T) {
+	// 弹出式字幕：加载后在00:01:00;02显示，00:01:02;00清屏，其中有一个无效的字节对
+	content := sccHeader + "\n\n" +
+		"00:00:59;20\t9420 9420 9470 9470 c8e5 ecec zz ef80\n\n" +
+		"00:01:00;02\t942f 942f\n\n" +
+		"00:01:02;00\t942c 942c\n"
+	got, diagnostics, err := (&SCCParser{}).Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(got) != 1 || got[0].Content != "Hello" {
+		t.Fatalf("解析结果 = %+v，诊断信息 %+v", got, diagnostics)
+	}
+	if want := "00:01:00,060 --> 00:01:01,995"; got[0].TimeRange != want {
+		t.Errorf("时间范围 = %s，期望 %s", got[0].TimeRange, want)
+	}
+	var warned bool
+	for _, d := range diagnostics {
+		warned = warned || strings.Contains(d.Message, "无效的字节对")
+	}
+	if !warned {
+		t.Errorf("无效的字节对应报告警告: %+v", diagnostics)
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
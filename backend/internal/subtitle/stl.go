@@ -0,0 +1,539 @@
+package subtitle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// EBU STL条目附加属性的键
+const (
+	AttrVerticalPosition = "verticalPosition" // 垂直位置（图文电视行号或开放字幕的位置值）
+	AttrJustification    = "justification"    // 对齐方式：0不变，1左对齐，2居中，3右对齐
+)
+
+// EBU STL (Tech 3264) 块大小
+const (
+	stlGSISize     = 1024
+	stlTTISize     = 128
+	stlTextSize    = 112
+	stlLastBlock   = 0xFF // EBN：最后一个扩展块
+	stlUserBlock   = 0xFE // EBN：用户数据块
+	stlNewline     = 0x8A
+	stlUnusedSpace = 0x8F
+)
+
+// STL文本字段中的格式控制码
+const (
+	stlItalicOn     = 0x80
+	stlItalicOff    = 0x81
+	stlUnderlineOn  = 0x82
+	stlUnderlineOff = 0x83
+)
+
+// STLParser EBU STL二进制格式解析器
+// 解析后保留GSI块，构建时沿用节目信息并更新统计字段
+type STLParser struct {
+	gsi []byte
+}
+
+func (p *STLParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	entries, diagnostics, err := parseSTL(data)
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	p.gsi = append([]byte(nil), data[:stlGSISize]...)
+	return entries, diagnostics, nil
+}
+
+func (p *STLParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	return buildSTL(p.gsi, entries)
+}
+
+// Validate 检查译文中所选字符代码表无法表示的字符，构建时这些字符会被替换为 '?'
+func (p *STLParser) Validate(entries []models.SubtitleEntry) []models.Diagnostic {
+	var diagnostics []models.Diagnostic
+	table := chooseSTLTable(entries)
+	for _, entry := range entries {
+		var missing []string
+		seen := make(map[rune]bool)
+		for _, r := range stlTagPattern.ReplaceAllString(entry.Content, "") {
+			if r == '\n' || r == '?' || seen[r] {
+				continue
+			}
+			if b := encodeSTLRune(r, table); len(b) == 1 && b[0] == '?' {
+				seen[r] = true
+				missing = append(missing, string(r))
+			}
+		}
+		if len(missing) > 0 {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("第%d条字幕包含EBU STL字符代码表%s无法表示的字符，已替换为?: %s", entry.Index, table, strings.Join(missing, " ")),
+				Raw:      entry.Content,
+			})
+		}
+	}
+	return diagnostics
+}
+
+func (p *STLParser) SupportedExtensions() []string {
+	return []string{".stl"}
+}
+
+// stlFrameRate 根据GSI中的磁盘格式代码（DFC）返回帧率
+func stlFrameRate(gsi []byte) (float64, bool) {
+	switch string(gsi[3:11]) {
+	case "STL25.01":
+		return 25, true
+	case "STL30.01":
+		return 30, true
+	default:
+		return 25, false
+	}
+}
+
+// parseSTL 解析GSI块和TTI块
+func parseSTL(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	var entries []models.SubtitleEntry
+	var diagnostics []models.Diagnostic
+	report := func(block int, severity, message string) {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     block,
+			Severity: severity,
+			Message:  message,
+		})
+	}
+
+	if len(data) < stlGSISize {
+		return nil, nil, fmt.Errorf("文件过短，不是有效的EBU STL文件")
+	}
+	gsi := data[:stlGSISize]
+	fps, ok := stlFrameRate(gsi)
+	if !ok {
+		return nil, nil, fmt.Errorf("不是有效的EBU STL文件：无法识别的磁盘格式代码 %q", gsi[3:11])
+	}
+	table := string(gsi[12:14])
+	if _, ok := stlDecoders[table]; !ok {
+		report(0, models.SeverityWarning, fmt.Sprintf("未知的字符代码表%q，按Latin（ISO 6937）解码", table))
+		table = "00"
+	}
+
+	body := data[stlGSISize:]
+	if rest := len(body) % stlTTISize; rest != 0 {
+		report(len(body)/stlTTISize+1, models.SeverityWarning, fmt.Sprintf("文件末尾有%d字节不完整的TTI块，已忽略", rest))
+	}
+	blocks := len(body) / stlTTISize
+	if tnb, err := strconv.Atoi(strings.TrimSpace(string(gsi[238:243]))); err == nil && tnb != blocks {
+		report(0, models.SeverityInfo, fmt.Sprintf("GSI声明%d个TTI块，实际为%d个", tnb, blocks))
+	}
+
+	// 诊断信息中的Line表示TTI块序号（从1开始）
+	var text []byte
+	for n := 0; n < blocks; n++ {
+		tti := body[n*stlTTISize : (n+1)*stlTTISize]
+		ebn := tti[3]
+		if ebn == stlUserBlock {
+			continue
+		}
+		text = append(text, tti[16:]...)
+		if ebn != stlLastBlock {
+			// 扩展块，文本在后续块中继续
+			continue
+		}
+		block, content := n+1, text
+		text = nil
+
+		if tti[15] != 0 {
+			report(block, models.SeverityInfo, "跳过注释块")
+			continue
+		}
+		start, err := stlTimecode(tti[5:9], fps)
+		if err != nil {
+			report(block, models.SeverityError, "入点时间码无效，已跳过该字幕: "+err.Error())
+			continue
+		}
+		end, err := stlTimecode(tti[9:13], fps)
+		if err != nil {
+			report(block, models.SeverityError, "出点时间码无效，已跳过该字幕: "+err.Error())
+			continue
+		}
+		decoded := decodeSTLText(content, table)
+		if decoded == "" {
+			report(block, models.SeverityWarning, "字幕文本为空，已跳过")
+			continue
+		}
+
+		entries = append(entries, models.SubtitleEntry{
+			Index:     len(entries) + 1,
+			TimeRange: utils.FormatSRTTimestamp(start) + " --> " + utils.FormatSRTTimestamp(end),
+			Content:   decoded,
+			Attributes: map[string]string{
+				AttrVerticalPosition: strconv.Itoa(int(tti[13])),
+				AttrJustification:    strconv.Itoa(int(tti[14])),
+			},
+		})
+	}
+	if len(text) > 0 {
+		report(blocks, models.SeverityError, "最后一条字幕缺少结束扩展块，已跳过")
+	}
+
+	return entries, diagnostics, nil
+}
+
+// stlTimecode 将 时:分:秒:帧 四个字节转换为时长
+func stlTimecode(tc []byte, fps float64) (time.Duration, error) {
+	h, m, s, f := int(tc[0]), int(tc[1]), int(tc[2]), int(tc[3])
+	if h > 23 || m > 59 || s > 59 || float64(f) >= fps {
+		return 0, fmt.Errorf("%02d:%02d:%02d:%02d", h, m, s, f)
+	}
+	seconds := float64(h*3600+m*60+s) + float64(f)/fps
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond, nil
+}
+
+// stlTimecodeBytes 将时长转换为 时:分:秒:帧 四个字节
+func stlTimecodeBytes(d time.Duration, fps float64) []byte {
+	if d < 0 {
+		d = 0
+	}
+	frames := int64(math.Round(d.Seconds() * fps))
+	perHour := int64(fps) * 3600
+	h := frames / perHour % 24
+	m := frames % perHour / (int64(fps) * 60)
+	s := frames % (int64(fps) * 60) / int64(fps)
+	f := frames % int64(fps)
+	return []byte{byte(h), byte(m), byte(s), byte(f)}
+}
+
+// decodeSTLText 解码TTI文本字段：0x8A换行，0x80/0x81斜体，0x82/0x83下划线，0x8F为填充
+func decodeSTLText(data []byte, table string) string {
+	var lines []string
+	var line strings.Builder
+	italic, underline := false, false
+	flush := func() {
+		if italic {
+			line.WriteString("</i>")
+		}
+		if underline {
+			line.WriteString("</u>")
+		}
+		if text := strings.TrimSpace(line.String()); text != "" && text != "<i></i>" && text != "<u></u>" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+		if italic {
+			line.WriteString("<i>")
+		}
+		if underline {
+			line.WriteString("<u>")
+		}
+	}
+
+	decode := stlDecoders[table]
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b == stlNewline:
+			flush()
+		case b == stlUnusedSpace:
+			// 填充字节，扩展块中填充之后可能还有文本
+		case b == stlItalicOn && !italic:
+			italic = true
+			line.WriteString("<i>")
+		case b == stlItalicOff && italic:
+			italic = false
+			line.WriteString("</i>")
+		case b == stlUnderlineOn && !underline:
+			underline = true
+			line.WriteString("<u>")
+		case b == stlUnderlineOff && underline:
+			underline = false
+			line.WriteString("</u>")
+		case b < 0x20 || (b >= 0x80 && b < 0xA0):
+			// 图文电视颜色、双高等控制码，在字幕文本中占一个空格
+			line.WriteByte(' ')
+		default:
+			r, size := decode(data[i:])
+			line.WriteRune(r)
+			i += size - 1
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// stlDecoders 字符代码表（CCT）对应的解码函数，返回字符和占用的字节数
+var stlDecoders = map[string]func([]byte) (rune, int){
+	"00": decodeISO6937,
+	"01": charmapDecoder(charmap.ISO8859_5),
+	"02": charmapDecoder(charmap.ISO8859_6),
+	"03": charmapDecoder(charmap.ISO8859_7),
+	"04": charmapDecoder(charmap.ISO8859_8),
+}
+
+// charmapDecoder 单字节代码表的解码函数
+func charmapDecoder(cm *charmap.Charmap) func([]byte) (rune, int) {
+	return func(b []byte) (rune, int) {
+		return cm.DecodeByte(b[0]), 1
+	}
+}
+
+// iso6937 ISO 6937 高位字符（0xC1-0xCF为变音符号，单独处理）
+var iso6937 = map[byte]rune{
+	0x24: '¤', 0xA0: '\u00A0', 0xA1: '¡', 0xA2: '¢', 0xA3: '£', 0xA4: '$', 0xA5: '¥', 0xA6: '#', 0xA7: '§',
+	0xA8: '¤', 0xA9: '‘', 0xAA: '“', 0xAB: '«', 0xAC: '←', 0xAD: '↑', 0xAE: '→', 0xAF: '↓',
+	0xB0: '°', 0xB1: '±', 0xB2: '²', 0xB3: '³', 0xB4: '×', 0xB5: 'µ', 0xB6: '¶', 0xB7: '·',
+	0xB8: '÷', 0xB9: '’', 0xBA: '”', 0xBB: '»', 0xBC: '¼', 0xBD: '½', 0xBE: '¾', 0xBF: '¿',
+	0xD0: '―', 0xD1: '¹', 0xD2: '®', 0xD3: '©', 0xD4: '™', 0xD5: '♪', 0xD6: '¬', 0xD7: '¦',
+	0xDC: '⅛', 0xDD: '⅜', 0xDE: '⅝', 0xDF: '⅞',
+	0xE0: 'Ω', 0xE1: 'Æ', 0xE2: 'Đ', 0xE3: 'ª', 0xE4: 'Ħ', 0xE6: 'Ĳ', 0xE7: 'Ŀ',
+	0xE8: 'Ł', 0xE9: 'Ø', 0xEA: 'Œ', 0xEB: 'º', 0xEC: 'Þ', 0xED: 'Ŧ', 0xEE: 'Ŋ', 0xEF: 'ŉ',
+	0xF0: 'ĸ', 0xF1: 'æ', 0xF2: 'đ', 0xF3: 'ð', 0xF4: 'ħ', 0xF5: 'ı', 0xF6: 'ĳ', 0xF7: 'ŀ',
+	0xF8: 'ł', 0xF9: 'ø', 0xFA: 'œ', 0xFB: 'ß', 0xFC: 'þ', 0xFD: 'ŧ', 0xFE: 'ŋ', 0xFF: '\u00AD',
+}
+
+// iso6937Reverse 字符到ISO 6937高位字节的映射
+var iso6937Reverse = func() map[rune]byte {
+	m := make(map[rune]byte)
+	for b, r := range iso6937 {
+		if b >= 0x80 {
+			m[r] = b
+		}
+	}
+	return m
+}()
+
+// iso6937Diacritics 非间距变音符号前缀对应的组合字符
+var iso6937Diacritics = map[byte]rune{
+	0xC1: '\u0300', 0xC2: '\u0301', 0xC3: '\u0302', 0xC4: '\u0303', 0xC5: '\u0304', 0xC6: '\u0306',
+	0xC7: '\u0307', 0xC8: '\u0308', 0xCA: '\u030A', 0xCB: '\u0327', 0xCD: '\u030B', 0xCE: '\u0328', 0xCF: '\u030C',
+}
+
+// decodeISO6937 解码ISO 6937字符，变音符号前缀与后面的字母合成为一个字符
+func decodeISO6937(b []byte) (rune, int) {
+	if mark, ok := iso6937Diacritics[b[0]]; ok {
+		if len(b) > 1 && b[1] >= 0x20 && b[1] < 0x7F {
+			composed := []rune(norm.NFC.String(string([]rune{rune(b[1]), mark})))
+			if len(composed) == 1 {
+				return composed[0], 2
+			}
+			return rune(b[1]), 2
+		}
+		return ' ', 1
+	}
+	if r, ok := iso6937[b[0]]; ok {
+		return r, 1
+	}
+	if b[0] < 0x80 {
+		return rune(b[0]), 1
+	}
+	return '?', 1
+}
+
+// stlEncoders 编码时可选的字符代码表，按顺序尝试
+var stlEncoders = []struct {
+	table  string
+	script *unicode.RangeTable
+	cm     *charmap.Charmap
+}{
+	{"01", unicode.Cyrillic, charmap.ISO8859_5},
+	{"02", unicode.Arabic, charmap.ISO8859_6},
+	{"03", unicode.Greek, charmap.ISO8859_7},
+	{"04", unicode.Hebrew, charmap.ISO8859_8},
+}
+
+// chooseSTLTable 根据译文使用的文字选择字符代码表，默认Latin
+func chooseSTLTable(entries []models.SubtitleEntry) string {
+	for _, enc := range stlEncoders {
+		for _, entry := range entries {
+			for _, r := range entry.Content {
+				if unicode.Is(enc.script, r) {
+					return enc.table
+				}
+			}
+		}
+	}
+	return "00"
+}
+
+// encodeSTLRune 按代码表编码单个字符，无法表示的字符返回 '?'
+func encodeSTLRune(r rune, table string) []byte {
+	if table != "00" {
+		for _, enc := range stlEncoders {
+			if enc.table == table {
+				if b, ok := enc.cm.EncodeRune(r); ok {
+					return []byte{b}
+				}
+				return []byte{'?'}
+			}
+		}
+	}
+	if r >= 0x20 && r < 0x7F && r != '$' {
+		return []byte{byte(r)}
+	}
+	if b, ok := iso6937Reverse[r]; ok {
+		return []byte{b}
+	}
+	// 带变音符号的字母分解为 前缀 + 基本字母
+	if d := []rune(norm.NFD.String(string(r))); len(d) == 2 && d[0] < 0x7F {
+		for b, mark := range iso6937Diacritics {
+			if mark == d[1] {
+				return []byte{b, byte(d[0])}
+			}
+		}
+	}
+	return []byte{'?'}
+}
+
+// stlTagPattern 字幕内容中的格式标记
+var stlTagPattern = regexp.MustCompile(`(?i)</?[a-z][^>]*>`)
+
+// encodeSTLText 将字幕内容编码为TTI文本字段（不含填充）
+func encodeSTLText(content, table string) []byte {
+	var out []byte
+	for i, line := range strings.Split(content, "\n") {
+		if i > 0 {
+			out = append(out, stlNewline)
+		}
+		last := 0
+		for _, loc := range stlTagPattern.FindAllStringIndex(line, -1) {
+			out = appendSTLText(out, line[last:loc[0]], table)
+			switch strings.ToLower(line[loc[0]:loc[1]]) {
+			case "<i>":
+				out = append(out, stlItalicOn)
+			case "</i>":
+				out = append(out, stlItalicOff)
+			case "<u>":
+				out = append(out, stlUnderlineOn)
+			case "</u>":
+				out = append(out, stlUnderlineOff)
+			}
+			last = loc[1]
+		}
+		out = appendSTLText(out, line[last:], table)
+	}
+	return out
+}
+
+func appendSTLText(out []byte, text, table string) []byte {
+	for _, r := range text {
+		out = append(out, encodeSTLRune(r, table)...)
+	}
+	return out
+}
+
+// defaultSTLGSI 从其他格式转换时使用的GSI块：25帧开放字幕
+func defaultSTLGSI() []byte {
+	gsi := bytes.Repeat([]byte{' '}, stlGSISize)
+	today := time.Now().Format("060102")
+	put := func(offset int, value string) { copy(gsi[offset:], value) }
+	put(0, "850")
+	put(3, "STL25.01")
+	put(11, "0")
+	put(12, "00")
+	put(14, "00")
+	put(224, today)
+	put(230, today)
+	put(236, "00")
+	put(248, "001")
+	put(251, "40")
+	put(253, "23")
+	put(255, "1")
+	put(256, "00000000")
+	put(272, "1")
+	put(273, "1")
+	return gsi
+}
+
+// buildSTL 生成STL文件，gsi为空时使用默认GSI块
+func buildSTL(gsi []byte, entries []models.SubtitleEntry) []byte {
+	if len(gsi) != stlGSISize {
+		gsi = defaultSTLGSI()
+	} else {
+		gsi = append([]byte(nil), gsi...)
+	}
+	fps, _ := stlFrameRate(gsi)
+	table := chooseSTLTable(entries)
+	copy(gsi[12:14], table)
+
+	var body []byte
+	subtitles := 0
+	var firstIn []byte
+	for _, entry := range entries {
+		tr, err := utils.ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			continue
+		}
+		text := encodeSTLText(entry.Content, table)
+		tci, tco := stlTimecodeBytes(tr.Start, fps), stlTimecodeBytes(tr.End, fps)
+		if firstIn == nil {
+			firstIn = tci
+		}
+
+		lines := strings.Count(entry.Content, "\n") + 1
+		vp := stlAttr(entry, AttrVerticalPosition, max(1, 22-2*(lines-1)))
+		jc := stlAttr(entry, AttrJustification, 2)
+
+		// 文本超过112字节时拆分为多个扩展块，不在变音符号前缀之后断开
+		chunks := splitSTLText(text)
+		for i, chunk := range chunks {
+			tti := make([]byte, stlTTISize)
+			tti[0] = 0
+			binary.LittleEndian.PutUint16(tti[1:3], uint16(subtitles))
+			tti[3] = byte(i)
+			if i == len(chunks)-1 {
+				tti[3] = stlLastBlock
+			}
+			copy(tti[5:9], tci)
+			copy(tti[9:13], tco)
+			tti[13] = byte(vp)
+			tti[14] = byte(jc)
+			copy(tti[16:], chunk)
+			for j := 16 + len(chunk); j < stlTTISize; j++ {
+				tti[j] = stlUnusedSpace
+			}
+			body = append(body, tti...)
+		}
+		subtitles++
+	}
+
+	copy(gsi[238:243], fmt.Sprintf("%05d", len(body)/stlTTISize))
+	copy(gsi[243:248], fmt.Sprintf("%05d", subtitles))
+	if firstIn != nil {
+		copy(gsi[264:272], fmt.Sprintf("%02d%02d%02d%02d", firstIn[0], firstIn[1], firstIn[2], firstIn[3]))
+	}
+	return append(gsi, body...)
+}
+
+// stlAttr 读取数值属性，缺失或无效时返回默认值
+func stlAttr(entry models.SubtitleEntry, key string, fallback int) int {
+	if v, err := strconv.Atoi(entry.Attributes[key]); err == nil && v >= 0 && v <= 255 {
+		return v
+	}
+	return fallback
+}
+
+// splitSTLText 将文本按112字节拆分
+func splitSTLText(text []byte) [][]byte {
+	var chunks [][]byte
+	for len(text) > stlTextSize {
+		n := stlTextSize
+		if _, ok := iso6937Diacritics[text[n-1]]; ok {
+			n--
+		}
+		chunks = append(chunks, text[:n])
+		text = text[n:]
+	}
+	return append(chunks, text)
+}
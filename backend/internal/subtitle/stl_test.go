@@ -0,0 +1,165 @@
+package subtitle
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// stlTTI 构造一个TTI块，text不足112字节时用0x8F填充
+func stlTTI(sn uint16, ebn byte, tci, tco []byte, text []byte) []byte {
+	tti := make([]byte, stlTTISize)
+	tti[1], tti[2], tti[3] = byte(sn), byte(sn>>8), ebn
+	copy(tti[5:9], tci)
+	copy(tti[9:13], tco)
+	tti[13], tti[14] = 20, 2
+	copy(tti[16:], text)
+	for i := 16 + len(text); i < stlTTISize; i++ {
+		tti[i] = stlUnusedSpace
+	}
+	return tti
+}
+
+func TestSTLRoundTrip(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,520", Content: "Café <i>crème</i>\nŁódź ½"},
+		{Index: 2, TimeRange: "00:01:00,040 --> 00:01:03,000", Content: "<u>Über</u> naïve", Attributes: map[string]string{AttrVerticalPosition: "1", AttrJustification: "1"}},
+	}
+	built := (&STLParser{}).Build(entries, "translated")
+
+	p := &STLParser{}
+	got, diagnostics, err := p.Parse(built)
+	if err != nil {
+		t.Fatalf("解析构建结果失败: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("诊断信息 = %+v，期望为空", diagnostics)
+	}
+	want := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,520", Content: "Café <i>crème</i>\nŁódź ½", Attributes: map[string]string{AttrVerticalPosition: "20", AttrJustification: "2"}},
+		{Index: 2, TimeRange: "00:01:00,040 --> 00:01:03,000", Content: "<u>Über</u> naïve", Attributes: map[string]string{AttrVerticalPosition: "1", AttrJustification: "1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("往返后 = %+v，期望 %+v", got, want)
+	}
+
+	// 再次构建时沿用GSI块，输出与第一次相同
+	if rebuilt := p.Build(got, "translated"); !bytes.Equal(rebuilt, built) {
+		t.Error("第二次构建的结果与第一次不同")
+	}
+	if tnb := string(built[238:243]); tnb != "00002" {
+		t.Errorf("GSI中的TTI块数 = %q，期望 00002", tnb)
+	}
+}
+
+func TestSTLDiacriticAcrossExtensionBlocks(t *testing.T) {
+	// 111个字母之后是 é（0xC2 'e'），变音符号前缀正好落在第一个块的最后一个字节
+	content := strings.Repeat("a", stlTextSize-1) + "é"
+	built := (&STLParser{}).Build([]models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: content}}, "translated")
+
+	body := built[stlGSISize:]
+	if len(body) != 2*stlTTISize {
+		t.Fatalf("TTI块数 = %d，期望 2", len(body)/stlTTISize)
+	}
+	first, second := body[:stlTTISize], body[stlTTISize:]
+	if first[3] != 0 || second[3] != stlLastBlock {
+		t.Errorf("扩展块序号 = %#x, %#x，期望 0x00, 0xff", first[3], second[3])
+	}
+	if first[16+stlTextSize-1] != stlUnusedSpace || !bytes.HasPrefix(second[16:], []byte{0xC2, 'e'}) {
+		t.Error("变音符号前缀与字母被拆到了不同的扩展块")
+	}
+
+	got, _, err := (&STLParser{}).Parse(built)
+	if err != nil {
+		t.Fatalf("解析构建结果失败: %v", err)
+	}
+	if len(got) != 1 || got[0].Content != content {
+		t.Errorf("往返后 = %+v，期望内容 %q", got, content)
+	}
+
+	// 其他工具写出的文件中，前缀是块的最后一个字节，字母在下一个扩展块开头
+	tci, tco := []byte{0, 0, 1, 0}, []byte{0, 0, 2, 0}
+	text := append(bytes.Repeat([]byte{'a'}, stlTextSize-1), 0xC2)
+	file := append(defaultSTLGSI(), stlTTI(0, 0, tci, tco, text)...)
+	file = append(file, stlTTI(0, stlLastBlock, tci, tco, []byte{'e', '!'})...)
+	got, _, err = (&STLParser{}).Parse(file)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if want := content + "!"; len(got) != 1 || got[0].Content != want {
+		t.Errorf("解析结果 = %+v，期望内容 %q", got, want)
+	}
+}
+
+func TestSTLParseDiagnostics(t *testing.T) {
+	gsi := defaultSTLGSI()
+	file := append([]byte(nil), gsi...)
+	file = append(file, stlTTI(0, stlLastBlock, []byte{0, 0, 1, 0}, []byte{0, 0, 2, 0}, []byte("Hello"))...)
+	file = append(file, stlTTI(1, stlLastBlock, []byte{0, 0, 3, 30}, []byte{0, 0, 4, 0}, []byte("Bad frame"))...)
+	file = append(file, stlTTI(2, 0, []byte{0, 0, 5, 0}, []byte{0, 0, 6, 0}, []byte("Unterminated"))...)
+
+	got, diagnostics, err := (&STLParser{}).Parse(file)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(got) != 1 || got[0].Content != "Hello" {
+		t.Errorf("解析结果 = %+v", got)
+	}
+	var lines []int
+	for _, d := range diagnostics {
+		if d.Severity == models.SeverityError {
+			lines = append(lines, d.Line)
+		}
+	}
+	if !reflect.DeepEqual(lines, []int{2, 3}) {
+		t.Errorf("error级别诊断的块序号 = %v，期望 [2 3]（诊断信息 %+v）", lines, diagnostics)
+	}
+
+	if _, _, err := (&STLParser{}).Parse([]byte("not an stl file")); err == nil {
+		t.Error("过短的文件应返回错误")
+	}
+}
+
+func TestSTLValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []models.SubtitleEntry
+		want    []string // 每条诊断信息中应包含的文本
+	}{
+		{
+			name:    "Latin代码表可以表示",
+			entries: []models.SubtitleEntry{{Index: 1, Content: "<i>Ça va?</i> Straße ½"}},
+		},
+		{
+			name:    "汉字和符号无法表示",
+			entries: []models.SubtitleEntry{{Index: 1, Content: "Hello"}, {Index: 2, Content: "你好 ☃ 你"}},
+			want:    []string{"第2条字幕包含EBU STL字符代码表00无法表示的字符，已替换为?: 你 好 ☃"},
+		},
+		{
+			name:    "选择西里尔代码表后拉丁变音字母无法表示",
+			entries: []models.SubtitleEntry{{Index: 1, Content: "Привет"}, {Index: 2, Content: "Café"}},
+			want:    []string{"第2条字幕包含EBU STL字符代码表01无法表示的字符，已替换为?: é"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := (&STLParser{}).Validate(tt.entries)
+			if len(diagnostics) != len(tt.want) {
+				t.Fatalf("诊断信息 = %+v，期望 %d 条", diagnostics, len(tt.want))
+			}
+			for i, d := range diagnostics {
+				if d.Severity != models.SeverityWarning || d.Message != tt.want[i] {
+					t.Errorf("诊断信息 = %+v，期望 warning %q", d, tt.want[i])
+				}
+			}
+		})
+	}
+
+	factory := NewParserFactory()
+	if diagnostics := factory.Validate("out.stl", []models.SubtitleEntry{{Index: 1, Content: "你好"}}); len(diagnostics) != 1 {
+		t.Errorf("工厂未对.stl调用Validate: %+v", diagnostics)
+	}
+}
@@ -0,0 +1,596 @@
+package subtitle
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+)
+
+// TTML条目附加属性的键
+const (
+	AttrRegion = "region" // 显示区域
+	AttrStyle  = "style"  // 样式引用
+	AttrID     = "id"     // xml:id
+)
+
+// ttmlOpenEnd 缺少结束时间且无法从下一条推断时使用的显示时长
+const ttmlOpenEnd = 2 * time.Second
+
+// TTMLParser TTML/DFXP/IMSC1格式解析器
+// 解析后保留根元素和head原文，构建时沿用，保证样式、区域等元数据在往返中不丢失
+type TTMLParser struct {
+	doc *ttmlDocument
+}
+
+func (p *TTMLParser) Parse(data []byte) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	entries, diagnostics, doc, err := parseTTML(string(data))
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	p.doc = doc
+	return entries, diagnostics, nil
+}
+
+func (p *TTMLParser) Build(entries []models.SubtitleEntry, outputFormat string) []byte {
+	doc := p.doc
+	if doc == nil {
+		doc = defaultTTMLDocument()
+	}
+	return []byte(buildTTML(doc, entries))
+}
+
+func (p *TTMLParser) SupportedExtensions() []string {
+	return []string{".ttml", ".dfxp", ".xml"}
+}
+
+// ttmlDocument 构建时需要保留的文档结构
+type ttmlDocument struct {
+	root          string // <tt>开始标签原文
+	head          string // <head>...</head>原文
+	body          string // <body>开始标签原文
+	div           string // 第一个<div>开始标签原文
+	timing        ttmlTiming
+	useTicks      bool   // 原文使用tick计时，构建时保持一致
+	stylingPrefix string // 样式命名空间在原文中的前缀
+	prefix        string // TTML命名空间在原文元素名上的前缀（例如 <tt:tt> 中的tt），写出的元素使用同一前缀
+}
+
+// ttmlTiming 文档的计时参数
+type ttmlTiming struct {
+	frameRate    float64
+	subFrameRate float64
+	tickRate     float64
+}
+
+// ttmlScope body/div的计时和区域继承范围
+type ttmlScope struct {
+	begin  time.Duration
+	end    time.Duration // 0表示未指定
+	region string
+}
+
+// ttmlCue 正在解析的<p>
+type ttmlCue struct {
+	line   int
+	raw    string
+	begin  time.Duration
+	end    time.Duration // -1表示需要推断
+	attrs  map[string]string
+	text   strings.Builder
+	closes []string // 尚未闭合的span对应的结束标记
+}
+
+// ttmlStylingNS TTML样式命名空间
+const ttmlStylingNS = "http://www.w3.org/ns/ttml#styling"
+
+// defaultTTMLDocument 从其他格式转换时使用的IMSC1文本模板
+func defaultTTMLDocument() *ttmlDocument {
+	return &ttmlDocument{
+		root: `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" ttp:timeBase="media" ttp:profile="http://www.w3.org/ns/ttml/profile/imsc1/text">`,
+		head: `<head>
+    <styling>
+      <style xml:id="default" tts:color="white" tts:textAlign="center" tts:fontFamily="proportionalSansSerif"/>
+    </styling>
+    <layout>
+      <region xml:id="bottom" tts:origin="10% 10%" tts:extent="80% 80%" tts:displayAlign="after"/>
+    </layout>
+  </head>`,
+		body:          `<body style="default" region="bottom">`,
+		div:           `<div>`,
+		timing:        ttmlTiming{frameRate: 30, subFrameRate: 1, tickRate: 1},
+		stylingPrefix: "tts",
+	}
+}
+
+// parseTTML 解析TTML文档
+func parseTTML(content string) ([]models.SubtitleEntry, []models.Diagnostic, *ttmlDocument, error) {
+	var entries []models.SubtitleEntry
+	var diagnostics []models.Diagnostic
+	var ends []time.Duration
+
+	content = strings.TrimPrefix(content, "\uFEFF")
+	lineOf := func(offset int64) int {
+		return strings.Count(content[:offset], "\n") + 1
+	}
+
+	doc := &ttmlDocument{timing: ttmlTiming{frameRate: 30, subFrameRate: 1}}
+	tickRateSet := false
+	var scopes []ttmlScope
+	var cue *ttmlCue
+
+	dec := xml.NewDecoder(strings.NewReader(content))
+	dec.Entity = xml.HTMLEntity
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, diagnostics, nil, fmt.Errorf("解析TTML失败: %w", err)
+		}
+		raw := content[offset:dec.InputOffset()]
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if cue != nil {
+				switch t.Name.Local {
+				case "br":
+					cue.text.WriteString("\n")
+				case "span":
+					open, close := ttmlSpanTags(t, raw)
+					cue.text.WriteString(open)
+					cue.closes = append(cue.closes, close)
+				default:
+					// metadata、set等不属于正文
+					if err := dec.Skip(); err != nil {
+						return nil, diagnostics, nil, fmt.Errorf("解析TTML失败: %w", err)
+					}
+				}
+				continue
+			}
+
+			switch t.Name.Local {
+			case "tt":
+				doc.root = raw
+				doc.prefix = elementPrefix(raw)
+				multiplier := 1.0
+				for _, a := range t.Attr {
+					switch {
+					case a.Name.Space == "xmlns" && strings.HasSuffix(a.Value, "#styling"):
+						doc.stylingPrefix = a.Name.Local
+					case a.Name.Local == "frameRate":
+						doc.timing.frameRate = parsePositive(a.Value, doc.timing.frameRate)
+					case a.Name.Local == "subFrameRate":
+						doc.timing.subFrameRate = parsePositive(a.Value, doc.timing.subFrameRate)
+					case a.Name.Local == "frameRateMultiplier":
+						if f := strings.Fields(a.Value); len(f) == 2 {
+							num, den := parsePositive(f[0], 0), parsePositive(f[1], 0)
+							if num > 0 && den > 0 {
+								multiplier = num / den
+							}
+						}
+					case a.Name.Local == "tickRate":
+						doc.timing.tickRate = parsePositive(a.Value, 0)
+						tickRateSet = doc.timing.tickRate > 0
+					}
+				}
+				doc.timing.frameRate *= multiplier
+				if !tickRateSet {
+					doc.timing.tickRate = doc.timing.frameRate * doc.timing.subFrameRate
+				}
+			case "head":
+				if err := dec.Skip(); err != nil {
+					return nil, diagnostics, nil, fmt.Errorf("解析TTML失败: %w", err)
+				}
+				doc.head = content[offset:dec.InputOffset()]
+			case "body", "div":
+				if t.Name.Local == "body" && doc.body == "" {
+					doc.body = raw
+				} else if t.Name.Local == "div" && doc.div == "" {
+					doc.div = raw
+				}
+				scope := ttmlScope{}
+				if len(scopes) > 0 {
+					scope = scopes[len(scopes)-1]
+				}
+				begin, end, _, err := doc.timing.interval(t, scope)
+				if err != nil {
+					diagnostics = append(diagnostics, models.Diagnostic{
+						Line:     lineOf(offset),
+						Severity: models.SeverityWarning,
+						Message:  "忽略无效的时间属性: " + err.Error(),
+						Raw:      raw,
+					})
+				} else {
+					scope.begin, scope.end = begin, end
+				}
+				if region := attr(t, "", "region"); region != "" {
+					scope.region = region
+				}
+				scopes = append(scopes, scope)
+			case "p":
+				scope := ttmlScope{}
+				if len(scopes) > 0 {
+					scope = scopes[len(scopes)-1]
+				}
+				cue = &ttmlCue{line: lineOf(offset), raw: raw, attrs: map[string]string{}}
+				begin, end, ticks, err := doc.timing.interval(t, scope)
+				if err != nil {
+					cue.begin = -1
+					diagnostics = append(diagnostics, models.Diagnostic{
+						Line:     cue.line,
+						Severity: models.SeverityError,
+						Message:  "时间属性无效，已跳过该字幕: " + err.Error(),
+						Raw:      raw,
+					})
+				} else {
+					cue.begin, cue.end = begin, end
+					if end == 0 {
+						cue.end = -1
+					}
+					if ticks && len(entries) == 0 {
+						doc.useTicks = true
+					}
+				}
+				if region := attr(t, "", "region"); region != "" {
+					cue.attrs[AttrRegion] = region
+				} else if scope.region != "" {
+					cue.attrs[AttrRegion] = scope.region
+				}
+				if style := attr(t, "", "style"); style != "" {
+					cue.attrs[AttrStyle] = style
+				}
+				for _, a := range t.Attr {
+					if a.Name.Local == "id" {
+						cue.attrs[AttrID] = a.Value
+					}
+				}
+			}
+
+		case xml.EndElement:
+			if cue == nil {
+				if (t.Name.Local == "body" || t.Name.Local == "div") && len(scopes) > 0 {
+					scopes = scopes[:len(scopes)-1]
+				}
+				continue
+			}
+			switch t.Name.Local {
+			case "span":
+				if n := len(cue.closes); n > 0 {
+					cue.text.WriteString(cue.closes[n-1])
+					cue.closes = cue.closes[:n-1]
+				}
+			case "p":
+				text := normalizeTTMLText(cue.text.String())
+				switch {
+				case cue.begin < 0:
+					// 时间无效，已报告
+				case text == "":
+					diagnostics = append(diagnostics, models.Diagnostic{
+						Line:     cue.line,
+						Severity: models.SeverityWarning,
+						Message:  "字幕文本为空，已跳过",
+						Raw:      cue.raw,
+					})
+				default:
+					if cue.end < 0 {
+						diagnostics = append(diagnostics, models.Diagnostic{
+							Line:     cue.line,
+							Severity: models.SeverityWarning,
+							Message:  "缺少结束时间，已根据下一条字幕推断",
+							Raw:      cue.raw,
+						})
+					}
+					entry := models.SubtitleEntry{
+						Index:     len(entries) + 1,
+						TimeRange: utils.FormatSRTTimestamp(cue.begin),
+						Content:   text,
+					}
+					if len(cue.attrs) > 0 {
+						entry.Attributes = cue.attrs
+					}
+					entries = append(entries, entry)
+					ends = append(ends, cue.end)
+				}
+				cue = nil
+			}
+
+		case xml.CharData:
+			if cue != nil {
+				cue.text.WriteString(ttmlSpacePattern.ReplaceAllString(string(t), " "))
+			}
+		}
+	}
+
+	if doc.root == "" {
+		return nil, diagnostics, nil, fmt.Errorf("不是有效的TTML文档：缺少<tt>根元素")
+	}
+
+	// 补全时间范围，缺少结束时间的使用下一条的开始时间
+	for i := range entries {
+		begin, _ := utils.ParseTimestamp(entries[i].TimeRange)
+		end := ends[i]
+		if end < 0 {
+			end = begin + ttmlOpenEnd
+			if i+1 < len(entries) {
+				end, _ = utils.ParseTimestamp(entries[i+1].TimeRange)
+			}
+		}
+		entries[i].TimeRange += " --> " + utils.FormatSRTTimestamp(end)
+	}
+
+	return entries, diagnostics, doc, nil
+}
+
+// elementPrefix 返回开始标签原文中元素名的前缀，没有前缀时返回空字符串
+func elementPrefix(raw string) string {
+	name := strings.TrimPrefix(raw, "<")
+	if i := strings.IndexAny(name, " \t\r\n/>"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// attr 返回指定命名空间和名称的属性值
+func attr(e xml.StartElement, space, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// parsePositive 解析正数，失败时返回默认值
+func parsePositive(value string, fallback float64) float64 {
+	if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && f > 0 {
+		return f
+	}
+	return fallback
+}
+
+// interval 计算元素相对于父范围的开始和结束时间，end为0表示未指定
+// 返回值ticks表示开始时间是否使用tick计时
+func (t ttmlTiming) interval(e xml.StartElement, parent ttmlScope) (time.Duration, time.Duration, bool, error) {
+	begin, end := parent.begin, time.Duration(0)
+	ticks := false
+	if v := attr(e, "", "begin"); v != "" {
+		d, isTick, err := t.parse(v)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		begin, ticks = parent.begin+d, isTick
+	}
+	if v := attr(e, "", "end"); v != "" {
+		d, _, err := t.parse(v)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		end = parent.begin + d
+	} else if v := attr(e, "", "dur"); v != "" {
+		d, _, err := t.parse(v)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		end = begin + d
+	} else if parent.end > 0 {
+		end = parent.end
+	}
+	return begin, end, ticks, nil
+}
+
+// TTML时间表达式：时钟时间 HH:MM:SS.fff / HH:MM:SS:FF.sub 以及偏移时间 1.5s、100ms、25f、12345t
+var (
+	ttmlClockPattern  = regexp.MustCompile(`^(\d+):(\d{2}):(\d{2})(?:(\.\d+)|:(\d+)(?:\.(\d+))?)?$`)
+	ttmlOffsetPattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|ms|m|s|f|t)$`)
+)
+
+// parse 解析TTML时间表达式，返回是否为tick计时
+func (t ttmlTiming) parse(value string) (time.Duration, bool, error) {
+	value = strings.TrimSpace(value)
+	if m := ttmlClockPattern.FindStringSubmatch(value); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		min, _ := strconv.Atoi(m[2])
+		sec, _ := strconv.Atoi(m[3])
+		seconds := float64(h*3600 + min*60 + sec)
+		switch {
+		case m[4] != "":
+			frac, _ := strconv.ParseFloat("0"+m[4], 64)
+			seconds += frac
+		case m[5] != "":
+			frames, _ := strconv.ParseFloat(m[5], 64)
+			if m[6] != "" {
+				sub, _ := strconv.ParseFloat(m[6], 64)
+				frames += sub / t.subFrameRate
+			}
+			seconds += frames / t.frameRate
+		}
+		return secondsToDuration(seconds), false, nil
+	}
+	if m := ttmlOffsetPattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		switch m[2] {
+		case "h":
+			n *= 3600
+		case "m":
+			n *= 60
+		case "ms":
+			n /= 1000
+		case "f":
+			n /= t.frameRate
+		case "t":
+			return secondsToDuration(n / t.tickRate), true, nil
+		}
+		return secondsToDuration(n), false, nil
+	}
+	return 0, false, fmt.Errorf("无法识别的时间表达式: %s", value)
+}
+
+// secondsToDuration 将秒数换算为时长，精确到毫秒
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond
+}
+
+// ttmlSpanTags 将<span>转换为字幕内容中的标记
+// 只有斜体、粗体、下划线时转换为<i>/<b>/<u>，其余保留span原文，构建时原样写回
+func ttmlSpanTags(e xml.StartElement, raw string) (string, string) {
+	var open, close string
+	for _, a := range e.Attr {
+		switch {
+		case a.Name.Local == "fontStyle" && a.Value == "italic":
+			open, close = open+"<i>", "</i>"+close
+		case a.Name.Local == "fontWeight" && a.Value == "bold":
+			open, close = open+"<b>", "</b>"+close
+		case a.Name.Local == "textDecoration" && a.Value == "underline":
+			open, close = open+"<u>", "</u>"+close
+		default:
+			return raw, "</span>"
+		}
+	}
+	if open == "" {
+		return raw, "</span>"
+	}
+	return open, close
+}
+
+// ttmlSpacePattern 连续空白按XML默认规则折叠为一个空格
+var ttmlSpacePattern = regexp.MustCompile(`[ \t\r\n]+`)
+
+// normalizeTTMLText 去掉<br/>前后以及首尾的空格（文本中的空白在读取时已折叠）
+func normalizeTTMLText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// buildTTML 按文档结构写出TTML
+func buildTTML(doc *ttmlDocument, entries []models.SubtitleEntry) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(doc.root + "\n")
+	if doc.head != "" {
+		b.WriteString("  " + doc.head + "\n")
+	}
+	// 写出的字幕使用绝对时间，去掉body/div上的时间属性，避免再次叠加偏移
+	body, div := ttmlTimingAttrPattern.ReplaceAllString(doc.body, ""), ttmlTimingAttrPattern.ReplaceAllString(doc.div, "")
+	if body == "" {
+		body = "<" + doc.name("body") + ">"
+	}
+	if div == "" {
+		div = "<" + doc.name("div") + ">"
+	}
+	b.WriteString("  " + body + "\n")
+	b.WriteString("    " + div + "\n")
+
+	for _, entry := range entries {
+		tr, err := utils.ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			continue
+		}
+		b.WriteString("      <" + doc.name("p"))
+		if id := entry.Attributes[AttrID]; id != "" {
+			b.WriteString(` xml:id="` + escapeXMLAttr(id) + `"`)
+		}
+		b.WriteString(` begin="` + doc.formatTime(tr.Start) + `" end="` + doc.formatTime(tr.End) + `"`)
+		if region := entry.Attributes[AttrRegion]; region != "" {
+			b.WriteString(` region="` + escapeXMLAttr(region) + `"`)
+		}
+		if style := entry.Attributes[AttrStyle]; style != "" {
+			b.WriteString(` style="` + escapeXMLAttr(style) + `"`)
+		}
+		b.WriteString(">" + doc.formatContent(entry.Content) + "</" + doc.name("p") + ">\n")
+	}
+
+	b.WriteString("    </" + doc.name("div") + ">\n")
+	b.WriteString("  </" + doc.name("body") + ">\n")
+	b.WriteString("</" + doc.name("tt") + ">\n")
+	return b.String()
+}
+
+// ttmlTimingAttrPattern 匹配开始标签中的begin、end、dur属性
+var ttmlTimingAttrPattern = regexp.MustCompile(`\s+(?:begin|end|dur)\s*=\s*(?:"[^"]*"|'[^']*')`)
+
+// name 返回带原文前缀的元素名
+func (doc *ttmlDocument) name(local string) string {
+	if doc.prefix == "" {
+		return local
+	}
+	return doc.prefix + ":" + local
+}
+
+// formatTime 按原文的计时方式输出时间
+func (doc *ttmlDocument) formatTime(d time.Duration) string {
+	if doc.useTicks && doc.timing.tickRate > 0 {
+		return strconv.FormatInt(int64(math.Round(d.Seconds()*doc.timing.tickRate)), 10) + "t"
+	}
+	return utils.FormatVTTTimestamp(d)
+}
+
+// contentTagPattern 匹配字幕内容中的格式标记
+var contentTagPattern = regexp.MustCompile(`<[^>]+>`)
+
+// spanTagPattern 匹配可能带前缀的span开始标签的元素名部分
+var spanTagPattern = regexp.MustCompile(`^<(?:[\w.-]+:)?span\b`)
+
+// formatContent 将字幕内容转换为<p>的内容：换行转为<br/>，<i>/<b>/<u>转为带样式的span
+func (doc *ttmlDocument) formatContent(content string) string {
+	prefix, declare := doc.stylingPrefix, ""
+	if prefix == "" {
+		prefix, declare = "tts", ` xmlns:tts="`+ttmlStylingNS+`"`
+	}
+	span, br := doc.name("span"), "<"+doc.name("br")+"/>"
+	styled := map[string]string{
+		"<i>": `<` + span + declare + ` ` + prefix + `:fontStyle="italic">`,
+		"<b>": `<` + span + declare + ` ` + prefix + `:fontWeight="bold">`,
+		"<u>": `<` + span + declare + ` ` + prefix + `:textDecoration="underline">`,
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range contentTagPattern.FindAllStringIndex(content, -1) {
+		b.WriteString(escapeXMLText(content[last:loc[0]], br))
+		tag := content[loc[0]:loc[1]]
+		lower := strings.ToLower(tag)
+		switch {
+		case styled[lower] != "":
+			b.WriteString(styled[lower])
+		case lower == "</i>" || lower == "</b>" || lower == "</u>" || lower == "</span>":
+			b.WriteString("</" + span + ">")
+		case spanTagPattern.MatchString(tag):
+			// 解析时保留的span原文，元素名换成本文档的前缀
+			b.WriteString("<" + span + tag[len(spanTagPattern.FindString(tag)):])
+		}
+		// 其他格式的标记（例如<font>）在TTML中没有对应写法，直接去掉
+		last = loc[1]
+	}
+	b.WriteString(escapeXMLText(content[last:], br))
+	return b.String()
+}
+
+// escapeXMLText 转义文本并将换行转为br
+func escapeXMLText(text, br string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return strings.ReplaceAll(b.String(), "&#xA;", br)
+}
+
+// escapeXMLAttr 转义属性值
+func escapeXMLAttr(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
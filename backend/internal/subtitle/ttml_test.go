@@ -0,0 +1,131 @@
+package subtitle
+
+import (
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+const ttmlNS = "http://www.w3.org/ns/ttml"
+
+// roundTripTTML 解析、构建后再次解析，返回两次解析的结果和构建的文档
+func roundTripTTML(t *testing.T, input string) ([]models.SubtitleEntry, []models.SubtitleEntry, string) {
+	t.Helper()
+	p := &TTMLParser{}
+	first, _, err := p.Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	built := string(p.Build(first, "translated"))
+	second, _, err := (&TTMLParser{}).Parse([]byte(built))
+	if err != nil {
+		t.Fatalf("解析构建结果失败: %v\n%s", err, built)
+	}
+	return first, second, built
+}
+
+// checkTTMLNamespace 检查文档中的元素都在TTML命名空间内
+func checkTTMLNamespace(t *testing.T, doc string) {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("构建结果不是有效的XML: %v\n%s", err, doc)
+		}
+		if e, ok := tok.(xml.StartElement); ok && e.Name.Space != ttmlNS {
+			t.Errorf("元素<%s>的命名空间为%q，期望%q", e.Name.Local, e.Name.Space, ttmlNS)
+		}
+	}
+}
+
+func TestTTMLRoundTrip(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:frameRate="25">
+  <head>
+    <styling><style xml:id="s1" tts:color="yellow"/></styling>
+    <layout><region xml:id="top" tts:origin="10% 10%" tts:extent="80% 20%"/></layout>
+  </head>
+  <body region="top">
+    <div begin="1s">
+      <p xml:id="c1" begin="00:00:00:00" end="00:00:01:12" style="s1">Hello<br/><span tts:fontStyle="italic">world</span></p>
+      <p begin="2s" dur="1500ms">Fish &amp; chips</p>
+    </div>
+  </body>
+</tt>`
+	first, second, built := roundTripTTML(t, input)
+
+	want := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,480", Content: "Hello\n<i>world</i>", Attributes: map[string]string{AttrID: "c1", AttrStyle: "s1", AttrRegion: "top"}},
+		{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,500", Content: "Fish & chips", Attributes: map[string]string{AttrRegion: "top"}},
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("解析结果 = %+v，期望 %+v", first, want)
+	}
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("往返后 = %+v，期望 %+v", second, first)
+	}
+	if !strings.Contains(built, `<style xml:id="s1" tts:color="yellow"/>`) {
+		t.Errorf("构建结果没有保留head:\n%s", built)
+	}
+	checkTTMLNamespace(t, built)
+}
+
+func TestTTMLRoundTripPrefixed(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<tt:tt xmlns:tt="http://www.w3.org/ns/ttml" xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:tickRate="10000000">
+  <tt:head>
+    <tt:styling><tt:style xml:id="s1" tts:color="yellow"/></tt:styling>
+  </tt:head>
+  <tt:body>
+    <tt:div>
+      <tt:p xml:id="c1" begin="10000000t" end="25000000t" style="s1">Hello<tt:br/><tt:span tts:fontStyle="italic">world</tt:span></tt:p>
+      <tt:p begin="30000000t" end="40000000t"><tt:span tts:color="red">Red</tt:span> text</tt:p>
+    </tt:div>
+  </tt:body>
+</tt:tt>`
+	first, second, built := roundTripTTML(t, input)
+
+	want := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,500", Content: "Hello\n<i>world</i>", Attributes: map[string]string{AttrID: "c1", AttrStyle: "s1"}},
+		{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: `<tt:span tts:color="red">Red</span> text`},
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Errorf("解析结果 = %+v，期望 %+v", first, want)
+	}
+	if !reflect.DeepEqual(second, first) {
+		t.Errorf("往返后 = %+v，期望 %+v", second, first)
+	}
+	for _, tag := range []string{`<tt:p xml:id="c1" begin="10000000t" end="25000000t"`, "<tt:br/>", "</tt:span>", "</tt:div>", "</tt:body>", "</tt:tt>"} {
+		if !strings.Contains(built, tag) {
+			t.Errorf("构建结果缺少 %s:\n%s", tag, built)
+		}
+	}
+	checkTTMLNamespace(t, built)
+}
+
+func TestTTMLBuildFromOtherFormat(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "<b>Bold</b> <font color=\"red\">text</font>"},
+	}
+	built := string((&TTMLParser{}).Build(entries, "translated"))
+	checkTTMLNamespace(t, built)
+
+	got, _, err := (&TTMLParser{}).Parse([]byte(built))
+	if err != nil {
+		t.Fatalf("解析构建结果失败: %v", err)
+	}
+	want := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "<b>Bold</b> text", Attributes: map[string]string{AttrRegion: "bottom"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("解析结果 = %+v，期望 %+v", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package timing
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/utils"
+)
+
+// 支持的时间调整操作
+const (
+	OperationShift     = "shift"     // 整体平移
+	OperationScale     = "scale"     // 线性缩放
+	OperationFramerate = "framerate" // 帧率转换
+	OperationSync      = "sync"      // 两点线性同步
+)
+
+// Anchor 两点同步的锚点：将序号为Index的字幕开始时间对齐到Time
+type Anchor struct {
+	Index int
+	Time  time.Duration
+}
+
+// Apply 根据请求中的时间调整选项处理字幕条目
+func Apply(entries []models.SubtitleEntry, opts models.TimingOptions) ([]models.SubtitleEntry, error) {
+	switch strings.ToLower(opts.Operation) {
+	case OperationShift:
+		return Shift(entries, time.Duration(opts.OffsetMs)*time.Millisecond)
+	case OperationScale:
+		return Scale(entries, opts.Factor)
+	case OperationFramerate:
+		return ConvertFramerate(entries, opts.FromFPS, opts.ToFPS)
+	case OperationSync:
+		if opts.AnchorA == nil || opts.AnchorB == nil {
+			return nil, fmt.Errorf("两点同步需要提供anchorA和anchorB")
+		}
+		a, err := parseAnchor(*opts.AnchorA)
+		if err != nil {
+			return nil, err
+		}
+		b, err := parseAnchor(*opts.AnchorB)
+		if err != nil {
+			return nil, err
+		}
+		return Sync(entries, a, b)
+	default:
+		return nil, fmt.Errorf("不支持的时间调整操作: %s", opts.Operation)
+	}
+}
+
+// parseAnchor 将请求中的锚点转换为Anchor
+func parseAnchor(a models.SyncAnchor) (Anchor, error) {
+	t, err := utils.ParseTimestamp(a.Time)
+	if err != nil {
+		return Anchor{}, fmt.Errorf("锚点时间无效: %w", err)
+	}
+	return Anchor{Index: a.Index, Time: t}, nil
+}
+
+// Shift 将所有字幕整体平移offset，结果为负的时间截断为0
+func Shift(entries []models.SubtitleEntry, offset time.Duration) ([]models.SubtitleEntry, error) {
+	return transform(entries, func(t time.Duration) time.Duration {
+		return t + offset
+	})
+}
+
+// Scale 将所有时间乘以factor
+func Scale(entries []models.SubtitleEntry, factor float64) ([]models.SubtitleEntry, error) {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return nil, fmt.Errorf("缩放系数必须为正数: %v", factor)
+	}
+	return transform(entries, func(t time.Duration) time.Duration {
+		return scaleDuration(t, factor)
+	})
+}
+
+// ConvertFramerate 将按fromFPS制作的字幕转换为toFPS的视频时间轴
+// 例如 25fps(PAL加速版) 转 23.976fps 时，所有时间乘以 25/23.976
+func ConvertFramerate(entries []models.SubtitleEntry, fromFPS, toFPS float64) ([]models.SubtitleEntry, error) {
+	if fromFPS <= 0 || toFPS <= 0 {
+		return nil, fmt.Errorf("帧率必须为正数: %v -> %v", fromFPS, toFPS)
+	}
+	return Scale(entries, fromFPS/toFPS)
+}
+
+// Sync 两点线性同步：字幕a的开始时间映射到a.Time，字幕b的开始时间映射到b.Time，
+// 其余时间按这两点确定的线性关系换算
+func Sync(entries []models.SubtitleEntry, a, b Anchor) ([]models.SubtitleEntry, error) {
+	startA, err := startOf(entries, a.Index)
+	if err != nil {
+		return nil, err
+	}
+	startB, err := startOf(entries, b.Index)
+	if err != nil {
+		return nil, err
+	}
+	if startA == startB {
+		return nil, fmt.Errorf("两个锚点字幕的开始时间相同，无法计算线性同步")
+	}
+
+	factor := float64(b.Time-a.Time) / float64(startB-startA)
+	if factor <= 0 {
+		return nil, fmt.Errorf("锚点时间顺序与字幕顺序相反")
+	}
+
+	return transform(entries, func(t time.Duration) time.Duration {
+		return a.Time + scaleDuration(t-startA, factor)
+	})
+}
+
+// startOf 查找指定序号字幕的开始时间
+func startOf(entries []models.SubtitleEntry, index int) (time.Duration, error) {
+	for _, entry := range entries {
+		if entry.Index != index {
+			continue
+		}
+		tr, err := utils.ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			return 0, fmt.Errorf("第%d条字幕时间无效: %w", index, err)
+		}
+		return tr.Start, nil
+	}
+	return 0, fmt.Errorf("找不到序号为%d的字幕", index)
+}
+
+// transform 对每条字幕的开始和结束时间应用fn，返回新的条目切片
+func transform(entries []models.SubtitleEntry, fn func(time.Duration) time.Duration) ([]models.SubtitleEntry, error) {
+	result := make([]models.SubtitleEntry, len(entries))
+	for i, entry := range entries {
+		tr, err := utils.ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			return nil, fmt.Errorf("第%d条字幕时间无效: %w", entry.Index, err)
+		}
+
+		tr.Start = roundToMillis(fn(tr.Start))
+		tr.End = roundToMillis(fn(tr.End))
+		if tr.Start < 0 {
+			tr.Start = 0
+		}
+		if tr.End < 0 {
+			tr.End = 0
+		}
+
+		entry.TimeRange = tr.String()
+		result[i] = entry
+	}
+	return result, nil
+}
+
+// scaleDuration 按浮点系数缩放时长
+func scaleDuration(d time.Duration, factor float64) time.Duration {
+	return time.Duration(math.Round(float64(d) * factor))
+}
+
+// roundToMillis 四舍五入到毫秒
+func roundToMillis(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
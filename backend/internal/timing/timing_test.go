@@ -0,0 +1,115 @@
+package timing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// testEntries 三条字幕，开始时间分别为1秒、10秒和20秒
+func testEntries() []models.SubtitleEntry {
+	return []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,500", Content: "a"},
+		{Index: 2, TimeRange: "00:00:10,000 --> 00:00:12,000", Content: "b"},
+		{Index: 3, TimeRange: "00:00:20,000 --> 00:00:21,000", Content: "c"},
+	}
+}
+
+// timeRanges 返回条目的时间范围
+func timeRanges(entries []models.SubtitleEntry) []string {
+	ranges := make([]string, len(entries))
+	for i, entry := range entries {
+		ranges[i] = entry.TimeRange
+	}
+	return ranges
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		opts models.TimingOptions
+		want []string
+	}{
+		{
+			name: "平移",
+			opts: models.TimingOptions{Operation: "shift", OffsetMs: 1500},
+			want: []string{"00:00:02,500 --> 00:00:04,000", "00:00:11,500 --> 00:00:13,500", "00:00:21,500 --> 00:00:22,500"},
+		},
+		{
+			name: "向前平移时截断为0",
+			opts: models.TimingOptions{Operation: "shift", OffsetMs: -2000},
+			want: []string{"00:00:00,000 --> 00:00:00,500", "00:00:08,000 --> 00:00:10,000", "00:00:18,000 --> 00:00:19,000"},
+		},
+		{
+			name: "缩放",
+			opts: models.TimingOptions{Operation: "scale", Factor: 2},
+			want: []string{"00:00:02,000 --> 00:00:05,000", "00:00:20,000 --> 00:00:24,000", "00:00:40,000 --> 00:00:42,000"},
+		},
+		{
+			name: "帧率转换",
+			opts: models.TimingOptions{Operation: "framerate", FromFPS: 25, ToFPS: 23.976},
+			want: []string{"00:00:01,043 --> 00:00:02,607", "00:00:10,427 --> 00:00:12,513", "00:00:20,854 --> 00:00:21,897"},
+		},
+		{
+			name: "两点同步",
+			opts: models.TimingOptions{
+				Operation: "sync",
+				AnchorA:   &models.SyncAnchor{Index: 1, Time: "00:00:03,000"},
+				AnchorB:   &models.SyncAnchor{Index: 3, Time: "00:00:41,000"},
+			},
+			want: []string{"00:00:03,000 --> 00:00:06,000", "00:00:21,000 --> 00:00:25,000", "00:00:41,000 --> 00:00:43,000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := testEntries()
+			got, err := Apply(entries, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(timeRanges(got), "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(timeRanges(got), "\n"), strings.Join(tt.want, "\n"))
+			}
+			if entries[0].TimeRange != "00:00:01,000 --> 00:00:02,500" {
+				t.Error("不应修改输入的条目")
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts models.TimingOptions
+		want string
+	}{
+		{"未知操作", models.TimingOptions{Operation: "stretch"}, "不支持的时间调整操作"},
+		{"缩放系数为0", models.TimingOptions{Operation: "scale"}, "缩放系数必须为正数"},
+		{"帧率为负", models.TimingOptions{Operation: "framerate", FromFPS: 25, ToFPS: -1}, "帧率必须为正数"},
+		{"缺少锚点", models.TimingOptions{Operation: "sync", AnchorA: &models.SyncAnchor{Index: 1, Time: "00:00:01,000"}}, "anchorA和anchorB"},
+		{"锚点字幕不存在", models.TimingOptions{
+			Operation: "sync",
+			AnchorA:   &models.SyncAnchor{Index: 1, Time: "00:00:01,000"},
+			AnchorB:   &models.SyncAnchor{Index: 9, Time: "00:00:30,000"},
+		}, "找不到序号为9的字幕"},
+		{"锚点顺序相反", models.TimingOptions{
+			Operation: "sync",
+			AnchorA:   &models.SyncAnchor{Index: 1, Time: "00:00:30,000"},
+			AnchorB:   &models.SyncAnchor{Index: 3, Time: "00:00:01,000"},
+		}, "锚点时间顺序与字幕顺序相反"},
+		{"锚点时间无效", models.TimingOptions{
+			Operation: "sync",
+			AnchorA:   &models.SyncAnchor{Index: 1, Time: "soon"},
+			AnchorB:   &models.SyncAnchor{Index: 3, Time: "00:00:01,000"},
+		}, "锚点时间无效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply(testEntries(), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
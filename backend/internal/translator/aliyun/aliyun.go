@@ -1,95 +1,77 @@
 package aliyun
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	aliErrors "github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/alimt"
-	"log"
+	"log/slog"
+	"strconv"
 	"strings"
 	"time"
-)
 
-// 阿里云翻译限制：每秒最多50个请求，单次最大5000字符
-const (
-	maxRequestsPerSecond    = 50
-	maxCharactersPerRequest = 5000
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+	"github.com/frank0/subtitleTranslate/internal/ratelimit"
+	"github.com/frank0/subtitleTranslate/internal/retry"
 )
 
-// RateLimiter 实现简单的速率限制
-type RateLimiter struct {
-	tokens chan struct{}
-	ticker *time.Ticker
-	stopCh chan struct{}
-}
-
-// NewRateLimiter 创建新的速率限制器
-func NewRateLimiter() *RateLimiter {
-	rl := &RateLimiter{
-		tokens: make(chan struct{}, maxRequestsPerSecond),
-		ticker: time.NewTicker(time.Second / maxRequestsPerSecond),
-		stopCh: make(chan struct{}),
-	}
-
-	// 初始化令牌
-	for i := 0; i < maxRequestsPerSecond; i++ {
-		rl.tokens <- struct{}{}
-	}
-
-	// 启动令牌补充协程
-	go rl.refillTokens()
-
-	return rl
-}
-
-// refillTokens 定期补充令牌
-func (rl *RateLimiter) refillTokens() {
-	for {
-		select {
-		case <-rl.stopCh:
-			rl.ticker.Stop()
-			return
-		case <-rl.ticker.C:
-			select {
-			case rl.tokens <- struct{}{}:
-				// 成功添加令牌
-			default:
-				// 令牌桶已满，跳过
-			}
-		}
-	}
+// createClient 创建阿里云翻译客户端
+func createClient(accessKeyId, accessKeySecret, regionId string) (*alimt.Client, error) {
+	return alimt.NewClientWithAccessKey(regionId, accessKeyId, accessKeySecret)
 }
 
-// Wait 等待获取令牌
-func (rl *RateLimiter) Wait() error {
+// translateGeneral 发送翻译请求，ctx取消时立即返回
+// 阿里云SDK不支持context，取消后请求仍在后台完成，结果被丢弃
+func translateGeneral(ctx context.Context, client *alimt.Client, request *alimt.TranslateGeneralRequest) (*alimt.TranslateGeneralResponse, error) {
+	type result struct {
+		response *alimt.TranslateGeneralResponse
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := client.TranslateGeneral(request)
+		done <- result{response, err}
+	}()
 	select {
-	case <-rl.tokens:
-		return nil
-	case <-rl.stopCh:
-		return fmt.Errorf("rate limiter stopped")
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
 
-// Stop 停止速率限制器
-func (rl *RateLimiter) Stop() {
-	close(rl.stopCh)
+// retryableResponseCodes 可以重试的翻译结果错误码：10001 请求超时，10002 系统错误
+// 其余错误码（例如 10005 语言方向不支持、10009/10010 没有权限或未开通服务）不重试
+var retryableResponseCodes = map[int]bool{
+	10001: true,
+	10002: true,
 }
 
-// globalRateLimiter 全局速率限制器实例
-var globalRateLimiter = NewRateLimiter()
-
-// Cleanup 清理资源
-func Cleanup() {
-	if globalRateLimiter != nil {
-		globalRateLimiter.Stop()
+// classifySDKError 根据SDK错误标记err是否可以重试
+// 服务端的限流、5xx以及超时和网络错误可以重试，签名错误等其余错误不重试
+func classifySDKError(err, sdkErr error) error {
+	var serverErr *aliErrors.ServerError
+	if errors.As(sdkErr, &serverErr) {
+		code := serverErr.ErrorCode()
+		if retry.RetryableStatus(serverErr.HttpStatus()) || strings.HasPrefix(code, "Throttling") || code == "ServiceUnavailable" {
+			return retry.Retryable(err)
+		}
+		return retry.Permanent(err)
 	}
+	var clientErr *aliErrors.ClientError
+	if errors.As(sdkErr, &clientErr) {
+		if clientErr.ErrorCode() == aliErrors.TimeoutErrorCode {
+			return retry.Retryable(err)
+		}
+		return retry.Permanent(err)
+	}
+	return retry.Retryable(err)
 }
 
-// createClient 创建阿里云翻译客户端
-func createClient(accessKeyId, accessKeySecret, regionId string) (*alimt.Client, error) {
-	return alimt.NewClientWithAccessKey(regionId, accessKeyId, accessKeySecret)
-}
-
-// TranslateText 翻译单个文本
-func TranslateText(text, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId string) (string, error) {
+// TranslateText 翻译单个文本，ctx中的请求ID会记录在日志中
+// 返回的错误标记了是否可以重试，由调用方按重试策略处理
+func TranslateText(ctx context.Context, text, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId string) (string, error) {
 	// 参数验证
 	if text == "" {
 		return "", fmt.Errorf("翻译文本不能为空")
@@ -101,13 +83,11 @@ func TranslateText(text, targetLang, sourceLang, accessKeyId, accessKeySecret, r
 		return "", fmt.Errorf("阿里云API密钥未配置")
 	}
 
-	// 等待速率限制
-	if err := globalRateLimiter.Wait(); err != nil {
+	// 等待速率限制，每秒请求数由配置决定
+	if err := ratelimit.Wait(ctx, "aliyun"); err != nil {
 		return "", fmt.Errorf("等待速率限制失败: %w", err)
 	}
 
-	log.Printf("[阿里云翻译] 正在翻译文本，长度: %d 字符，源语言: %s，目标语言: %s", len(text), sourceLang, targetLang)
-
 	// 创建客户端
 	client, err := createClient(accessKeyId, accessKeySecret, regionId)
 	if err != nil {
@@ -124,37 +104,56 @@ func TranslateText(text, targetLang, sourceLang, accessKeyId, accessKeySecret, r
 	request.Scene = "general" // 通用场景
 
 	// 发送翻译请求
-	response, err := client.TranslateGeneral(request)
+	start := time.Now()
+	response, err := translateGeneral(ctx, client, request)
 	if err != nil {
-		log.Printf("[阿里云翻译] 翻译请求失败: %v", err)
-		return "", fmt.Errorf("翻译请求失败: %w", err)
+		code := "network"
+		if ctx.Err() != nil {
+			code = "canceled"
+		}
+		if sdkErr, ok := err.(interface{ ErrorCode() string }); ok && sdkErr.ErrorCode() != "" {
+			code = sdkErr.ErrorCode()
+		}
+		metrics.ObserveProviderCall("aliyun", start, code)
+		slog.WarnContext(ctx, "provider call failed", "provider", "aliyun", "code", code, "error", err, "latency", time.Since(start))
+		return "", classifySDKError(fmt.Errorf("翻译请求失败: %w", err), err)
 	}
 
 	// 检查响应
 	if response == nil {
+		metrics.ObserveProviderCall("aliyun", start, "empty_response")
 		return "", fmt.Errorf("翻译结果为空")
 	}
 
 	if response.Code != 200 {
+		metrics.ObserveProviderCall("aliyun", start, strconv.Itoa(response.Code))
 		errorMsg := response.Message
 		if errorMsg == "" {
 			errorMsg = "未知错误"
 		}
-		return "", fmt.Errorf("阿里云翻译API错误: %s - %s", response.Code, errorMsg)
+		slog.WarnContext(ctx, "provider call failed", "provider", "aliyun", "code", response.Code,
+			"message", errorMsg, "provider_request_id", response.RequestId, "latency", time.Since(start))
+		err := fmt.Errorf("阿里云翻译API错误: %d - %s", response.Code, errorMsg)
+		if retryableResponseCodes[response.Code] {
+			return "", retry.Retryable(err)
+		}
+		return "", retry.Permanent(err)
 	}
 
+	metrics.ObserveProviderCall("aliyun", start, "")
 	translatedText := response.Data.Translated
 
-	log.Printf("[阿里云翻译] 翻译成功，结果长度: %d 字符", len(translatedText))
+	slog.InfoContext(ctx, "provider call", "provider", "aliyun", "source", sourceLang, "target", targetLang,
+		"characters", len([]rune(text)), "result_characters", len([]rune(translatedText)), "latency", time.Since(start))
 	return translatedText, nil
 }
 
 // TranslateTexts 批量翻译文本（逐行翻译）
-func TranslateTexts(texts []string, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId string) ([]string, error) {
+func TranslateTexts(ctx context.Context, texts []string, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId string) ([]string, error) {
 	results := make([]string, len(texts))
 
 	for i, text := range texts {
-		translated, err := TranslateText(text, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId)
+		translated, err := TranslateText(ctx, text, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId)
 		if err != nil {
 			return nil, fmt.Errorf("翻译第%d个文本失败: %w", i+1, err)
 		}
@@ -165,9 +164,9 @@ func TranslateTexts(texts []string, targetLang, sourceLang, accessKeyId, accessK
 }
 
 // TranslateMergedText 翻译合并后的文本，并返回分割后的结果
-func TranslateMergedText(mergedText, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId string) ([]string, error) {
+func TranslateMergedText(ctx context.Context, mergedText, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId string) ([]string, error) {
 	// 翻译合并后的文本
-	translated, err := TranslateText(mergedText, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId)
+	translated, err := TranslateText(ctx, mergedText, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId)
 	if err != nil {
 		return nil, fmt.Errorf("翻译合并文本失败: %w", err)
 	}
@@ -182,16 +181,3 @@ func TranslateMergedText(mergedText, targetLang, sourceLang, accessKeyId, access
 
 	return translatedLines, nil
 }
-
-// TranslateTextsWithSettings 使用设置翻译文本（兼容现有接口）
-func TranslateTextsWithSettings(texts []string, targetLang, accessKeyId, accessKeySecret, sourceLang string) ([]string, error) {
-	// 检查必要的参数
-	if accessKeyId == "" || accessKeySecret == "" {
-		return nil, fmt.Errorf("阿里云API密钥未配置，请在设置中配置AccessKeyId和AccessKeySecret")
-	}
-
-	// 设置默认区域
-	regionId := "cn-hangzhou"
-
-	return TranslateTexts(texts, targetLang, sourceLang, accessKeyId, accessKeySecret, regionId)
-}
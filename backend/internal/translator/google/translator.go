@@ -2,12 +2,19 @@ package google
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/langdetect"
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+	"github.com/frank0/subtitleTranslate/internal/retry"
 )
 
 // TranslateRequest Google翻译请求结构
@@ -28,93 +35,95 @@ type TranslateResponse struct {
 	} `json:"data"`
 }
 
-// TranslateTexts 使用Google翻译多个文本
-func TranslateTexts(texts []string, targetLanguage string, sourceLanguage ...string) ([]string, error) {
+// DefaultTranslateURL Google Cloud Translation v2 的地址
+const DefaultTranslateURL = "https://translation.googleapis.com/language/translate/v2"
+
+// TranslateTexts 使用Google翻译多个文本，API密钥和地址从环境变量读取
+func TranslateTexts(ctx context.Context, texts []string, targetLanguage string, sourceLanguage ...string) ([]string, error) {
+	return TranslateTextsWithSettings(ctx, texts, targetLanguage, os.Getenv("GOOGLE_API_KEY"), os.Getenv("GOOGLE_TRANSLATE_URL"), sourceLanguage...)
+}
+
+// TranslateTextsWithSettings 使用指定的API密钥和地址翻译多个文本，ctx中的请求ID会记录在日志中
+// 返回的错误标记了是否可以重试，由调用方按重试策略处理
+func TranslateTextsWithSettings(ctx context.Context, texts []string, targetLanguage, apiKey, apiURL string, sourceLanguage ...string) ([]string, error) {
 	client := &http.Client{
 		Timeout: 30 * time.Second,
 	}
 
 	reqBody := TranslateRequest{
 		Q:      texts,
-		Target: mapLanguageCode(targetLanguage),
+		Target: targetLanguage,
 		Format: "text",
 	}
 
 	// 如果提供了源语言且不是自动检测
 	if len(sourceLanguage) > 0 && sourceLanguage[0] != "" && sourceLanguage[0] != "auto" {
-		reqBody.Source = mapLanguageCode(sourceLanguage[0])
+		reqBody.Source = sourceLanguage[0]
 	}
 
-	// 从环境变量获取API密钥
-	apiKey := os.Getenv("GOOGLE_API_KEY")
 	if apiKey == "" {
 		// 使用默认密钥（仅用于演示）
 		apiKey = "demo"
 	}
-	
-	// 从环境变量获取API URL
-	apiURL := os.Getenv("GOOGLE_TRANSLATE_URL")
+
 	if apiURL == "" {
-		// 使用默认URL
-		apiURL = "https://translation.googleapis.com/language/translate/v2"
+		apiURL = DefaultTranslateURL
 	}
-	
-	// 构建请求URL
-	url := fmt.Sprintf("%s?key=%s", apiURL, apiKey)
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("序列化请求失败: %w", err)
 	}
 
-	resp, err := client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建翻译请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// 密钥放在请求头中，不出现在URL里，避免随请求错误进入日志和返回给客户端的错误信息
+	req.Header.Set("X-Goog-Api-Key", apiKey)
+
+	start := time.Now()
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("请求翻译API失败: %w", err)
+		code := "network"
+		if ctx.Err() != nil {
+			code = "canceled"
+		}
+		metrics.ObserveProviderCall("google", start, code)
+		slog.WarnContext(ctx, "provider call failed", "provider", "google", "code", code, "error", err, "latency", time.Since(start))
+		return nil, retry.Retryable(fmt.Errorf("请求翻译API失败: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("翻译API返回错误: %s, 响应: %s", resp.Status, string(body))
+		metrics.ObserveProviderCall("google", start, strconv.Itoa(resp.StatusCode))
+		slog.WarnContext(ctx, "provider call failed", "provider", "google", "code", resp.StatusCode, "latency", time.Since(start))
+		// 408、429和5xx可以重试，并遵守 Retry-After；400（例如不支持的语言）和403（密钥无效）不重试
+		return nil, retry.HTTPError(fmt.Errorf("翻译API返回错误: %s, 响应: %s", resp.Status, string(body)), resp.StatusCode, resp.Header)
 	}
 
 	var translateResp TranslateResponse
 	if err := json.NewDecoder(resp.Body).Decode(&translateResp); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
+		metrics.ObserveProviderCall("google", start, "invalid_response")
+		slog.WarnContext(ctx, "provider call failed", "provider", "google", "code", "invalid_response", "error", err, "latency", time.Since(start))
+		return nil, retry.Retryable(fmt.Errorf("解析响应失败: %w", err))
 	}
-
 	var results []string
+	detected := ""
 	for _, translation := range translateResp.Data.Translations {
 		results = append(results, translation.TranslatedText)
+		// 未指定源语言时Google返回识别出的语言，记录下来在响应中与本地识别结果一并返回
+		if detected == "" {
+			detected = translation.DetectedSourceLanguage
+		}
 	}
+	langdetect.RecordProvider(ctx, detected)
 
-	return results, nil
-}
+	metrics.ObserveProviderCall("google", start, "")
+	slog.InfoContext(ctx, "provider call", "provider", "google", "source", reqBody.Source, "detected_source", detected,
+		"target", targetLanguage, "text_count", len(texts), "latency", time.Since(start))
 
-// mapLanguageCode 将通用语言代码映射到Google支持的语言代码
-func mapLanguageCode(language string) string {
-	// Google翻译支持的语言代码映射
-	languageMap := map[string]string{
-		"zh":    "zh",    // 中文
-		"zh-CN": "zh-CN", // 简体中文
-		"zh-TW": "zh-TW", // 繁体中文
-		"en":    "en",    // 英语
-		"ja":    "ja",    // 日语
-		"ko":    "ko",    // 韩语
-		"fr":    "fr",    // 法语
-		"de":    "de",    // 德语
-		"es":    "es",    // 西班牙语
-		"it":    "it",    // 意大利语
-		"ru":    "ru",    // 俄语
-		"pt":    "pt",    // 葡萄牙语
-		"ar":    "ar",    // 阿拉伯语
-		"th":    "th",    // 泰语
-		"vi":    "vi",    // 越南语
-	}
-
-	// 如果找到映射，返回映射后的代码，否则返回原始代码
-	if code, ok := languageMap[language]; ok {
-		return code
-	}
-	return language
+	return results, nil
 }
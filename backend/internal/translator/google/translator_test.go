@@ -0,0 +1,89 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/langdetect"
+	"github.com/frank0/subtitleTranslate/internal/retry"
+)
+
+func TestTranslateTextsCanceled(t *testing.T) {
+	// 服务端一直不响应，直到客户端断开
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	t.Setenv("GOOGLE_TRANSLATE_URL", server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := TranslateTexts(ctx, []string{"你好"}, "en", "zh")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("取消后%v才返回", elapsed)
+	}
+}
+
+func TestTranslateTextsThrottled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	t.Setenv("GOOGLE_TRANSLATE_URL", server.URL)
+
+	_, err := TranslateTexts(context.Background(), []string{"你好"}, "en", "zh")
+	retryable, retryAfter := retry.Classify(err)
+	if !retryable || retryAfter != 2*time.Second {
+		t.Fatalf("Classify(%v) = (%v, %v), want (true, 2s)", err, retryable, retryAfter)
+	}
+}
+
+func TestTranslateTextsSendsKeyInHeader(t *testing.T) {
+	var gotKey, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, gotQuery = r.Header.Get("X-Goog-Api-Key"), r.URL.RawQuery
+		w.Write([]byte(`{"data": {"translations": [{"translatedText": "hello"}]}}`))
+	}))
+	defer server.Close()
+
+	got, err := TranslateTextsWithSettings(context.Background(), []string{"你好"}, "en", "test-key", server.URL, "zh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "hello" {
+		t.Errorf("got %v", got)
+	}
+	if gotKey != "test-key" || gotQuery != "" {
+		t.Errorf("key=%q query=%q, 密钥应只出现在请求头中", gotKey, gotQuery)
+	}
+}
+
+func TestTranslateTextsRecordsDetectedLanguage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"translations": [{"translatedText": "hello", "detectedSourceLanguage": "zh-CN"}, {"translatedText": "bye", "detectedSourceLanguage": "ja"}]}}`))
+	}))
+	defer server.Close()
+
+	ctx := langdetect.WithProviderDetection(context.Background())
+	if _, err := TranslateTextsWithSettings(ctx, []string{"你好", "再见"}, "en", "test-key", server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if got := langdetect.ProviderLanguage(ctx); got != "zh-CN" {
+		t.Errorf("ProviderLanguage = %q，期望第一条的识别结果 zh-CN", got)
+	}
+}
@@ -3,93 +3,41 @@ package tencent
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+	"github.com/frank0/subtitleTranslate/internal/ratelimit"
+	"github.com/frank0/subtitleTranslate/internal/retry"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	tmt "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tmt/v20180321"
 )
 
-// 腾讯云翻译限制：每秒最多5个请求
-const maxRequestsPerSecond = 5
-
-// RateLimiter 实现简单的速率限制
-type RateLimiter struct {
-	tokens chan struct{}
-	ticker *time.Ticker
-	ctx    context.Context
-	cancel context.CancelFunc
-}
-
-// NewRateLimiter 创建新的速率限制器
-func NewRateLimiter() *RateLimiter {
-	ctx, cancel := context.WithCancel(context.Background())
-	rl := &RateLimiter{
-		tokens: make(chan struct{}, maxRequestsPerSecond),
-		ticker: time.NewTicker(time.Second / maxRequestsPerSecond),
-		ctx:    ctx,
-		cancel: cancel,
-	}
-
-	// 初始化令牌
-	for i := 0; i < maxRequestsPerSecond; i++ {
-		rl.tokens <- struct{}{}
-	}
-
-	// 启动令牌补充协程
-	go rl.refillTokens()
-
-	return rl
+// retryableCodes 可以重试的腾讯云错误码前缀：限流、服务内部错误和SDK的网络错误
+// 其余错误码（例如 AuthFailure、UnsupportedOperation、InvalidParameter）不重试
+var retryableCodes = []string{
+	"RequestLimitExceeded",
+	"InternalError",
+	"ClientError.NetworkError",
+	"ClientError.HttpStatusCodeError",
 }
 
-// refillTokens 定期补充令牌
-func (rl *RateLimiter) refillTokens() {
-	for {
-		select {
-		case <-rl.ctx.Done():
-			rl.ticker.Stop()
-			return
-		case <-rl.ticker.C:
-			select {
-			case rl.tokens <- struct{}{}:
-				// 成功添加令牌
-			default:
-				// 令牌桶已满，跳过
-			}
+// classify 根据腾讯云错误码标记错误是否可以重试
+func classify(err error, code string) error {
+	for _, prefix := range retryableCodes {
+		if strings.HasPrefix(code, prefix) {
+			return retry.Retryable(err)
 		}
 	}
+	return retry.Permanent(err)
 }
 
-// Wait 等待获取令牌
-func (rl *RateLimiter) Wait() error {
-	select {
-	case <-rl.tokens:
-		return nil
-	case <-rl.ctx.Done():
-		return fmt.Errorf("rate limiter stopped")
-	}
-}
-
-// Stop 停止速率限制器
-func (rl *RateLimiter) Stop() {
-	rl.cancel()
-}
-
-// globalRateLimiter 全局速率限制器实例
-var globalRateLimiter = NewRateLimiter()
-
-// Cleanup 清理资源
-func Cleanup() {
-	if globalRateLimiter != nil {
-		globalRateLimiter.Stop()
-	}
-}
-
-// TranslateText 翻译单个文本
-func TranslateText(text, targetLang, sourceLang, secretId, secretKey, region string) (string, error) {
+// TranslateText 翻译单个文本，ctx中的请求ID会记录在日志中
+// 返回的错误标记了是否可以重试，由调用方按重试策略处理
+func TranslateText(ctx context.Context, text, targetLang, sourceLang, secretId, secretKey, region string) (string, error) {
 	// 参数验证
 	if text == "" {
 		return "", fmt.Errorf("翻译文本不能为空")
@@ -101,23 +49,17 @@ func TranslateText(text, targetLang, sourceLang, secretId, secretKey, region str
 		return "", fmt.Errorf("源语言不能为空")
 	}
 
-	// 等待速率限制
-	if err := globalRateLimiter.Wait(); err != nil {
+	// 等待速率限制，每秒请求数由配置决定
+	if err := ratelimit.Wait(ctx, "tencent"); err != nil {
 		return "", fmt.Errorf("等待速率限制失败: %w", err)
 	}
 
-	log.Printf("[腾讯云翻译] 正在翻译文本，长度: %d 字符，源语言: %s，目标语言: %s", len(text), sourceLang, targetLang)
-
 	// 创建认证对象
 	credential := common.NewCredential(secretId, secretKey)
 
 	// 创建客户端配置
 	cpf := profile.NewClientProfile()
-	//if region != "" {
-	//	cpf.HttpProfile.Endpoint = fmt.Sprintf("tmt.%s.tencentcloudapi.com", region)
-	//} else {
 	cpf.HttpProfile.Endpoint = "tmt.tencentcloudapi.com"
-	//}
 
 	// 创建客户端
 	client, err := tmt.NewClient(credential, region, cpf)
@@ -133,31 +75,41 @@ func TranslateText(text, targetLang, sourceLang, secretId, secretKey, region str
 	request.ProjectId = common.Int64Ptr(0)
 
 	// 发送请求
-	response, err := client.TextTranslate(request)
+	start := time.Now()
+	response, err := client.TextTranslateWithContext(ctx, request)
 	if err != nil {
 		if tencentErr, ok := err.(*errors.TencentCloudSDKError); ok {
-			log.Printf("[腾讯云翻译] API错误，错误码: %s，错误信息: %s", tencentErr.GetCode(), tencentErr.GetMessage())
-			return "", fmt.Errorf("腾讯云翻译API错误: %s - %s", tencentErr.GetCode(), tencentErr.GetMessage())
+			metrics.ObserveProviderCall("tencent", start, tencentErr.GetCode())
+			slog.WarnContext(ctx, "provider call failed", "provider", "tencent", "code", tencentErr.GetCode(),
+				"message", tencentErr.GetMessage(), "provider_request_id", tencentErr.GetRequestId(), "latency", time.Since(start))
+			return "", classify(fmt.Errorf("腾讯云翻译API错误: %s - %s", tencentErr.GetCode(), tencentErr.GetMessage()), tencentErr.GetCode())
 		}
-		log.Printf("[腾讯云翻译] 翻译请求失败: %v", err)
-		return "", fmt.Errorf("翻译请求失败: %w", err)
+		code := "network"
+		if ctx.Err() != nil {
+			code = "canceled"
+		}
+		metrics.ObserveProviderCall("tencent", start, code)
+		slog.WarnContext(ctx, "provider call failed", "provider", "tencent", "code", code, "error", err, "latency", time.Since(start))
+		return "", retry.Retryable(fmt.Errorf("翻译请求失败: %w", err))
 	}
+	metrics.ObserveProviderCall("tencent", start, "")
 
 	if response.Response.TargetText == nil {
 		return "", fmt.Errorf("翻译结果为空")
 	}
 
-	log.Printf("[腾讯云翻译] 翻译成功，结果长度: %d 字符", len(*response.Response.TargetText))
+	slog.InfoContext(ctx, "provider call", "provider", "tencent", "source", sourceLang, "target", targetLang,
+		"characters", len([]rune(text)), "result_characters", len([]rune(*response.Response.TargetText)), "latency", time.Since(start))
 
 	return *response.Response.TargetText, nil
 }
 
 // TranslateTexts 批量翻译文本（逐行翻译）
-func TranslateTexts(texts []string, targetLang, sourceLang, secretId, secretKey, region string) ([]string, error) {
+func TranslateTexts(ctx context.Context, texts []string, targetLang, sourceLang, secretId, secretKey, region string) ([]string, error) {
 	results := make([]string, len(texts))
 
 	for i, text := range texts {
-		translated, err := TranslateText(text, targetLang, sourceLang, secretId, secretKey, region)
+		translated, err := TranslateText(ctx, text, targetLang, sourceLang, secretId, secretKey, region)
 		if err != nil {
 			return nil, fmt.Errorf("翻译第%d个文本失败: %w", i+1, err)
 		}
@@ -168,9 +120,9 @@ func TranslateTexts(texts []string, targetLang, sourceLang, secretId, secretKey,
 }
 
 // TranslateMergedText 翻译合并后的文本，并返回分割后的结果
-func TranslateMergedText(mergedText, targetLang, sourceLang, secretId, secretKey, region string) ([]string, error) {
+func TranslateMergedText(ctx context.Context, mergedText, targetLang, sourceLang, secretId, secretKey, region string) ([]string, error) {
 	// 翻译合并后的文本
-	translated, err := TranslateText(mergedText, targetLang, sourceLang, secretId, secretKey, region)
+	translated, err := TranslateText(ctx, mergedText, targetLang, sourceLang, secretId, secretKey, region)
 	if err != nil {
 		return nil, fmt.Errorf("翻译合并文本失败: %w", err)
 	}
@@ -185,16 +137,3 @@ func TranslateMergedText(mergedText, targetLang, sourceLang, secretId, secretKey
 
 	return translatedLines, nil
 }
-
-// TranslateTextsWithSettings 使用设置翻译文本（兼容现有接口）
-func TranslateTextsWithSettings(texts []string, targetLang, secretId, secretKey, sourceLang string) ([]string, error) {
-	// 检查必要的参数
-	if secretId == "" || secretKey == "" {
-		return nil, fmt.Errorf("腾讯云API密钥未配置，请在设置中配置SecretId和SecretKey")
-	}
-
-	// 设置默认区域
-	region := "ap-beijing"
-
-	return TranslateTexts(texts, targetLang, sourceLang, secretId, secretKey, region)
-}
@@ -0,0 +1,16 @@
+package tencent
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTranslateTextCanceledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := TranslateText(ctx, "你好", "en", "zh", "id", "key", "ap-beijing")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
@@ -1,14 +1,20 @@
 package volcengine
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/volcengine/volc-sdk-golang/base"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/metrics"
+	"github.com/frank0/subtitleTranslate/internal/retry"
 )
 
 // TranslateRequest 火山引擎翻译请求结构
@@ -90,6 +96,25 @@ func getClient(accessKey, secretKey string) *base.Client {
 	return client
 }
 
+// headerRecorder 记录最后一次HTTP响应的头
+// base.Client 的 CtxJson 不返回响应头，每次调用使用自己的recorder，复用SDK的连接池
+type headerRecorder struct {
+	next   http.RoundTripper
+	header http.Header
+}
+
+func (r *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := r.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err == nil {
+		r.header = resp.Header
+	}
+	return resp, err
+}
+
 // getEnvWithDefault 获取环境变量，不存在则返回默认值
 func getEnvWithDefault(key, defaultValue string) string {
 	if value := getEnv(key); value != "" {
@@ -104,111 +129,83 @@ func getEnv(key string) string {
 	return os.Getenv(key)
 }
 
-// TranslateTexts 使用火山引擎翻译多个文本，支持重试机制
-func TranslateTexts(texts []string, targetLanguage string, sourceLanguage ...string) ([]string, error) {
-	return TranslateTextsWithSettings(texts, targetLanguage, "", "", sourceLanguage...)
+// TranslateTexts 使用火山引擎翻译多个文本
+func TranslateTexts(ctx context.Context, texts []string, targetLanguage string, sourceLanguage ...string) ([]string, error) {
+	return TranslateTextsWithSettings(ctx, texts, targetLanguage, "", "", sourceLanguage...)
 }
 
-// TranslateTextsWithSettings 使用火山引擎翻译多个文本，支持自定义API设置，支持重试机制
-func TranslateTextsWithSettings(texts []string, targetLanguage, accessKey, secretKey string, sourceLanguage ...string) ([]string, error) {
+// TranslateTextsWithSettings 使用火山引擎翻译多个文本，支持自定义API设置
+// 返回的错误标记了是否可以重试，由调用方按重试策略处理；ctx中的请求ID会记录在日志中
+func TranslateTextsWithSettings(ctx context.Context, texts []string, targetLanguage, accessKey, secretKey string, sourceLanguage ...string) ([]string, error) {
 	if len(texts) == 0 {
 		return []string{}, nil
 	}
 
 	client := getClient(accessKey, secretKey)
+	// SDK只返回响应体和状态码，通过Transport取得响应头（例如429时的Retry-After）
+	recorder := &headerRecorder{next: client.Client.Transport}
+	client.Client = &http.Client{Transport: recorder}
 
 	req := Req{
-		TargetLanguage: mapLanguageCode(targetLanguage),
+		TargetLanguage: targetLanguage,
 		TextList:       texts,
 	}
 
 	// 如果提供了源语言且不是自动检测
 	if len(sourceLanguage) > 0 && sourceLanguage[0] != "" && sourceLanguage[0] != "auto" {
-		req.SourceLanguage = mapLanguageCode(sourceLanguage[0])
+		req.SourceLanguage = sourceLanguage[0]
 	}
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("序列化请求数据失败: %w", err)
 	}
 
-	// 重试配置
-	maxRetries := 3
-	retryDelay := time.Second
-
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			// 指数退避重试
-			time.Sleep(retryDelay * time.Duration(attempt))
-		}
-
-		resp, code, err := client.Json("TranslateText", nil, string(body))
-		if err != nil {
-			lastErr = fmt.Errorf("翻译请求失败: %w", err)
-			continue
-		}
-
-		if code != http.StatusOK {
-			lastErr = fmt.Errorf("翻译服务返回非200状态码: %d, 响应: %s", code, string(resp))
-			if code >= 500 && code < 600 {
-				// 服务器错误，可以重试
-				continue
-			}
-			return nil, lastErr
-		}
-
-		var response TranslateResponse
-		if err := json.Unmarshal([]byte(resp), &response); err != nil {
-			lastErr = fmt.Errorf("解析响应失败: %w", err)
-			continue
-		}
-
-		if response.ResponseMetadata.Error != "" {
-			lastErr = fmt.Errorf("翻译服务错误: %s", response.ResponseMetadata.Error)
-			// 业务错误不重试
-			return nil, lastErr
-		}
-
-		if len(response.TranslationList) != len(texts) {
-			lastErr = fmt.Errorf("翻译结果数量不匹配: 请求%d条，返回%d条", len(texts), len(response.TranslationList))
-			return nil, lastErr
-		}
-
-		translations := make([]string, len(response.TranslationList))
-		for i, translation := range response.TranslationList {
-			translations[i] = translation.Translation
-		}
-
-		return translations, nil
-	}
-
-	return nil, fmt.Errorf("翻译失败，重试%d次后仍无法完成: %w", maxRetries, lastErr)
-}
+	start := time.Now()
+	resp, code, err := client.CtxJson(ctx, "TranslateText", nil, string(body))
+	if ctx.Err() != nil {
+		metrics.ObserveProviderCall("volce", start, "canceled")
+		return nil, fmt.Errorf("翻译被取消: %w", ctx.Err())
+	}
+	if err != nil && code == 0 {
+		// 网络错误可以重试
+		metrics.ObserveProviderCall("volce", start, "network")
+		slog.WarnContext(ctx, "provider call failed", "provider", "volce", "code", "network", "error", err, "latency", time.Since(start))
+		return nil, retry.Retryable(fmt.Errorf("翻译请求失败: %w", err))
+	}
+
+	if code != http.StatusOK {
+		metrics.ObserveProviderCall("volce", start, strconv.Itoa(code))
+		slog.WarnContext(ctx, "provider call failed", "provider", "volce", "code", code, "latency", time.Since(start))
+		// 5xx和429可以重试，其余状态码（例如认证失败）不重试
+		return nil, retry.HTTPError(fmt.Errorf("翻译服务返回非200状态码: %d, 响应: %s", code, string(resp)), code, recorder.header)
+	}
+
+	var response TranslateResponse
+	if err := json.Unmarshal([]byte(resp), &response); err != nil {
+		metrics.ObserveProviderCall("volce", start, "invalid_response")
+		slog.WarnContext(ctx, "provider call failed", "provider", "volce", "code", "invalid_response", "error", err, "latency", time.Since(start))
+		return nil, retry.Retryable(fmt.Errorf("解析响应失败: %w", err))
+	}
+
+	if response.ResponseMetadata.Error != "" {
+		metrics.ObserveProviderCall("volce", start, "service_error")
+		slog.WarnContext(ctx, "provider call failed", "provider", "volce", "code", "service_error", "message", response.ResponseMetadata.Error,
+			"provider_request_id", response.ResponseMetadata.RequestID, "latency", time.Since(start))
+		// 业务错误不重试
+		return nil, retry.Permanent(fmt.Errorf("翻译服务错误: %s", response.ResponseMetadata.Error))
+	}
+
+	metrics.ObserveProviderCall("volce", start, "")
+	slog.InfoContext(ctx, "provider call", "provider", "volce", "source", req.SourceLanguage, "target", targetLanguage,
+		"text_count", len(texts), "provider_request_id", response.ResponseMetadata.RequestID, "latency", time.Since(start))
+	if len(response.TranslationList) != len(texts) {
+		return nil, fmt.Errorf("翻译结果数量不匹配: 请求%d条，返回%d条", len(texts), len(response.TranslationList))
+	}
+
+	translations := make([]string, len(response.TranslationList))
+	for i, translation := range response.TranslationList {
+		translations[i] = translation.Translation
+	}
 
-// mapLanguageCode 将通用语言代码映射到火山引擎支持的语言代码
-func mapLanguageCode(language string) string {
-	// 火山引擎支持的语言代码映射
-	languageMap := map[string]string{
-		"zh":    "zh",      // 中文
-		"zh-CN": "zh",      // 简体中文
-		"zh-TW": "zh-Hant", // 繁体中文
-		"en":    "en",      // 英语
-		"ja":    "ja",      // 日语
-		"ko":    "ko",      // 韩语
-		"fr":    "fr",      // 法语
-		"de":    "de",      // 德语
-		"es":    "es",      // 西班牙语
-		"it":    "it",      // 意大利语
-		"ru":    "ru",      // 俄语
-		"pt":    "pt",      // 葡萄牙语
-		"ar":    "ar",      // 阿拉伯语
-		"th":    "th",      // 泰语
-		"vi":    "vi",      // 越南语
-	}
-
-	// 如果找到映射，返回映射后的代码，否则返回原始代码
-	if code, ok := languageMap[language]; ok {
-		return code
-	}
-	return language
+	return translations, nil
 }
@@ -0,0 +1,51 @@
+package volcengine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/retry"
+)
+
+func TestTranslateTextsHTTPError(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		retryAfter     string
+		wantRetryable  bool
+		wantRetryAfter time.Duration
+	}{
+		{name: "限流时使用Retry-After", status: http.StatusTooManyRequests, retryAfter: "7", wantRetryable: true, wantRetryAfter: 7 * time.Second},
+		{name: "限流但没有Retry-After", status: http.StatusTooManyRequests, wantRetryable: true},
+		{name: "认证失败不重试", status: http.StatusUnauthorized, retryAfter: "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"ResponseMetadata":{"Error":"throttled"}}`))
+			}))
+			defer server.Close()
+
+			host := ServiceInfo.Host
+			ServiceInfo.Host = strings.TrimPrefix(server.URL, "http://")
+			defer func() { ServiceInfo.Host = host }()
+
+			_, err := TranslateTextsWithSettings(context.Background(), []string{"你好"}, "en", "ak-test", "sk-test", "zh")
+			if err == nil {
+				t.Fatal("期望返回错误")
+			}
+			retryable, retryAfter := retry.Classify(err)
+			if retryable != tt.wantRetryable || retryAfter != tt.wantRetryAfter {
+				t.Errorf("Classify = %v, %v，期望 %v, %v（错误 %v）", retryable, retryAfter, tt.wantRetryable, tt.wantRetryAfter, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package usage
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/frank0/subtitleTranslate/config"
+)
+
+// WriteCSV 将用量记录导出为CSV，费用按实际发送的字符数和配置的价格计算
+func WriteCSV(w io.Writer, records []Record, prices map[string]config.Price) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"day", "client", "provider", "requests", "characters", "sent", "cost", "currency"}); err != nil {
+		return err
+	}
+	for _, r := range records {
+		cost, currency := "", ""
+		if price, ok := prices[r.Provider]; ok {
+			cost = strconv.FormatFloat(Cost(r.Sent, price), 'f', 4, 64)
+			currency = price.Currency
+		}
+		row := []string{
+			r.Day,
+			r.Client,
+			r.Provider,
+			strconv.FormatInt(r.Requests, 10),
+			strconv.FormatInt(r.Characters, 10),
+			strconv.FormatInt(r.Sent, 10),
+			cost,
+			currency,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
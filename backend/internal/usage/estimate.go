@@ -0,0 +1,56 @@
+package usage
+
+import (
+	"math"
+	"unicode/utf8"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/models"
+	"github.com/frank0/subtitleTranslate/internal/services"
+)
+
+// Estimate 估算翻译这些文本的计费字符数和各提供商的费用
+// 重复的字幕只翻译一次，空白字幕不翻译；项目没有翻译缓存和术语表，
+// 节省的字符数只来自同一文件内的重复字幕，即 DuplicateCharacters
+func Estimate(texts []string, providers []string, prices map[string]config.Price) models.CostEstimate {
+	unique, positions := services.Unique(texts)
+	estimate := models.CostEstimate{
+		Cues:      len(texts),
+		Providers: []models.ProviderEstimate{},
+	}
+	var uniqueCharacters int64
+	for _, text := range unique {
+		uniqueCharacters += int64(utf8.RuneCountInString(text))
+	}
+	var blankCharacters int64
+	for i, text := range texts {
+		n := int64(utf8.RuneCountInString(text))
+		estimate.SourceCharacters += n
+		if positions[i] < 0 {
+			estimate.BlankCues++
+			blankCharacters += n
+		}
+	}
+	// 空白字幕的字符数不计入去重节省
+	estimate.DuplicateCharacters = estimate.SourceCharacters - blankCharacters - uniqueCharacters
+
+	for _, provider := range providers {
+		pe := models.ProviderEstimate{
+			Provider:           provider,
+			BillableCharacters: services.BillableCharacters(provider, unique),
+		}
+		if price, ok := prices[provider]; ok {
+			cost := Cost(pe.BillableCharacters, price)
+			pe.PerMillionCharacters = price.PerMillionCharacters
+			pe.Currency = price.Currency
+			pe.Cost = &cost
+		}
+		estimate.Providers = append(estimate.Providers, pe)
+	}
+	return estimate
+}
+
+// Cost 按每百万字符价格计算费用，保留四位小数
+func Cost(characters int64, price config.Price) float64 {
+	return math.Round(float64(characters)*price.PerMillionCharacters/1e6*1e4) / 1e4
+}
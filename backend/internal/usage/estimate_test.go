@@ -0,0 +1,54 @@
+package usage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestEstimate(t *testing.T) {
+	texts := []string{"Hello", "Hi", "Hello", " ", ""}
+	prices := map[string]config.Price{"google": {PerMillionCharacters: 20000, Currency: "USD"}}
+	got := Estimate(texts, []string{"google", "aliyun"}, prices)
+
+	googleCost := 0.14
+	want := models.CostEstimate{
+		Cues:             5,
+		SourceCharacters: 13,
+		// 重复的"Hello"节省5字符，空白字幕不计入
+		DuplicateCharacters: 5,
+		BlankCues:           2,
+		Providers: []models.ProviderEstimate{
+			{Provider: "google", BillableCharacters: 7, PerMillionCharacters: 20000, Currency: "USD", Cost: &googleCost},
+			// 阿里云合并短字幕发送，每个分隔符计1字符；未配置价格时没有费用
+			{Provider: "aliyun", BillableCharacters: 8},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Estimate = %+v，期望 %+v", got, want)
+	}
+
+	if empty := Estimate(nil, nil, nil); empty.Providers == nil || empty.SourceCharacters != 0 {
+		t.Errorf("空文件的估算 = %+v", empty)
+	}
+}
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		characters int64
+		price      float64
+		want       float64
+	}{
+		{1_000_000, 20, 20},
+		{1_500_000, 58, 87},
+		{1234, 20, 0.0247},
+		{0, 20, 0},
+	}
+	for _, tt := range tests {
+		if got := Cost(tt.characters, config.Price{PerMillionCharacters: tt.price}); got != tt.want {
+			t.Errorf("Cost(%d, %v) = %v，期望 %v", tt.characters, tt.price, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dayLayout 用量记录的日期格式（UTC）
+const dayLayout = "2006-01-02"
+
+// Record 某个客户端某天在某个提供商上的用量
+type Record struct {
+	Day        string `json:"day"`        // 日期（UTC），格式 2006-01-02
+	Client     string `json:"client"`     // 客户端标识：API密钥ID或 ip:地址
+	Provider   string `json:"provider"`   // 翻译提供商
+	Requests   int64  `json:"requests"`   // 请求次数
+	Characters int64  `json:"characters"` // 计入配额的源文字符数
+	Sent       int64  `json:"sent"`       // 实际发送给提供商的字符数（去重后，翻译成功时记录）
+}
+
+// recordKey 用量记录的唯一键
+type recordKey struct {
+	day, client, provider string
+}
+
+// Store 按天记录用量，保存在JSON文件中，并发安全
+// 记录只更新内存，由 Flush 或 FlushEvery 写入文件，避免每个请求都重写整个文件；path为空时只保存在内存中
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	records map[recordKey]*Record
+	dirty   bool // 内存中有尚未写入文件的记录
+
+	flushMu sync.Mutex // 保证写入文件的顺序，旧的快照不会覆盖新的
+}
+
+// storeFile 用量文件结构
+type storeFile struct {
+	Records []Record `json:"records"`
+}
+
+// Open 打开用量文件，文件不存在时创建空的记录
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, records: make(map[recordKey]*Record)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取用量文件失败: %w", err)
+	}
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析用量文件失败: %w", err)
+	}
+	for i := range file.Records {
+		r := file.Records[i]
+		s.records[recordKey{r.Day, r.Client, r.Provider}] = &r
+	}
+	return s, nil
+}
+
+// Day 返回时间对应的日期（UTC）
+func Day(t time.Time) string {
+	return t.UTC().Format(dayLayout)
+}
+
+// Add 记录一次请求的用量
+func (s *Store) Add(t time.Time, client, provider string, characters int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := recordKey{Day(t), client, provider}
+	r, ok := s.records[key]
+	if !ok {
+		r = &Record{Day: key.day, Client: client, Provider: provider}
+		s.records[key] = r
+	}
+	r.Requests++
+	r.Characters += characters
+	s.dirty = true
+}
+
+// AddSent 记录实际发送给提供商的字符数
+func (s *Store) AddSent(t time.Time, client, provider string, characters int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := recordKey{Day(t), client, provider}
+	r, ok := s.records[key]
+	if !ok {
+		r = &Record{Day: key.day, Client: client, Provider: provider}
+		s.records[key] = r
+	}
+	r.Sent += characters
+	s.dirty = true
+}
+
+// Characters 返回客户端在 [from, to] 日期范围内（含两端）计入配额的字符数
+func (s *Store) Characters(client, from, to string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var total int64
+	for key, r := range s.records {
+		if key.client == client && key.day >= from && key.day <= to {
+			total += r.Characters
+		}
+	}
+	return total
+}
+
+// Records 返回满足条件的用量记录，按日期、客户端、提供商排序
+// client为空时返回所有客户端；from/to为空时不限制日期
+func (s *Store) Records(client, from, to string) []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var records []Record
+	for key, r := range s.records {
+		if client != "" && key.client != client {
+			continue
+		}
+		if (from != "" && key.day < from) || (to != "" && key.day > to) {
+			continue
+		}
+		records = append(records, *r)
+	}
+	sortRecords(records)
+	return records
+}
+
+// sortRecords 按日期、客户端、提供商排序
+func sortRecords(records []Record) {
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Client != b.Client {
+			return a.Client < b.Client
+		}
+		return a.Provider < b.Provider
+	})
+}
+
+// FlushEvery 每隔interval把用量记录写入文件，ctx取消时写入最后一次后返回
+func (s *Store) FlushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if err := s.Flush(); err != nil {
+				slog.Error("failed to save usage", "error", err)
+			}
+			return
+		}
+		if err := s.Flush(); err != nil {
+			slog.Error("failed to save usage", "error", err)
+		}
+	}
+}
+
+// Flush 把内存中的用量记录原子地写入文件，没有新记录时不写入
+// 写文件时不持有记录的锁，不会阻塞 Add 和查询
+func (s *Store) Flush() error {
+	if s.path == "" {
+		return nil
+	}
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, *r)
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.write(records); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write 原子地写入用量文件
+func (s *Store) write(records []Record) error {
+	sortRecords(records)
+	data, err := json.MarshalIndent(storeFile{Records: records}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化用量记录失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建用量文件目录失败: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("写入用量文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("写入用量文件失败: %w", err)
+	}
+	return nil
+}
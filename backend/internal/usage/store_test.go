@@ -0,0 +1,153 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/config"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "usage.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("打开不存在的用量文件失败: %v", err)
+	}
+
+	day1 := time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)
+	// 东八区3月2日07:30，按UTC仍记在3月1日
+	day1Local := time.Date(2024, 3, 2, 7, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	day2 := time.Date(2024, 3, 2, 1, 0, 0, 0, time.UTC)
+	for _, add := range []struct {
+		t          time.Time
+		client     string
+		provider   string
+		characters int64
+	}{
+		{day1, "key_a", "google", 100},
+		{day1Local, "key_a", "google", 50},
+		{day1, "key_a", "aliyun", 10},
+		{day2, "key_a", "google", 7},
+		{day2, "ip:10.0.0.1", "google", 3},
+	} {
+		store.Add(add.t, add.client, add.provider, add.characters)
+	}
+	store.AddSent(day1, "key_a", "google", 120)
+
+	tests := []struct {
+		client, from, to string
+		want             int64
+	}{
+		{"key_a", "2024-03-01", "2024-03-01", 160},
+		{"key_a", "2024-03-01", "2024-03-02", 167},
+		{"key_a", "2024-03-02", "2024-03-31", 7},
+		{"ip:10.0.0.1", "2024-03-01", "2024-03-31", 3},
+		{"key_b", "2024-03-01", "2024-03-31", 0},
+	}
+	for _, tt := range tests {
+		if got := store.Characters(tt.client, tt.from, tt.to); got != tt.want {
+			t.Errorf("Characters(%s, %s, %s) = %d，期望 %d", tt.client, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	// 写入文件后重新打开，记录不变，并按日期、客户端、提供商排序
+	if err := store.Flush(); err != nil {
+		t.Fatalf("写入用量文件失败: %v", err)
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("重新打开用量文件失败: %v", err)
+	}
+	want := []Record{
+		{Day: "2024-03-01", Client: "key_a", Provider: "aliyun", Requests: 1, Characters: 10},
+		{Day: "2024-03-01", Client: "key_a", Provider: "google", Requests: 2, Characters: 150, Sent: 120},
+		{Day: "2024-03-02", Client: "ip:10.0.0.1", Provider: "google", Requests: 1, Characters: 3},
+		{Day: "2024-03-02", Client: "key_a", Provider: "google", Requests: 1, Characters: 7},
+	}
+	if got := reopened.Records("", "", ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("Records = %+v，期望 %+v", got, want)
+	}
+	if got := reopened.Records("key_a", "2024-03-02", ""); !reflect.DeepEqual(got, want[3:]) {
+		t.Errorf("按客户端和日期过滤 = %+v，期望 %+v", got, want[3:])
+	}
+}
+
+func TestFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// 记录只更新内存，Flush之前不写文件
+	store.Add(day, "key_a", "google", 10)
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Flush之前不应写入文件: %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Flush后应写入文件: %v", err)
+	}
+
+	// 没有新记录时不重写文件
+	os.Chtimes(path, day, day)
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ = os.Stat(path); !info.ModTime().Equal(day) {
+		t.Error("没有新记录时不应重写文件")
+	}
+
+	// ctx取消时FlushEvery写入最后的记录后返回
+	store.AddSent(day, "key_a", "google", 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		store.FlushEvery(ctx, time.Hour)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ctx取消后FlushEvery没有返回")
+	}
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Record{{Day: "2024-03-01", Client: "key_a", Provider: "google", Requests: 1, Characters: 10, Sent: 8}}
+	if got := reopened.Records("", "", ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("关闭时写入的记录 = %+v，期望 %+v", got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	records := []Record{
+		{Day: "2024-03-01", Client: "key_a", Provider: "google", Requests: 2, Characters: 150, Sent: 120},
+		{Day: "2024-03-01", Client: "key_a", Provider: "tencent", Requests: 1, Characters: 10, Sent: 10},
+	}
+	prices := map[string]config.Price{"google": {PerMillionCharacters: 20000, Currency: "USD"}}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records, prices); err != nil {
+		t.Fatalf("导出CSV失败: %v", err)
+	}
+	// 费用按实际发送的字符数计算，未配置价格的提供商费用为空
+	want := "day,client,provider,requests,characters,sent,cost,currency\n" +
+		"2024-03-01,key_a,google,2,150,120,2.4000,USD\n" +
+		"2024-03-01,key_a,tencent,1,10,10,,\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\n期望\n%s", buf.String(), want)
+	}
+}
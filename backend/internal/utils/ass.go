@@ -10,20 +10,26 @@ import (
 	"github.com/frank0/subtitleTranslate/internal/models"
 )
 
-// ParseASS 解析ASS格式的字幕文件内容
-func ParseASS(content string) ([]models.SubtitleEntry, error) {
+// ParseASS 解析ASS格式的字幕文件内容，返回被跳过的行的诊断信息
+func ParseASS(content string) ([]models.SubtitleEntry, []models.Diagnostic, error) {
 	var entries []models.SubtitleEntry
+	var diagnostics []models.Diagnostic
 	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
 	// 跳过头部信息，直接找到[Events]部分
 	var inEventsSection bool
 	var lineIndex int
+	lineNumber := 0
 
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		lineNumber++
+		raw := scanner.Text()
+		line := strings.TrimSpace(strings.TrimPrefix(raw, "\uFEFF"))
+		lower := strings.ToLower(line)
 
-		if strings.HasPrefix(strings.ToLower(line), "[events]") {
-			inEventsSection = true
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inEventsSection = lower == "[events]"
 			continue
 		}
 
@@ -31,44 +37,74 @@ func ParseASS(content string) ([]models.SubtitleEntry, error) {
 			continue
 		}
 
-		// 跳过格式行和注释
-		if strings.HasPrefix(strings.ToLower(line), "format:") || strings.HasPrefix(line, ";") || line == "" {
+		// 跳过格式行、注释行和被注释掉的事件
+		if strings.HasPrefix(lower, "format:") || strings.HasPrefix(lower, "comment:") || strings.HasPrefix(line, ";") || line == "" {
 			continue
 		}
 
 		// 解析Dialogue行
-		if strings.HasPrefix(strings.ToLower(line), "dialogue:") {
+		if strings.HasPrefix(lower, "dialogue:") {
 			entry, err := parseASSDialogue(line, lineIndex+1)
 			if err != nil {
-				continue // 跳过解析失败的行
+				diagnostics = append(diagnostics, models.Diagnostic{
+					Line:     lineNumber,
+					Severity: models.SeverityError,
+					Message:  "Dialogue行解析失败，已跳过: " + err.Error(),
+					Raw:      raw,
+				})
+				continue
 			}
 			entries = append(entries, *entry)
 			lineIndex++
+			continue
 		}
+
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     lineNumber,
+			Severity: models.SeverityWarning,
+			Message:  "跳过[Events]中无法识别的行",
+			Raw:      raw,
+		})
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("解析ASS文件失败: %w", err)
+		return nil, diagnostics, fmt.Errorf("解析ASS文件失败: %w", err)
+	}
+
+	if lineNumber > 0 && !strings.Contains(strings.ToLower(content), "[events]") {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     1,
+			Severity: models.SeverityWarning,
+			Message:  "未找到[Events]部分",
+		})
 	}
 
-	return entries, nil
+	return entries, diagnostics, nil
 }
 
 // parseASSDialogue 解析ASS格式的Dialogue行
 func parseASSDialogue(line string, index int) (*models.SubtitleEntry, error) {
-	// 移除"Dialogue:"前缀（不区分大小写）
-	line = strings.TrimPrefix(strings.ToLower(line), "dialogue:")
-	line = strings.TrimSpace(line)
+	// 移除"Dialogue:"前缀（不区分大小写），保留正文的大小写
+	line = strings.TrimSpace(line[len("dialogue:"):])
 
 	// 按逗号分割，但需要考虑引号内的逗号
 	parts := splitASSLine(line)
+	if len(parts) == 9 && strings.HasSuffix(line, ",") {
+		parts = append(parts, "") // 正文为空
+	}
 	if len(parts) < 10 {
-		return nil, fmt.Errorf("ASS Dialogue格式不正确")
+		return nil, fmt.Errorf("字段数为%d，至少需要10个", len(parts))
 	}
 
 	// 提取时间和文本内容
 	startTime := parts[1]
 	endTime := parts[2]
+	if _, err := ParseTimestamp(startTime); err != nil {
+		return nil, fmt.Errorf("开始时间无效: %s", startTime)
+	}
+	if _, err := ParseTimestamp(endTime); err != nil {
+		return nil, fmt.Errorf("结束时间无效: %s", endTime)
+	}
 
 	// 将ASS时间格式转换为标准时间格式
 	startTimeStr := convertASSTime(startTime)
@@ -170,12 +206,8 @@ func convertASSTime(assTime string) string {
 
 // cleanASSTags 清理ASS文本中的标签
 func cleanASSTags(text string) string {
-	// 移除基本的ASS标签
-	re := regexp.MustCompile(`\[.*?\]`)
-	text = re.ReplaceAllString(text, "")
-
-	// 移除花括号标签
-	re = regexp.MustCompile(`\{.*?\}`)
+	// 移除花括号标签（方括号不是ASS标签，可能是听障注释，予以保留）
+	re := regexp.MustCompile(`\{.*?\}`)
 	text = re.ReplaceAllString(text, "")
 
 	// 移除反斜杠转义
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// withoutMessages 清空诊断信息的提示文本，只核对行号、级别和原始内容
+func withoutMessages(diagnostics []models.Diagnostic) []models.Diagnostic {
+	var out []models.Diagnostic
+	for _, d := range diagnostics {
+		d.Message = ""
+		out = append(out, d)
+	}
+	return out
+}
+
+func TestParseASSDiagnostics(t *testing.T) {
+	content := "[Script Info]\n" +
+		"Title: test\n" +
+		"\n" +
+		"[Events]\n" +
+		"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+		"Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Hello\n" +
+		"Dialogue: 0,0:00:xx.00,0:00:04.00,Default,,0,0,0,,Bad time\n" +
+		"Dialogue: 0,0:00:05.00,0:00:06.00\n" +
+		"Comment: 0,0:00:07.00,0:00:08.00,Default,,0,0,0,,Skipped\n" +
+		"garbage\n" +
+		"Dialogue: 0,0:00:09.00,0:00:10.00,Default,,0,0,0,,World\n"
+
+	entries, diagnostics, err := ParseASS(content)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	wantEntries := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"},
+		{Index: 2, TimeRange: "00:00:09,000 --> 00:00:10,000", Content: "World"},
+	}
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("字幕 = %+v，期望 %+v", entries, wantEntries)
+	}
+
+	// 行号从文件第一行开始计算，原始内容为未经处理的整行
+	want := []models.Diagnostic{
+		{Line: 7, Severity: models.SeverityError, Raw: "Dialogue: 0,0:00:xx.00,0:00:04.00,Default,,0,0,0,,Bad time"},
+		{Line: 8, Severity: models.SeverityError, Raw: "Dialogue: 0,0:00:05.00,0:00:06.00"},
+		{Line: 10, Severity: models.SeverityWarning, Raw: "garbage"},
+	}
+	if got := withoutMessages(diagnostics); !reflect.DeepEqual(got, want) {
+		t.Errorf("诊断信息 = %+v，期望 %+v", diagnostics, want)
+	}
+	for _, d := range diagnostics {
+		if d.Message == "" {
+			t.Errorf("第%d行的诊断信息缺少提示文本", d.Line)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// DefaultMicroDVDFPS 未指定帧率且文件没有帧率头时使用的帧率
+const DefaultMicroDVDFPS = 23.976
+
+// defaultOpenEndDuration 结束帧缺失且无法从下一条推断时使用的显示时长
+const defaultOpenEndDuration = 2 * time.Second
+
+// microDVDLinePattern 匹配 {开始帧}{结束帧}文本，结束帧可以为空
+var microDVDLinePattern = regexp.MustCompile(`^\{(\d+)\}\{(\d*)\}(.*)$`)
+
+// microDVDCodePattern 匹配MicroDVD控制码，例如 {y:i}、{c:$0000FF}
+var microDVDCodePattern = regexp.MustCompile(`\{[a-zA-Z]:[^}]*\}`)
+
+// ParseMicroDVD 解析MicroDVD格式（.sub）字幕内容
+// 首行为 {1}{1}23.976 形式的帧率头时优先使用其帧率，否则使用fps（<=0时使用默认帧率）
+// 返回实际使用的帧率，以便构建时保持一致
+func ParseMicroDVD(content string, fps float64) ([]models.SubtitleEntry, []models.Diagnostic, float64, error) {
+	var entries []models.SubtitleEntry
+	var diagnostics []models.Diagnostic
+	var starts, ends []int // 每条字幕的起止帧，结束帧-1表示缺失
+
+	if fps <= 0 {
+		fps = DefaultMicroDVDFPS
+	}
+	headerSeen := false
+
+	for i, line := range splitLines(content) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		matches := microDVDLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityError,
+				Message:  "无法识别的MicroDVD行，已跳过",
+				Raw:      line,
+			})
+			continue
+		}
+
+		// 帧率头只能出现在第一条
+		if !headerSeen && len(entries) == 0 && (matches[1] == "0" || matches[1] == "1") {
+			headerSeen = true
+			if headerFPS, err := strconv.ParseFloat(strings.TrimSpace(matches[3]), 64); err == nil && headerFPS > 0 {
+				if math.Abs(headerFPS-fps) > 0.001 {
+					diagnostics = append(diagnostics, models.Diagnostic{
+						Line:     i + 1,
+						Severity: models.SeverityInfo,
+						Message:  fmt.Sprintf("使用文件头声明的帧率%g", headerFPS),
+						Raw:      line,
+					})
+				}
+				fps = headerFPS
+				continue
+			}
+		}
+		headerSeen = true
+
+		start, _ := strconv.Atoi(matches[1])
+		end := -1
+		if matches[2] != "" {
+			end, _ = strconv.Atoi(matches[2])
+		}
+		text := parseMicroDVDText(matches[3])
+		if strings.TrimSpace(text) == "" {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityWarning,
+				Message:  "字幕文本为空，已跳过",
+				Raw:      line,
+			})
+			continue
+		}
+		if end == -1 {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityWarning,
+				Message:  "缺少结束帧，已根据下一条字幕推断",
+				Raw:      line,
+			})
+		}
+
+		// 时间范围在帧率确定后再换算
+		entries = append(entries, models.SubtitleEntry{
+			Index:   len(entries) + 1,
+			Content: text,
+		})
+		starts = append(starts, start)
+		ends = append(ends, end)
+	}
+
+	for i := range entries {
+		startTime := framesToDuration(starts[i], fps)
+		endTime := startTime + defaultOpenEndDuration
+		switch {
+		case ends[i] >= 0:
+			endTime = framesToDuration(ends[i], fps)
+		case i+1 < len(entries):
+			endTime = framesToDuration(starts[i+1], fps)
+		}
+		entries[i].TimeRange = FormatSRTTimestamp(startTime) + " --> " + FormatSRTTimestamp(endTime)
+	}
+
+	return entries, diagnostics, fps, nil
+}
+
+// parseMicroDVDText 将 | 换行转为换行符，{y:i} 斜体转为 <i>，其余控制码移除
+func parseMicroDVDText(text string) string {
+	// 大写Y作用于整条字幕，小写y只作用于所在行
+	italicAll := leadingCode(text) == "{Y:i}"
+	lines := strings.Split(text, "|")
+	for i, line := range lines {
+		italic := italicAll || leadingCode(line) == "{y:i}"
+		line = strings.TrimSpace(microDVDCodePattern.ReplaceAllString(line, ""))
+		if italic && line != "" {
+			line = "<i>" + line + "</i>"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// leadingCode 返回文本开头的控制码
+func leadingCode(text string) string {
+	if loc := microDVDCodePattern.FindStringIndex(text); loc != nil && loc[0] == 0 {
+		return text[:loc[1]]
+	}
+	return ""
+}
+
+// BuildMicroDVD 构建MicroDVD格式字幕内容，首行写入 {1}{1}帧率 文件头
+func BuildMicroDVD(entries []models.SubtitleEntry, outputFormat string, fps float64) string {
+	if fps <= 0 {
+		fps = DefaultMicroDVDFPS
+	}
+	var builder strings.Builder
+	builder.WriteString("{1}{1}" + strconv.FormatFloat(fps, 'f', -1, 64) + "\n")
+
+	for _, entry := range entries {
+		tr, err := ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			continue
+		}
+		// MicroDVD每条字幕占一行，多行文本（包括双语时的原文和译文）用 | 连接
+		builder.WriteString(fmt.Sprintf("{%d}{%d}%s\n", durationToFrames(tr.Start, fps), durationToFrames(tr.End, fps), formatPipeText(entry.Content, "{y:i}")))
+	}
+
+	return builder.String()
+}
+
+// formatPipeText 将多行文本用 | 连接，整行斜体替换为指定的斜体标记
+func formatPipeText(content, italic string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "<i>") && strings.HasSuffix(line, "</i>") && strings.Count(line, "<i>") == 1 {
+			line = italic + strings.TrimSuffix(strings.TrimPrefix(line, "<i>"), "</i>")
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "|")
+}
+
+// framesToDuration 将帧号换算为时长
+func framesToDuration(frames int, fps float64) time.Duration {
+	return time.Duration(math.Round(float64(frames)/fps*1000)) * time.Millisecond
+}
+
+// durationToFrames 将时长换算为帧号
+func durationToFrames(d time.Duration, fps float64) int {
+	return int(math.Round(d.Seconds() * fps))
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestParseMicroDVD(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		fps         float64
+		entries     []models.SubtitleEntry
+		diagnostics []diag
+		wantFPS     float64
+	}{
+		{
+			// 文件头与默认帧率不同时以文件头为准并给出提示
+			name:        "文件头声明帧率",
+			content:     "{1}{1}25\n{25}{50}Hello\n",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+			diagnostics: []diag{{1, models.SeverityInfo}},
+			wantFPS:     25,
+		},
+		{
+			name:        "文件头优先于指定帧率",
+			content:     "{0}{0}25\n{25}{50}Hello\n",
+			fps:         30,
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+			diagnostics: []diag{{1, models.SeverityInfo}},
+			wantFPS:     25,
+		},
+		{
+			name:    "文件头与指定帧率相同",
+			content: "{1}{1}25\n{25}{50}Hello\n",
+			fps:     25,
+			entries: []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+			wantFPS: 25,
+		},
+		{
+			name:    "没有文件头时使用指定帧率",
+			content: "{25}{50}Hello\n",
+			fps:     25,
+			entries: []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+			wantFPS: 25,
+		},
+		{
+			name:    "没有文件头时使用默认帧率",
+			content: "{24}{48}Hello\n",
+			entries: []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,001 --> 00:00:02,002", Content: "Hello"}},
+			wantFPS: DefaultMicroDVDFPS,
+		},
+		{
+			// 缺少结束帧时使用下一条的开始帧，最后一条显示默认时长
+			name:    "缺少结束帧",
+			content: "{25}{}A\n{75}{100}B\n{125}{}C\n",
+			fps:     25,
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:03,000", Content: "A"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "B"},
+				{Index: 3, TimeRange: "00:00:05,000 --> 00:00:07,000", Content: "C"},
+			},
+			diagnostics: []diag{{1, models.SeverityWarning}, {3, models.SeverityWarning}},
+			wantFPS:     25,
+		},
+		{
+			name:    "斜体和控制码",
+			content: "{25}{50}{Y:i}Line1|Line2\n{75}{100}{y:i}It|{c:$0000FF}Plain\n",
+			fps:     25,
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "<i>Line1</i>\n<i>Line2</i>"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "<i>It</i>\nPlain"},
+			},
+			wantFPS: 25,
+		},
+		{
+			name:        "无法识别的行和空文本",
+			content:     "garbage\n{25}{50}\n{75}{100}Hello\n",
+			fps:         25,
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "Hello"}},
+			diagnostics: []diag{{1, models.SeverityError}, {2, models.SeverityWarning}},
+			wantFPS:     25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, diagnostics, fps, err := ParseMicroDVD(tt.content, tt.fps)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("字幕 = %+v，期望 %+v", entries, tt.entries)
+			}
+			var got []diag
+			for _, d := range diagnostics {
+				got = append(got, diag{d.Line, d.Severity})
+			}
+			if !reflect.DeepEqual(got, tt.diagnostics) {
+				t.Errorf("诊断信息 = %+v，期望 %+v", diagnostics, tt.diagnostics)
+			}
+			if fps != tt.wantFPS {
+				t.Errorf("帧率 = %g，期望 %g", fps, tt.wantFPS)
+			}
+		})
+	}
+}
+
+func TestBuildMicroDVD(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "<i>Hello</i>\nthere"},
+		{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"},
+	}
+	tests := []struct {
+		fps  float64
+		want string
+	}{
+		{25, "{1}{1}25\n{25}{50}{y:i}Hello|there\n{75}{100}World\n"},
+		{0, "{1}{1}23.976\n{24}{48}{y:i}Hello|there\n{72}{96}World\n"},
+	}
+	for _, tt := range tests {
+		got := BuildMicroDVD(entries, "", tt.fps)
+		if got != tt.want {
+			t.Errorf("BuildMicroDVD(fps=%g) =\n%q\n期望\n%q", tt.fps, got, tt.want)
+		}
+
+		// 往返时从文件头读回相同的帧率和字幕
+		parsed, _, fps, err := ParseMicroDVD(got, 0)
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if again := BuildMicroDVD(parsed, "", fps); again != got {
+			t.Errorf("往返结果 =\n%q\n期望\n%q", again, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// mpl2LinePattern 匹配 [开始][结束]文本，时间单位为0.1秒，结束时间可以为空
+var mpl2LinePattern = regexp.MustCompile(`^\[(\d+)\]\[(\d*)\](.*)$`)
+
+// ParseMPL2 解析MPL2格式字幕内容，行首的 / 表示斜体
+func ParseMPL2(content string) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	var entries []models.SubtitleEntry
+	var diagnostics []models.Diagnostic
+	var starts, ends []int // 每条字幕的起止时间（0.1秒），结束时间-1表示缺失
+
+	for i, line := range splitLines(content) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		matches := mpl2LinePattern.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityError,
+				Message:  "无法识别的MPL2行，已跳过",
+				Raw:      line,
+			})
+			continue
+		}
+
+		start, _ := strconv.Atoi(matches[1])
+		end := -1
+		if matches[2] != "" {
+			end, _ = strconv.Atoi(matches[2])
+		}
+		text := parseMPL2Text(matches[3])
+		if strings.TrimSpace(text) == "" {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityWarning,
+				Message:  "字幕文本为空，已跳过",
+				Raw:      line,
+			})
+			continue
+		}
+		if end == -1 {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     i + 1,
+				Severity: models.SeverityWarning,
+				Message:  "缺少结束时间，已根据下一条字幕推断",
+				Raw:      line,
+			})
+		}
+
+		entries = append(entries, models.SubtitleEntry{
+			Index:   len(entries) + 1,
+			Content: text,
+		})
+		starts = append(starts, start)
+		ends = append(ends, end)
+	}
+
+	for i := range entries {
+		startTime := decisecondsToDuration(starts[i])
+		endTime := startTime + defaultOpenEndDuration
+		switch {
+		case ends[i] >= 0:
+			endTime = decisecondsToDuration(ends[i])
+		case i+1 < len(entries):
+			endTime = decisecondsToDuration(starts[i+1])
+		}
+		entries[i].TimeRange = FormatSRTTimestamp(startTime) + " --> " + FormatSRTTimestamp(endTime)
+	}
+
+	return entries, diagnostics, nil
+}
+
+// parseMPL2Text 将 | 换行转为换行符，行首的 / 斜体转为 <i>
+func parseMPL2Text(text string) string {
+	lines := strings.Split(text, "|")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "/") {
+			if line = strings.TrimSpace(line[1:]); line != "" {
+				line = "<i>" + line + "</i>"
+			}
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildMPL2 构建MPL2格式字幕内容
+func BuildMPL2(entries []models.SubtitleEntry, outputFormat string) string {
+	var builder strings.Builder
+
+	for _, entry := range entries {
+		tr, err := ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			continue
+		}
+		// MPL2时间以0.1秒为单位，取整可能使相邻字幕的时间相接；整行斜体写为 / 前缀
+		builder.WriteString(fmt.Sprintf("[%d][%d]%s\n", durationToDeciseconds(tr.Start), durationToDeciseconds(tr.End), formatPipeText(entry.Content, "/")))
+	}
+
+	return builder.String()
+}
+
+// decisecondsToDuration 将0.1秒单位的时间换算为时长
+func decisecondsToDuration(ds int) time.Duration {
+	return time.Duration(ds) * 100 * time.Millisecond
+}
+
+// durationToDeciseconds 将时长换算为0.1秒单位，四舍五入
+func durationToDeciseconds(d time.Duration) int {
+	return int((d + 50*time.Millisecond) / (100 * time.Millisecond))
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestParseMPL2(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		entries     []models.SubtitleEntry
+		diagnostics []diag
+	}{
+		{
+			name:    "规范文件",
+			content: "[10][25]Hello|/World\n[30][41]/Bye\n",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,500", Content: "Hello\n<i>World</i>"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,100", Content: "<i>Bye</i>"},
+			},
+		},
+		{
+			// 缺少结束时间时使用下一条的开始时间，最后一条显示默认时长
+			name:    "缺少结束时间",
+			content: "[10][]A\n[30][40]B\n[50][]C\n",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:03,000", Content: "A"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "B"},
+				{Index: 3, TimeRange: "00:00:05,000 --> 00:00:07,000", Content: "C"},
+			},
+			diagnostics: []diag{{1, models.SeverityWarning}, {3, models.SeverityWarning}},
+		},
+		{
+			name:        "无法识别的行和空文本",
+			content:     "garbage\n[10][20]/\n[30][40]Hello\n",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "Hello"}},
+			diagnostics: []diag{{1, models.SeverityError}, {2, models.SeverityWarning}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, diagnostics, err := ParseMPL2(tt.content)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("字幕 = %+v，期望 %+v", entries, tt.entries)
+			}
+			var got []diag
+			for _, d := range diagnostics {
+				got = append(got, diag{d.Line, d.Severity})
+			}
+			if !reflect.DeepEqual(got, tt.diagnostics) {
+				t.Errorf("诊断信息 = %+v，期望 %+v", diagnostics, tt.diagnostics)
+			}
+		})
+	}
+}
+
+func TestDurationToDeciseconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 0},
+		{1049 * time.Millisecond, 10},
+		{1050 * time.Millisecond, 11}, // 0.05秒向上取整
+		{1999 * time.Millisecond, 20},
+		{time.Hour + 12*time.Millisecond, 36000},
+	}
+	for _, tt := range tests {
+		if got := durationToDeciseconds(tt.d); got != tt.want {
+			t.Errorf("durationToDeciseconds(%v) = %d，期望 %d", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestBuildMPL2(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 1, TimeRange: "00:00:01,049 --> 00:00:02,050", Content: "Hello\n<i>World</i>"},
+		{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "<i>a <i>b</i> c</i>"},
+	}
+	// 只有整行斜体写为 / 前缀，嵌套的标签原样保留
+	want := "[10][21]Hello|/World\n[30][40]<i>a <i>b</i> c</i>\n"
+	got := BuildMPL2(entries, "")
+	if got != want {
+		t.Errorf("BuildMPL2 =\n%q\n期望\n%q", got, want)
+	}
+
+	parsed, _, err := ParseMPL2(got)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if again := BuildMPL2(parsed, ""); again != got {
+		t.Errorf("往返结果 =\n%q\n期望\n%q", again, got)
+	}
+}
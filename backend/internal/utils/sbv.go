@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// sbvTimePattern 匹配YouTube SBV时间行，例如 0:00:01.000,0:00:02.500
+var sbvTimePattern = regexp.MustCompile(`^\s*(\d+:\d{1,2}:\d{1,2}(?:\.\d{1,3})?)\s*,\s*(\d+:\d{1,2}:\d{1,2}(?:\.\d{1,3})?)\s*$`)
+
+// ParseSBV 解析YouTube SBV格式字幕内容，返回被跳过的块的诊断信息
+func ParseSBV(content string) ([]models.SubtitleEntry, []models.Diagnostic, error) {
+	var entries []models.SubtitleEntry
+	var diagnostics []models.Diagnostic
+
+	lines := splitLines(content)
+
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+
+		// 每个块以时间行开头，其后直到空行为字幕文本
+		start := i
+		var text []string
+		for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+			text = append(text, strings.TrimRight(lines[i], " \t"))
+		}
+
+		matches := sbvTimePattern.FindStringSubmatch(lines[start])
+		if matches == nil {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     start + 1,
+				Severity: models.SeverityError,
+				Message:  "时间行无效，已跳过该字幕",
+				Raw:      lines[start],
+			})
+			continue
+		}
+		begin, err1 := ParseTimestamp(matches[1])
+		end, err2 := ParseTimestamp(matches[2])
+		if err1 != nil || err2 != nil {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     start + 1,
+				Severity: models.SeverityError,
+				Message:  "时间戳无效，已跳过该字幕",
+				Raw:      lines[start],
+			})
+			continue
+		}
+		if len(text) == 0 {
+			diagnostics = append(diagnostics, models.Diagnostic{
+				Line:     start + 1,
+				Severity: models.SeverityWarning,
+				Message:  "字幕文本为空，已跳过",
+				Raw:      lines[start],
+			})
+			continue
+		}
+
+		entries = append(entries, models.SubtitleEntry{
+			Index:     len(entries) + 1,
+			TimeRange: FormatSRTTimestamp(begin) + " --> " + FormatSRTTimestamp(end),
+			Content:   strings.Join(text, "\n"),
+		})
+	}
+
+	return entries, diagnostics, nil
+}
+
+// BuildSBV 构建YouTube SBV格式字幕内容
+func BuildSBV(entries []models.SubtitleEntry, outputFormat string) string {
+	var builder strings.Builder
+
+	for i, entry := range entries {
+		tr, err := ParseTimeRange(entry.TimeRange)
+		if err != nil {
+			continue
+		}
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		// SBV用空行分隔字幕，多行文本原样写出；双语时原文和译文已在Content中各占一行
+		builder.WriteString(formatSBVTimestamp(tr.Start) + "," + formatSBVTimestamp(tr.End) + "\n")
+		builder.WriteString(entry.Content + "\n")
+	}
+
+	return builder.String()
+}
+
+// formatSBVTimestamp 将时长格式化为SBV时间戳 (H:MM:SS.mmm)
+func formatSBVTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	totalMillis := int64((d + time.Millisecond/2) / time.Millisecond)
+	return fmt.Sprintf("%d:%02d:%02d.%03d", totalMillis/3600000, totalMillis%3600000/60000, totalMillis%60000/1000, totalMillis%1000)
+}
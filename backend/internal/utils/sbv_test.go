@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestParseSBV(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		entries     []models.SubtitleEntry
+		diagnostics []diag
+	}{
+		{
+			name:    "规范文件",
+			content: "0:00:01.000,0:00:02.500\nHello\nthere\n\n0:00:03.000,0:00:04.000\nWorld\n",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,500", Content: "Hello\nthere"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"},
+			},
+		},
+		{
+			name:    "CRLF和时间中的空格",
+			content: "0:00:01.000 , 0:00:02.000\r\nHello\r\n",
+			entries: []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+		},
+		{
+			name:        "时间行无效",
+			content:     "0:00:xx,0:00:02.000\nBroken\n\n0:00:03.000,0:00:04.000\nWorld",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"}},
+			diagnostics: []diag{{1, models.SeverityError}},
+		},
+		{
+			name:        "字幕文本为空",
+			content:     "0:00:01.000,0:00:02.000\n\n0:00:03.000,0:00:04.000\nWorld",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"}},
+			diagnostics: []diag{{1, models.SeverityWarning}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, diagnostics, err := ParseSBV(tt.content)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("字幕 = %+v，期望 %+v", entries, tt.entries)
+			}
+			var got []diag
+			for _, d := range diagnostics {
+				got = append(got, diag{d.Line, d.Severity})
+			}
+			if !reflect.DeepEqual(got, tt.diagnostics) {
+				t.Errorf("诊断信息 = %+v，期望 %+v", diagnostics, tt.diagnostics)
+			}
+		})
+	}
+}
+
+func TestBuildSBVRoundTrip(t *testing.T) {
+	content := "0:00:01.000,0:00:02.500\nHello\nthere\n\n1:02:03.004,1:02:05.000\nWorld\n"
+	entries, _, err := ParseSBV(content)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if got := BuildSBV(entries, ""); got != content {
+		t.Errorf("往返结果 =\n%q\n期望\n%q", got, content)
+	}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"bufio"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -10,76 +9,237 @@ import (
 	"github.com/frank0/subtitleTranslate/internal/models"
 )
 
-// ParseSRT 解析SRT格式的字幕文件内容
+// SRTOptions SRT解析选项
+type SRTOptions struct {
+	Strict bool // 严格模式：遇到任何格式问题都返回错误，而不是尝试修复
+}
+
+// srtTimePattern 宽松匹配SRT时间行：允许单位数小时、点号或冒号毫秒分隔符、箭头前后空格变化以及行尾坐标
+var srtTimePattern = regexp.MustCompile(`^\s*(\d{1,3}:\d{1,2}:\d{1,2}(?:[,.:]\d{1,3})?)\s*-{1,2}>\s*(\d{1,3}:\d{1,2}:\d{1,2}(?:[,.:]\d{1,3})?)\s*(.*)$`)
+
+// srtStrictTimePattern 严格的SRT时间行
+var srtStrictTimePattern = regexp.MustCompile(`^\d{2}:\d{2}:\d{2},\d{3} --> \d{2}:\d{2}:\d{2},\d{3}$`)
+
+// ParseSRT 解析SRT格式的字幕文件内容（宽松模式，忽略诊断信息）
 func ParseSRT(content string) ([]models.SubtitleEntry, error) {
+	entries, _, err := ParseSRTWithOptions(content, SRTOptions{})
+	return entries, err
+}
+
+// ParseSRTWithOptions 解析SRT格式的字幕文件内容，返回每个被修复或跳过的块的诊断信息
+// 宽松模式可处理BOM、CRLF换行、缺失序号、点号毫秒分隔符、单位数小时、
+// 时间行后的坐标（X1: Y1:）以及字幕文本中的空行
+func ParseSRTWithOptions(content string, opts SRTOptions) ([]models.SubtitleEntry, []models.Diagnostic, error) {
 	var entries []models.SubtitleEntry
-	scanner := bufio.NewScanner(strings.NewReader(content))
+	var diagnostics []models.Diagnostic
 
-	var currentEntry *models.SubtitleEntry
-	var contentLines []string
+	report := func(line int, severity, message, raw string) error {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     line,
+			Severity: severity,
+			Message:  message,
+			Raw:      raw,
+		})
+		if opts.Strict && severity != models.SeverityInfo {
+			return fmt.Errorf("第%d行: %s", line, message)
+		}
+		return nil
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	if strings.HasPrefix(content, "\uFEFF") {
+		content = strings.TrimPrefix(content, "\uFEFF")
+		if err := report(1, models.SeverityInfo, "已移除UTF-8 BOM", ""); err != nil {
+			return nil, diagnostics, err
+		}
+	}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	lines := strings.Split(content, "\n")
 
-		// 尝试解析为索引号
-		if currentEntry == nil {
-			index, err := strconv.Atoi(strings.TrimSpace(line))
-			if err == nil && index > 0 {
-				currentEntry = &models.SubtitleEntry{Index: index}
-				continue
+	// isIndex 判断是否为序号行
+	isIndex := func(i int) bool {
+		if i >= len(lines) {
+			return false
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(lines[i]))
+		return err == nil && n >= 0
+	}
+	// isTime 判断是否为时间行
+	isTime := func(i int) bool {
+		return i < len(lines) && srtTimePattern.MatchString(lines[i])
+	}
+	// startsCue 判断第i行是否开始一条新字幕（序号+时间行，或缺失序号的时间行）
+	startsCue := func(i int) bool {
+		return isTime(i) || (isIndex(i) && isTime(i+1))
+	}
+	// startsBrokenCue 判断第i行是否为序号后跟无效时间行的损坏字幕
+	startsBrokenCue := func(i int) bool {
+		return isIndex(i) && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && !isTime(i+1)
+	}
+	// skipCue 跳过到下一条字幕的起点
+	skipCue := func(i int) int {
+		for i < len(lines) && !startsCue(i) {
+			i++
+		}
+		return i
+	}
+
+	lastIndex := 0
+	i := 0
+	for i < len(lines) {
+		if strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+
+		// 定位字幕起点
+		if startsBrokenCue(i) {
+			if err := report(i+2, models.SeverityError, "时间行无效，已跳过该字幕", lines[i+1]); err != nil {
+				return nil, diagnostics, err
 			}
+			i = skipCue(i + 1)
+			continue
+		}
+		if !startsCue(i) {
+			if err := report(i+1, models.SeverityWarning, "跳过无法识别的行", lines[i]); err != nil {
+				return nil, diagnostics, err
+			}
+			i++
+			continue
 		}
 
-		// 尝试解析为时间范围
-		if currentEntry != nil && currentEntry.TimeRange == "" {
-			// 时间格式: 00:00:00,000 --> 00:00:00,000
-			timeRangePattern := regexp.MustCompile(`\d{2}:\d{2}:\d{2},\d{3} --> \d{2}:\d{2}:\d{2},\d{3}`)
-			if timeRangePattern.MatchString(line) {
-				currentEntry.TimeRange = line
-				continue
+		entry := models.SubtitleEntry{}
+		cueLine := i + 1
+		if isIndex(i) {
+			entry.Index, _ = strconv.Atoi(strings.TrimSpace(lines[i]))
+			i++
+		} else {
+			entry.Index = lastIndex + 1
+			if err := report(i+1, models.SeverityWarning, "字幕缺少序号，已自动编号", lines[i]); err != nil {
+				return nil, diagnostics, err
+			}
+		}
+		if entry.Index <= lastIndex {
+			if err := report(cueLine, models.SeverityInfo, fmt.Sprintf("字幕序号%d不连续，输出时将重新编号", entry.Index), lines[cueLine-1]); err != nil {
+				return nil, diagnostics, err
 			}
 		}
 
-		// 收集字幕内容
-		if currentEntry != nil && currentEntry.TimeRange != "" {
-			if line == "" {
-				// 空行表示一个条目的结束
-				if len(contentLines) > 0 {
-					currentEntry.Content = strings.Join(contentLines, "\n")
-					entries = append(entries, *currentEntry)
-					currentEntry = nil
-					contentLines = nil
+		// 解析时间行
+		timeLine := lines[i]
+		timeRange, extra, err := normalizeSRTTimeLine(timeLine)
+		if err != nil {
+			if err := report(i+1, models.SeverityError, "时间行无效，已跳过该字幕: "+err.Error(), timeLine); err != nil {
+				return nil, diagnostics, err
+			}
+			i = skipCue(i + 1)
+			continue
+		}
+		if extra != "" {
+			if err := report(i+1, models.SeverityInfo, "已忽略时间行后的附加内容: "+extra, timeLine); err != nil {
+				return nil, diagnostics, err
+			}
+		}
+		if !srtStrictTimePattern.MatchString(timeLine) && extra == "" {
+			if err := report(i+1, models.SeverityWarning, "时间行格式不规范，已修正", timeLine); err != nil {
+				return nil, diagnostics, err
+			}
+		}
+		entry.TimeRange = timeRange
+		i++
+
+		// 收集字幕文本，直到空行后出现新的字幕或文件结束
+		var textLines []string
+		for i < len(lines) {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				next := i + 1
+				for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+					next++
 				}
-			} else {
-				contentLines = append(contentLines, line)
+				if next >= len(lines) || startsCue(next) || startsBrokenCue(next) {
+					i = next
+					break
+				}
+				// 空行之后仍是文本，视为字幕内部的空行
+				if err := report(i+1, models.SeverityWarning, "字幕文本中包含空行，已合并", ""); err != nil {
+					return nil, diagnostics, err
+				}
+				i = next
+				continue
 			}
+			if len(textLines) > 0 && startsCue(i) {
+				// 缺少分隔空行，直接开始了下一条字幕
+				if err := report(i+1, models.SeverityWarning, "字幕之间缺少空行", line); err != nil {
+					return nil, diagnostics, err
+				}
+				break
+			}
+			textLines = append(textLines, strings.TrimRight(line, " \t"))
+			i++
 		}
-	}
 
-	// 处理最后一个条目
-	if currentEntry != nil && currentEntry.TimeRange != "" && len(contentLines) > 0 {
-		currentEntry.Content = strings.Join(contentLines, "\n")
-		entries = append(entries, *currentEntry)
+		if len(textLines) == 0 {
+			if err := report(cueLine, models.SeverityWarning, "字幕文本为空，已跳过", timeLine); err != nil {
+				return nil, diagnostics, err
+			}
+			continue
+		}
+
+		entry.Content = strings.Join(textLines, "\n")
+		entries = append(entries, entry)
+		lastIndex = entry.Index
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("解析SRT文件失败: %w", err)
+	return entries, diagnostics, nil
+}
+
+// splitLines 移除BOM并按行切分，兼容CRLF和CR换行
+func splitLines(content string) []string {
+	content = strings.TrimPrefix(content, "\uFEFF")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	return strings.Split(content, "\n")
+}
+
+// normalizeSRTTimeLine 将宽松格式的时间行规范为 "HH:MM:SS,mmm --> HH:MM:SS,mmm"
+// 返回时间行之后被忽略的附加内容（例如坐标）
+func normalizeSRTTimeLine(line string) (string, string, error) {
+	matches := srtTimePattern.FindStringSubmatch(line)
+	if matches == nil {
+		return "", "", fmt.Errorf("无法识别的时间行")
+	}
+	start, err := ParseTimestamp(normalizeMillisSeparator(matches[1]))
+	if err != nil {
+		return "", "", err
 	}
+	end, err := ParseTimestamp(normalizeMillisSeparator(matches[2]))
+	if err != nil {
+		return "", "", err
+	}
+	return FormatSRTTimestamp(start) + " --> " + FormatSRTTimestamp(end), strings.TrimSpace(matches[3]), nil
+}
 
-	return entries, nil
+// normalizeMillisSeparator 将 "00:00:01:500" 中作为毫秒分隔符的第三个冒号替换为逗号
+func normalizeMillisSeparator(ts string) string {
+	if strings.Count(ts, ":") == 3 {
+		i := strings.LastIndex(ts, ":")
+		return ts[:i] + "," + ts[i+1:]
+	}
+	return ts
 }
 
-// FormatSRT 将字幕条目格式化为SRT字符串
+// FormatSRT 将字幕条目格式化为SRT字符串，序号按输出顺序重新编号
 func FormatSRT(entries []models.SubtitleEntry) string {
 	var builder strings.Builder
 
 	for i, entry := range entries {
 		// 添加索引
-		builder.WriteString(strconv.Itoa(entry.Index))
+		builder.WriteString(strconv.Itoa(i + 1))
 		builder.WriteString("\n")
 
 		// 添加时间范围
-		builder.WriteString(entry.TimeRange)
+		builder.WriteString(srtTimeRange(entry.TimeRange))
 		builder.WriteString("\n")
 
 		// 添加内容
@@ -95,17 +255,17 @@ func FormatSRT(entries []models.SubtitleEntry) string {
 	return builder.String()
 }
 
-// BuildSRT 构建SRT格式字幕内容
+// BuildSRT 构建SRT格式字幕内容，序号按输出顺序重新编号
 func BuildSRT(entries []models.SubtitleEntry, outputFormat string) string {
 	var builder strings.Builder
 
 	for i, entry := range entries {
 		// 写入索引
-		builder.WriteString(strconv.Itoa(entry.Index))
+		builder.WriteString(strconv.Itoa(i + 1))
 		builder.WriteString("\n")
 
 		// 写入时间范围
-		builder.WriteString(entry.TimeRange)
+		builder.WriteString(srtTimeRange(entry.TimeRange))
 		builder.WriteString("\n")
 
 		// 根据输出格式写入内容
@@ -126,3 +286,12 @@ func BuildSRT(entries []models.SubtitleEntry, outputFormat string) string {
 
 	return builder.String()
 }
+
+// srtTimeRange 将时间范围规范为SRT格式，去掉VTT的cue设置
+func srtTimeRange(timeRange string) string {
+	tr, err := ParseTimeRange(timeRange)
+	if err != nil {
+		return timeRange
+	}
+	return FormatSRTTimestamp(tr.Start) + " --> " + FormatSRTTimestamp(tr.End)
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+// diag 诊断信息中需要核对的部分
+type diag struct {
+	line     int
+	severity string
+}
+
+func TestParseSRTWithOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		entries     []models.SubtitleEntry
+		diagnostics []diag
+	}{
+		{
+			name:    "规范文件",
+			content: "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:00:03,000 --> 00:00:04,000\nWorld\n",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"},
+			},
+		},
+		{
+			name:        "BOM和CRLF",
+			content:     "\uFEFF1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+			diagnostics: []diag{{1, models.SeverityInfo}},
+		},
+		{
+			name:    "缺少序号",
+			content: "00:00:01,000 --> 00:00:02,000\nHello\n\n00:00:03,000 --> 00:00:04,000\nWorld",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"},
+			},
+			diagnostics: []diag{{1, models.SeverityWarning}, {4, models.SeverityWarning}},
+		},
+		{
+			name:        "点号毫秒分隔符和单位数小时",
+			content:     "1\n0:00:01.000 --> 0:00:02.5\nHello",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,500", Content: "Hello"}},
+			diagnostics: []diag{{2, models.SeverityWarning}},
+		},
+		{
+			name:        "时间行后的坐标",
+			content:     "1\n00:00:01,000 --> 00:00:02,000 X1:100 X2:200 Y1:10 Y2:20\nHello",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+			diagnostics: []diag{{2, models.SeverityInfo}},
+		},
+		{
+			name:    "时间行无效",
+			content: "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:00:xx --> 00:00:04,000\nBroken\n\n3\n00:00:05,000 --> 00:00:06,000\nWorld",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"},
+				{Index: 3, TimeRange: "00:00:05,000 --> 00:00:06,000", Content: "World"},
+			},
+			diagnostics: []diag{{6, models.SeverityError}},
+		},
+		{
+			name:    "字幕文本中的空行",
+			content: "1\n00:00:01,000 --> 00:00:02,000\nHello\n\nthere\n\n2\n00:00:03,000 --> 00:00:04,000\nWorld",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello\nthere"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"},
+			},
+			diagnostics: []diag{{4, models.SeverityWarning}},
+		},
+		{
+			name:    "字幕之间缺少空行",
+			content: "1\n00:00:01,000 --> 00:00:02,000\nHello\n2\n00:00:03,000 --> 00:00:04,000\nWorld",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"},
+				{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"},
+			},
+			diagnostics: []diag{{4, models.SeverityWarning}},
+		},
+		{
+			// 重叠的时间由质量检查报告，解析时保持原样
+			name:    "时间重叠且序号倒序",
+			content: "2\n00:00:01,000 --> 00:00:03,000\nHello\n\n1\n00:00:02,000 --> 00:00:04,000\nWorld",
+			entries: []models.SubtitleEntry{
+				{Index: 2, TimeRange: "00:00:01,000 --> 00:00:03,000", Content: "Hello"},
+				{Index: 1, TimeRange: "00:00:02,000 --> 00:00:04,000", Content: "World"},
+			},
+			diagnostics: []diag{{5, models.SeverityInfo}},
+		},
+		{
+			name:        "字幕文本为空",
+			content:     "1\n00:00:01,000 --> 00:00:02,000\n\n2\n00:00:03,000 --> 00:00:04,000\nWorld",
+			entries:     []models.SubtitleEntry{{Index: 2, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"}},
+			diagnostics: []diag{{1, models.SeverityWarning}},
+		},
+		{
+			name:        "无法识别的行",
+			content:     "garbage\n\n1\n00:00:01,000 --> 00:00:02,000\nHello",
+			entries:     []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01,000 --> 00:00:02,000", Content: "Hello"}},
+			diagnostics: []diag{{1, models.SeverityWarning}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, diagnostics, err := ParseSRTWithOptions(tt.content, SRTOptions{})
+			if err != nil {
+				t.Fatalf("宽松模式返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("字幕 = %+v，期望 %+v", entries, tt.entries)
+			}
+			var got []diag
+			for _, d := range diagnostics {
+				got = append(got, diag{d.Line, d.Severity})
+			}
+			if !reflect.DeepEqual(got, tt.diagnostics) {
+				t.Errorf("诊断信息 = %+v，期望 %+v", diagnostics, tt.diagnostics)
+			}
+
+			// 严格模式在第一个警告或错误处失败，提示信息只有提示级别时正常解析
+			var firstProblem *diag
+			for i, d := range tt.diagnostics {
+				if d.severity != models.SeverityInfo {
+					firstProblem = &tt.diagnostics[i]
+					break
+				}
+			}
+			strictEntries, _, err := ParseSRTWithOptions(tt.content, SRTOptions{Strict: true})
+			if firstProblem == nil {
+				if err != nil {
+					t.Fatalf("严格模式返回错误: %v", err)
+				}
+				if !reflect.DeepEqual(strictEntries, tt.entries) {
+					t.Errorf("严格模式字幕 = %+v，期望 %+v", strictEntries, tt.entries)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("严格模式应返回错误")
+			}
+			if want := fmt.Sprintf("第%d行", firstProblem.line); !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("严格模式错误 = %q，期望以 %q 开头", err, want)
+			}
+		})
+	}
+}
+
+func TestBuildSRTRenumbers(t *testing.T) {
+	entries := []models.SubtitleEntry{
+		{Index: 5, TimeRange: "00:00:01.000 --> 00:00:02.000 align:start", Content: "Hello"},
+		{Index: 9, TimeRange: "00:00:03,000 --> 00:00:04,000", Content: "World"},
+	}
+	want := "1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:00:03,000 --> 00:00:04,000\nWorld"
+	if got := BuildSRT(entries, "translated"); got != want {
+		t.Errorf("BuildSRT = %q，期望 %q", got, want)
+	}
+}
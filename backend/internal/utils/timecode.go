@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampPattern 匹配 [HH:]MM:SS[,.]mmm 形式的时间戳，小时位数不限
+var timestampPattern = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{1,2})(?:[,.](\d{1,3}))?$`)
+
+// ParseTimestamp 解析SRT/VTT时间戳为时长，支持逗号或点作为毫秒分隔符
+func ParseTimestamp(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	matches := timestampPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return 0, fmt.Errorf("无效的时间戳: %q", s)
+	}
+
+	hours := 0
+	if matches[1] != "" {
+		hours, _ = strconv.Atoi(matches[1])
+	}
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.Atoi(matches[3])
+	if minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("无效的时间戳: %q", s)
+	}
+
+	millis := 0
+	if frac := matches[4]; frac != "" {
+		// 不足三位的小数按小数处理，例如 .5 表示500毫秒
+		for len(frac) < 3 {
+			frac += "0"
+		}
+		millis, _ = strconv.Atoi(frac)
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(millis)*time.Millisecond, nil
+}
+
+// FormatSRTTimestamp 将时长格式化为SRT时间戳 (HH:MM:SS,mmm)
+func FormatSRTTimestamp(d time.Duration) string {
+	return formatTimestamp(d, ",")
+}
+
+// FormatVTTTimestamp 将时长格式化为VTT时间戳 (HH:MM:SS.mmm)
+func FormatVTTTimestamp(d time.Duration) string {
+	return formatTimestamp(d, ".")
+}
+
+// formatTimestamp 按指定的毫秒分隔符格式化时间戳，负数按0处理
+func formatTimestamp(d time.Duration, sep string) string {
+	if d < 0 {
+		d = 0
+	}
+	totalMillis := int64((d + time.Millisecond/2) / time.Millisecond)
+	hours := totalMillis / 3600000
+	minutes := (totalMillis % 3600000) / 60000
+	seconds := (totalMillis % 60000) / 1000
+	millis := totalMillis % 1000
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", hours, minutes, seconds, sep, millis)
+}
+
+// TimeRange 表示解析后的时间范围
+type TimeRange struct {
+	Start    time.Duration // 开始时间
+	End      time.Duration // 结束时间
+	Settings string        // 时间行之后的附加内容，例如VTT的cue设置
+	Sep      string        // 原始毫秒分隔符，"," 或 "."
+}
+
+// ParseTimeRange 解析 "start --> end [settings]" 形式的时间范围
+func ParseTimeRange(timeRange string) (TimeRange, error) {
+	parts := strings.SplitN(timeRange, "-->", 2)
+	if len(parts) != 2 {
+		return TimeRange{}, fmt.Errorf("无效的时间范围: %q", timeRange)
+	}
+
+	startStr := strings.TrimSpace(parts[0])
+	rest := strings.Fields(parts[1])
+	if len(rest) == 0 {
+		return TimeRange{}, fmt.Errorf("无效的时间范围: %q", timeRange)
+	}
+	endStr := rest[0]
+
+	start, err := ParseTimestamp(startStr)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	end, err := ParseTimestamp(endStr)
+	if err != nil {
+		return TimeRange{}, err
+	}
+
+	sep := ","
+	if strings.Contains(startStr, ".") {
+		sep = "."
+	}
+
+	return TimeRange{
+		Start:    start,
+		End:      end,
+		Settings: strings.Join(rest[1:], " "),
+		Sep:      sep,
+	}, nil
+}
+
+// String 按原始分隔符格式化时间范围，保留附加设置
+func (tr TimeRange) String() string {
+	sep := tr.Sep
+	if sep == "" {
+		sep = ","
+	}
+	s := formatTimestamp(tr.Start, sep) + " --> " + formatTimestamp(tr.End, sep)
+	if tr.Settings != "" {
+		s += " " + tr.Settings
+	}
+	return s
+}
+
+// Duration 返回时间范围的持续时长
+func (tr TimeRange) Duration() time.Duration {
+	return tr.End - tr.Start
+}
@@ -19,8 +19,13 @@ func BuildVTT(entries []models.SubtitleEntry, outputFormat string) string {
 		// 写入序号（可选）
 		builder.WriteString(fmt.Sprintf("%d\n", entry.Index))
 
-		// 直接使用原始的时间范围
-		builder.WriteString(fmt.Sprintf("%s\n", entry.TimeRange))
+		// 时间范围统一使用VTT的点号毫秒分隔符
+		timeRange := entry.TimeRange
+		if tr, err := ParseTimeRange(timeRange); err == nil {
+			tr.Sep = "."
+			timeRange = tr.String()
+		}
+		builder.WriteString(fmt.Sprintf("%s\n", timeRange))
 
 		// 根据输出格式写入内容
 		if outputFormat == "bilingual" {
@@ -35,45 +40,88 @@ func BuildVTT(entries []models.SubtitleEntry, outputFormat string) string {
 	return builder.String()
 }
 
-// ParseVTT 解析VTT格式字幕内容
-func ParseVTT(content string) ([]models.SubtitleEntry, error) {
+// vttTimePattern 匹配VTT时间行，小时可省略，允许行尾的cue设置
+var vttTimePattern = regexp.MustCompile(`^((?:\d{2,}:)?\d{2}:\d{2}\.\d{3})\s+-->\s+((?:\d{2,}:)?\d{2}:\d{2}\.\d{3})(\s.*)?$`)
+
+// ParseVTT 解析VTT格式字幕内容，返回被跳过的块的诊断信息
+func ParseVTT(content string) ([]models.SubtitleEntry, []models.Diagnostic, error) {
 	var entries []models.SubtitleEntry
+	var diagnostics []models.Diagnostic
+	report := func(line int, severity, message, raw string) {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Line:     line,
+			Severity: severity,
+			Message:  message,
+			Raw:      raw,
+		})
+	}
+
+	// 移除BOM并统一换行符
+	lines := splitLines(content)
 
-	// 移除BOM和WEBVTT头部
-	content = strings.TrimSpace(content)
-	content = strings.TrimPrefix(content, "WEBVTT")
-	content = strings.TrimSpace(content)
+	// 按空行切分为块，记录每块的起始行号
+	type block struct {
+		line  int
+		lines []string
+	}
+	var blocks []block
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "" {
+			i++
+			continue
+		}
+		b := block{line: i + 1}
+		for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+			b.lines = append(b.lines, strings.TrimRight(lines[i], " \t"))
+			i++
+		}
+		blocks = append(blocks, b)
+	}
 
-	// 按空行分割
-	blocks := strings.Split(content, "\n\n")
+	if len(blocks) == 0 {
+		return entries, diagnostics, nil
+	}
+	if first := blocks[0]; strings.HasPrefix(first.lines[0], "WEBVTT") {
+		blocks = blocks[1:]
+	} else {
+		report(first.line, models.SeverityWarning, "缺少WEBVTT文件头", first.lines[0])
+	}
 
 	index := 1
-	for _, block := range blocks {
-		lines := strings.Split(strings.TrimSpace(block), "\n")
-		if len(lines) >= 2 {
-			// 第一行是时间范围
-			timeRange := strings.TrimSpace(lines[0])
-
-			// 验证时间范围格式
-			timeRegex := regexp.MustCompile(`^(\d{2}:\d{2}:\d{2}\.\d{3})\s+-->\s+(\d{2}:\d{2}:\d{2}\.\d{3})`)
-			if timeRegex.MatchString(timeRange) {
-				// 剩余的是内容
-				content := strings.Join(lines[1:], "\n")
-				content = strings.TrimSpace(content)
-
-				if content != "" {
-					entries = append(entries, models.SubtitleEntry{
-						Index:     index,
-						TimeRange: timeRange,
-						Content:   content,
-					})
-					index++
-				}
-			}
+	for _, b := range blocks {
+		head := b.lines[0]
+		// 注释、样式和区域定义块不包含字幕
+		if head == "NOTE" || strings.HasPrefix(head, "NOTE ") || head == "STYLE" || head == "REGION" {
+			continue
+		}
+
+		// 第一行可以是cue标识符
+		timeLine, offset := 0, 0
+		if !strings.Contains(head, "-->") && len(b.lines) > 1 {
+			timeLine, offset = 1, 1
 		}
+		timeRange := strings.TrimSpace(b.lines[timeLine])
+		if !vttTimePattern.MatchString(timeRange) {
+			report(b.line+offset, models.SeverityError, "时间行无效，已跳过该字幕", b.lines[timeLine])
+			continue
+		}
+
+		// 剩余的是内容
+		text := strings.TrimSpace(strings.Join(b.lines[timeLine+1:], "\n"))
+		if text == "" {
+			report(b.line+offset, models.SeverityWarning, "字幕文本为空，已跳过", b.lines[timeLine])
+			continue
+		}
+
+		entries = append(entries, models.SubtitleEntry{
+			Index:     index,
+			TimeRange: timeRange,
+			Content:   text,
+		})
+		index++
 	}
 
-	return entries, nil
+	return entries, diagnostics, nil
 }
 
 // SecondsToVTTTime 将秒数转换为VTT时间格式 (HH:MM:SS.mmm)
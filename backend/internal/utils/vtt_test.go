@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frank0/subtitleTranslate/internal/models"
+)
+
+func TestParseVTTDiagnostics(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		entries     []models.SubtitleEntry
+		diagnostics []models.Diagnostic
+	}{
+		{
+			name:    "规范文件",
+			content: "WEBVTT\n\nNOTE 注释\n\n1\n00:00:01.000 --> 00:00:02.000\nHello\n",
+			entries: []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01.000 --> 00:00:02.000", Content: "Hello"}},
+		},
+		{
+			// 带标识符的块中时间行位于第二行，诊断指向时间行本身
+			name:    "时间行无效",
+			content: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n\ncue-2\n00:00:03 -> 00:00:04\nBroken\n\nnot a cue\n\n00:00:05.000 --> 00:00:06.000\nWorld\n",
+			entries: []models.SubtitleEntry{
+				{Index: 1, TimeRange: "00:00:01.000 --> 00:00:02.000", Content: "Hello"},
+				{Index: 2, TimeRange: "00:00:05.000 --> 00:00:06.000", Content: "World"},
+			},
+			diagnostics: []models.Diagnostic{
+				{Line: 7, Severity: models.SeverityError, Raw: "00:00:03 -> 00:00:04"},
+				{Line: 10, Severity: models.SeverityError, Raw: "not a cue"},
+			},
+		},
+		{
+			name:    "缺少文件头",
+			content: "00:00:01.000 --> 00:00:02.000\nHello\n",
+			entries: []models.SubtitleEntry{{Index: 1, TimeRange: "00:00:01.000 --> 00:00:02.000", Content: "Hello"}},
+			diagnostics: []models.Diagnostic{
+				{Line: 1, Severity: models.SeverityWarning, Raw: "00:00:01.000 --> 00:00:02.000"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, diagnostics, err := ParseVTT(tt.content)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(entries, tt.entries) {
+				t.Errorf("字幕 = %+v，期望 %+v", entries, tt.entries)
+			}
+			if got := withoutMessages(diagnostics); !reflect.DeepEqual(got, tt.diagnostics) {
+				t.Errorf("诊断信息 = %+v，期望 %+v", diagnostics, tt.diagnostics)
+			}
+		})
+	}
+}
@@ -3,26 +3,79 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/frank0/subtitleTranslate/api/middleware"
 	"github.com/frank0/subtitleTranslate/api/routes"
 	"github.com/frank0/subtitleTranslate/config"
+	"github.com/frank0/subtitleTranslate/internal/auth"
+	"github.com/frank0/subtitleTranslate/internal/cli"
+	"github.com/frank0/subtitleTranslate/internal/logging"
+	"github.com/frank0/subtitleTranslate/internal/services"
+	"github.com/frank0/subtitleTranslate/internal/usage"
 )
 
+// usageFlushInterval 用量记录写入文件的间隔
+const usageFlushInterval = 5 * time.Second
+
 func main() {
+	// 命令行子命令
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	// 加载配置
-	cfg, err := config.Load()
+	configPath := config.Path()
+	cfg, err := config.LoadFile(configPath)
+	if err != nil {
+		fatal("failed to load configuration", err)
+	}
+
+	// 结构化日志
+	if err := logging.Setup(os.Stderr, logging.Options{
+		Level:   cfg.Log.Level,
+		Format:  cfg.Log.Format,
+		Content: cfg.Log.Content,
+	}); err != nil {
+		fatal("invalid log configuration", err)
+	}
+
+	// 启用认证时打开API密钥库
+	var keys *auth.Store
+	if cfg.Auth.Enabled {
+		keys, err = auth.Open(cfg.Auth.KeyStore)
+		if err != nil {
+			fatal("failed to open API key store", err)
+		}
+		if len(keys.List()) == 0 {
+			slog.Warn("API authentication is enabled but the key store has no keys; create one with: subtitleTranslate keys create -name admin -scopes admin",
+				"key_store", cfg.Auth.KeyStore)
+		}
+	}
+
+	// 用量记录，用于限流和字符配额
+	usageStore, err := usage.Open(cfg.Limits.UsageFile)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("failed to open usage file", err)
 	}
 
+	// 各提供商的速率限制
+	services.Configure(cfg)
+
 	// 设置路由
-	router := routes.SetupRouter()
+	limiter := middleware.NewLimiter(cfg.Limits, usageStore)
+	router := routes.SetupRouter(routes.Options{
+		Keys:    keys,
+		Limiter: limiter,
+		Server:  cfg.Server,
+	})
 
 	// 创建HTTP服务器
 	server := &http.Server{
@@ -34,24 +87,145 @@ func main() {
 
 	// 在goroutine中启动服务器
 	go func() {
-		log.Printf("Server is running on port %d", cfg.Server.Port)
+		slog.Info("server is running", "port", cfg.Server.Port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal("failed to start server", err)
 		}
 	}()
 
+	// 收到SIGHUP或配置文件变化时重新加载配置
+	configReloader := &reloader{path: configPath, current: cfg, limiter: limiter, keys: keys}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go func() {
+		for {
+			select {
+			case <-hup:
+				configReloader.reload("signal")
+			case <-watchCtx.Done():
+				return
+			}
+		}
+	}()
+	// 用量记录定期写入文件，关闭时写入最后一次
+	flushDone := make(chan struct{})
+	go func() {
+		usageStore.FlushEvery(watchCtx, usageFlushInterval)
+		close(flushDone)
+	}()
+	if cfg.Server.ConfigWatchSeconds > 0 {
+		interval := time.Duration(cfg.Server.ConfigWatchSeconds) * time.Second
+		go config.Watch(watchCtx, configPath, interval, func() { configReloader.reload("file") })
+	}
+
 	// 优雅关闭
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
+	stopWatch()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeoutSeconds)*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		usageStore.Flush()
+		fatal("server forced to shutdown", err)
+	}
+	<-flushDone
+	// 关闭期间完成的请求也写入用量文件
+	if err := usageStore.Flush(); err != nil {
+		slog.Error("failed to save usage", "error", err)
+	}
+
+	slog.Info("server exiting")
+}
+
+// reloader 重新加载配置并应用到运行中的服务
+type reloader struct {
+	path    string
+	limiter *middleware.Limiter
+	keys    *auth.Store // 未启用认证时为nil
+
+	mu      sync.Mutex
+	current *config.Config
+}
+
+// reload 重新读取配置文件，无效时保留原配置
+// 提供商密钥、请求设置、重试熔断、价格、客户端限流配额和日志设置立即生效，其余设置需要重启
+// API密钥库同时重新读取，即使配置文件无效
+func (r *reloader) reload(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.keys != nil {
+		if err := r.keys.Reload(); err != nil {
+			slog.Error("failed to reload API key store", "trigger", trigger, "error", err)
+		}
+	}
+
+	cfg, err := config.LoadFile(r.path)
+	if err != nil {
+		slog.Error("failed to reload configuration, keeping the previous one", "trigger", trigger, "path", r.path, "error", err)
+		return
+	}
+	if err := logging.Setup(os.Stderr, logging.Options{
+		Level:   cfg.Log.Level,
+		Format:  cfg.Log.Format,
+		Content: cfg.Log.Content,
+	}); err != nil {
+		slog.Error("invalid log configuration", "error", err)
+	}
+	services.Configure(cfg)
+	r.limiter.SetLimits(cfg.Limits)
+
+	if changed := restartRequired(r.current, cfg); len(changed) > 0 {
+		slog.Warn("some configuration changes take effect only after a restart", "settings", changed)
+	}
+	r.current = cfg
+	slog.Info("configuration reloaded", "trigger", trigger, "path", r.path)
+}
+
+// restartRequired 返回重新加载后不会生效的配置项
+func restartRequired(old, cfg *config.Config) []string {
+	var changed []string
+	if old.Server.Port != cfg.Server.Port {
+		changed = append(changed, "server.port")
+	}
+	if old.Server.ReadTimeoutSeconds != cfg.Server.ReadTimeoutSeconds {
+		changed = append(changed, "server.readTimeoutSeconds")
+	}
+	if old.Server.WriteTimeoutSeconds != cfg.Server.WriteTimeoutSeconds {
+		changed = append(changed, "server.writeTimeoutSeconds")
+	}
+	if old.Server.RequestTimeoutSeconds != cfg.Server.RequestTimeoutSeconds {
+		changed = append(changed, "server.requestTimeoutSeconds")
+	}
+	if old.Server.ShutdownTimeoutSeconds != cfg.Server.ShutdownTimeoutSeconds {
+		changed = append(changed, "server.shutdownTimeoutSeconds")
+	}
+	if !slices.Equal(old.Server.AllowedOrigins, cfg.Server.AllowedOrigins) {
+		changed = append(changed, "server.allowedOrigins")
+	}
+	if !slices.Equal(old.Server.TrustedProxies, cfg.Server.TrustedProxies) {
+		changed = append(changed, "server.trustedProxies")
+	}
+	if old.Server.ConfigWatchSeconds != cfg.Server.ConfigWatchSeconds {
+		changed = append(changed, "server.configWatchSeconds")
+	}
+	if old.Auth != cfg.Auth {
+		changed = append(changed, "auth")
+	}
+	if old.Limits.UsageFile != cfg.Limits.UsageFile {
+		changed = append(changed, "limits.usageFile")
 	}
+	return changed
+}
 
-	log.Println("Server exiting")
+// fatal 记录错误并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
\ No newline at end of file